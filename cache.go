@@ -0,0 +1,96 @@
+package glob
+
+import (
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A CachingFS wraps a filesystem and memoizes the results of ReadDir and Stat, including errors, so that each
+// directory is read and each path is looked up at most once. This avoids repeated operations when several patterns
+// or several matches visit the same directories.
+//
+// A CachingFS does not observe changes to the underlying filesystem, so it should be used for the duration of a
+// single match or a set of related matches and then discarded, or told of changes with Invalidate. Open is not cached.
+// A CachingFS is safe for concurrent use if the underlying filesystem is.
+type CachingFS struct {
+	fsys fs.FS
+
+	m     sync.Mutex
+	dirs  map[string]cachedDir
+	stats map[string]cachedStat
+}
+
+type cachedDir struct {
+	entries []fs.DirEntry
+	err     error
+}
+
+type cachedStat struct {
+	info fs.FileInfo
+	err  error
+}
+
+var (
+	_ = fs.ReadDirFS((*CachingFS)(nil))
+	_ = fs.StatFS((*CachingFS)(nil))
+)
+
+// NewCachingFS returns a CachingFS that wraps fsys.
+func NewCachingFS(fsys fs.FS) *CachingFS {
+	return &CachingFS{fsys: fsys, dirs: map[string]cachedDir{}, stats: map[string]cachedStat{}}
+}
+
+// Open opens the named file in the underlying filesystem.
+func (c *CachingFS) Open(name string) (fs.File, error) {
+	return c.fsys.Open(name)
+}
+
+// ReadDir reads the named directory, or returns the result of an earlier read. The returned slice is a copy that the
+// caller may modify.
+func (c *CachingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	c.m.Lock()
+	d, ok := c.dirs[name]
+	c.m.Unlock()
+	if !ok {
+		d.entries, d.err = fs.ReadDir(c.fsys, name)
+
+		c.m.Lock()
+		c.dirs[name] = d
+		c.m.Unlock()
+	}
+	return slices.Clone(d.entries), d.err
+}
+
+// Stat returns information about the named file, or the result of an earlier call.
+func (c *CachingFS) Stat(name string) (fs.FileInfo, error) {
+	c.m.Lock()
+	s, ok := c.stats[name]
+	c.m.Unlock()
+	if !ok {
+		s.info, s.err = fs.Stat(c.fsys, name)
+
+		c.m.Lock()
+		c.stats[name] = s
+		c.m.Unlock()
+	}
+	return s.info, s.err
+}
+
+// Invalidate discards the cached results for the named path, for the paths beneath it, and for the listing of its
+// parent directory. It should be called when the path is created, removed, or modified.
+func (c *CachingFS) Invalidate(name string) {
+	name = path.Clean(name)
+	stale := func(p string) bool {
+		return p == name || name == "." || strings.HasPrefix(p, name+"/")
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	maps.DeleteFunc(c.dirs, func(p string, _ cachedDir) bool { return stale(p) })
+	maps.DeleteFunc(c.stats, func(p string, _ cachedStat) bool { return stale(p) })
+	delete(c.dirs, path.Dir(name))
+}
@@ -0,0 +1,70 @@
+package glob
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingFS(t *testing.T) {
+	fsys := &countingFS{MapFS: fstest.MapFS{
+		"src/a.go":     {},
+		"src/b.txt":    {},
+		"src/lib/c.go": {},
+		"docs/d.md":    {},
+	}, reads: map[string]int{}, stats: map[string]int{}}
+	cache := NewCachingFS(fsys)
+
+	// Both matches visit src; the second match is answered entirely from the cache.
+	g, err := New([]string{"src/**/*.go", "docs/*.md", "missing/x.go"}, nil)
+	require.NoError(t, err)
+	for range 2 {
+		matches, err := Collect(g.Match(cache, ".", false))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"docs/d.md", "src/a.go", "src/lib/c.go"}, matches)
+	}
+	g, err = New([]string{"src/lib/c.go"}, nil)
+	require.NoError(t, err)
+	for range 2 {
+		matches, err := Collect(g.Match(cache, ".", false))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"src/lib/c.go"}, matches)
+	}
+
+	assert.Equal(t, map[string]int{".": 1, "src": 1, "src/lib": 1, "docs": 1}, fsys.reads)
+	assert.Equal(t, map[string]int{"src/lib/c.go": 1}, fsys.stats)
+
+	// Errors are cached, and the cached entries may be modified by the caller.
+	_, err = cache.ReadDir("missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = cache.ReadDir("missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Equal(t, 1, fsys.reads["missing"])
+
+	entries, err := cache.ReadDir("src")
+	require.NoError(t, err)
+	entries[0] = nil
+	entries, err = cache.ReadDir("src")
+	require.NoError(t, err)
+	assert.Equal(t, "a.go", entries[0].Name())
+}
+
+// countingFS counts the number of times each directory is read and each path is looked up.
+type countingFS struct {
+	fstest.MapFS
+	reads map[string]int
+	stats map[string]int
+}
+
+func (fsys *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsys.reads[name]++
+	return fsys.MapFS.ReadDir(name)
+}
+
+func (fsys *countingFS) Stat(name string) (fs.FileInfo, error) {
+	fsys.stats[name]++
+	return fsys.MapFS.Stat(name)
+}
@@ -0,0 +1,334 @@
+package glob
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A Diagnostic describes a problem with a single pattern in a set of include and exclude patterns.
+type Diagnostic struct {
+	// Pattern is the pattern text.
+	Pattern string
+	// Exclude is true if the pattern is an exclude pattern.
+	Exclude bool
+	// Index is the index of the pattern in its list.
+	Index int
+	// Message describes the problem.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	kind := "include"
+	if d.Exclude {
+		kind = "exclude"
+	}
+	return fmt.Sprintf("%v %q: %v", kind, d.Pattern, d.Message)
+}
+
+// Check validates a set of include and exclude patterns and reports patterns that are malformed, redundant, or have no
+// effect.
+//
+// A pattern is redundant if it duplicates an earlier pattern in the same list or if every path it matches is also
+// matched by another pattern in the same list. An include pattern is shadowed if every path it matches is excluded by
+// an exclude pattern, and an exclude pattern has no effect if it cannot match any path that an include pattern may
+// match. The analyses are conservative: they report only problems that they can prove from the structure of the
+// patterns. If any exclude pattern is negated, the order of the exclude patterns is significant and they are not
+// checked for redundancy or shadowing.
+func Check(includes, excludes []string) []Diagnostic {
+	var diags []Diagnostic
+	includeSteps, includeDiags := checkPatterns(includes, false)
+	excludeSteps, excludeDiags := checkPatterns(excludes, true)
+	diags = append(diags, includeDiags...)
+	diags = append(diags, excludeDiags...)
+
+	diags = append(diags, checkRedundant(includes, includeSteps, false, covers)...)
+	if !hasNegations(excludes) {
+		diags = append(diags, checkRedundant(excludes, excludeSteps, true, excludeCovers)...)
+		diags = append(diags, checkShadowed(includes, excludes, includeSteps, excludeSteps)...)
+	}
+	diags = append(diags, checkUnreachable(excludes, includeSteps, excludeSteps)...)
+	return diags
+}
+
+// checkShadowed reports include patterns whose every match is excluded by a single exclude pattern.
+func checkShadowed(includes, excludes []string, includeSteps, excludeSteps [][]pattern) []Diagnostic {
+	var diags []Diagnostic
+	for i, p := range includeSteps {
+		if p == nil {
+			continue
+		}
+		for j, q := range excludeSteps {
+			if q != nil && coversAll(q, p, excludeCovers) {
+				message := fmt.Sprintf("shadowed: every path it matches is excluded by %q", excludes[j])
+				diags = append(diags, Diagnostic{Pattern: includes[i], Index: i, Message: message})
+				break
+			}
+		}
+	}
+	return diags
+}
+
+// checkUnreachable reports exclude patterns that cannot match any path that an include pattern may match. If an
+// include pattern failed to compile, no exclude pattern is reported.
+func checkUnreachable(excludes []string, includeSteps, excludeSteps [][]pattern) []Diagnostic {
+	if slices.ContainsFunc(includeSteps, func(ps []pattern) bool { return ps == nil }) {
+		return nil
+	}
+
+	var diags []Diagnostic
+	for i, q := range excludeSteps {
+		if q == nil {
+			continue
+		}
+		reachable := slices.ContainsFunc(includeSteps, func(ps []pattern) bool {
+			return slices.ContainsFunc(ps, func(p pattern) bool {
+				return slices.ContainsFunc(q, func(q pattern) bool { return mayExclude(q, p) })
+			})
+		})
+		if !reachable {
+			message := "has no effect: it matches no path that an include pattern may match"
+			diags = append(diags, Diagnostic{Pattern: excludes[i], Exclude: true, Index: i, Message: message})
+		}
+	}
+	return diags
+}
+
+// mayExclude reports whether the exclude pattern q may match some path matched by the include pattern p or one of the
+// path's ancestors. The analysis is conservative: mayExclude returns false only if it can prove that no such path
+// exists.
+func mayExclude(q, p pattern) bool {
+	memo := map[[2]int]bool{}
+
+	var overlapsAt func(i, j int) bool
+	overlapsAt = func(i, j int) bool {
+		key := [2]int{i, j}
+		if v, ok := memo[key]; ok {
+			return v
+		}
+
+		var result bool
+		switch {
+		case j == len(q):
+			// q has matched a non-empty prefix of a path that p may still complete.
+			result = true
+		case i == len(p):
+			// The path has ended, but q requires more names.
+			result = false
+		case p[i].isGlobstar() && q[j].isGlobstar():
+			result = true
+		case p[i].isGlobstar() && i == len(p)-1:
+			// A trailing '**' may complete any remainder of q.
+			result = true
+		case p[i].isGlobstar():
+			result = overlapsAt(i+1, j) || overlapsAt(i, j+1)
+		case q[j].isGlobstar() && j == len(q)-1:
+			result = true
+		case q[j].isGlobstar():
+			result = overlapsAt(i, j+1) || overlapsAt(i+1, j)
+		default:
+			result = stepsIntersect(p[i], q[j]) && overlapsAt(i+1, j+1)
+		}
+		memo[key] = result
+		return result
+	}
+	return overlapsAt(0, 0)
+}
+
+// stepsIntersect reports whether some name may be matched by both p and q. The analysis is conservative: stepsIntersect
+// returns false only if one of the steps is a literal that the other does not match, or if the steps require
+// incompatible literal prefixes or suffixes.
+func stepsIntersect(p, q step) bool {
+	pm, qm := p.matcher, q.matcher
+	switch {
+	case pm.fold || qm.fold:
+		// A folded literal stands for several names.
+		return true
+	case pm.kind == segmentLiteral:
+		return q.match(pm.literal)
+	case qm.kind == segmentLiteral:
+		return p.match(qm.literal)
+	case pm.kind == segmentPrefix && qm.kind == segmentPrefix:
+		return strings.HasPrefix(pm.literal, qm.literal) || strings.HasPrefix(qm.literal, pm.literal)
+	case pm.kind == segmentSuffix && qm.kind == segmentSuffix:
+		return strings.HasSuffix(pm.literal, qm.literal) || strings.HasSuffix(qm.literal, pm.literal)
+	default:
+		return true
+	}
+}
+
+// checkPatterns compiles each of the given patterns. Patterns that fail to compile have no alternatives.
+func checkPatterns(patterns []string, exclude bool) ([][]pattern, []Diagnostic) {
+	var diags []Diagnostic
+	steps := make([][]pattern, len(patterns))
+	for i, p := range patterns {
+		text := p
+		if exclude {
+			text = strings.TrimPrefix(p, "!")
+		}
+		s, err := compilePattern(text, Options{})
+		if err != nil {
+			se := locateError(err, p, exclude, i)
+			message := fmt.Sprintf("syntax error at offset %d: %v", se.Start, se.Message)
+			diags = append(diags, Diagnostic{Pattern: p, Exclude: exclude, Index: i, Message: message})
+			continue
+		}
+		steps[i] = s
+
+		for _, alt := range s {
+			if e, ok := innerGlobstar(alt); ok {
+				message := fmt.Sprintf("'**' within element %q matches like '*' and does not cross directories", e)
+				diags = append(diags, Diagnostic{Pattern: p, Exclude: exclude, Index: i, Message: message})
+				break
+			}
+		}
+	}
+	return steps, diags
+}
+
+// innerGlobstar returns the first element of p that contains an unescaped '**' alongside other characters.
+func innerGlobstar(p pattern) (string, bool) {
+	for _, s := range p {
+		if !s.isGlobstar() && hasInnerGlobstar(s.text) {
+			return s.text, true
+		}
+	}
+	return "", false
+}
+
+// hasInnerGlobstar reports whether the element text contains an unescaped '**' outside of a character class.
+func hasInnerGlobstar(text string) bool {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			// Skip to the end of the class. The class is known to be well-formed.
+			_, rest, _ := compileClass(text[i+1:], false)
+			i = len(text) - len(rest) - 1
+		case '*':
+			if i+1 < len(text) && text[i+1] == '*' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRedundant reports patterns that duplicate or are covered by other patterns in the same list.
+func checkRedundant(patterns []string, steps [][]pattern, exclude bool, covers func(q, p pattern) bool) []Diagnostic {
+	var diags []Diagnostic
+	for i, p := range steps {
+		if p == nil {
+			continue
+		}
+		for j, q := range steps {
+			if i == j || q == nil {
+				continue
+			}
+
+			var message string
+			switch {
+			case patterns[i] == patterns[j]:
+				if j > i {
+					continue
+				}
+				message = fmt.Sprintf("duplicates pattern %d", j)
+			case coversAll(q, p, covers):
+				// If two patterns cover each other, report only the later one.
+				if j > i && coversAll(p, q, covers) {
+					continue
+				}
+				message = fmt.Sprintf("redundant: every path it matches is also matched by %q", patterns[j])
+			default:
+				continue
+			}
+			diags = append(diags, Diagnostic{Pattern: patterns[i], Exclude: exclude, Index: i, Message: message})
+			break
+		}
+	}
+	return diags
+}
+
+// coversAll reports whether every alternative in ps is covered by some alternative in qs.
+func coversAll(qs, ps []pattern, covers func(q, p pattern) bool) bool {
+	for _, p := range ps {
+		if !slices.ContainsFunc(qs, func(q pattern) bool { return covers(q, p) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// covers reports whether every path matched by p is also matched by q.
+func covers(q, p pattern) bool {
+	// q cannot cover p if it is stricter about case or leading dots. Flags apply to entire patterns, so it is
+	// sufficient to compare the first steps.
+	if p.fold() && !q.fold() || q[0].hideDot && !p[0].hideDot {
+		return false
+	}
+
+	memo := map[[2]int]bool{}
+
+	var coversAt func(i, j int) bool
+	coversAt = func(i, j int) bool {
+		key := [2]int{i, j}
+		if v, ok := memo[key]; ok {
+			return v
+		}
+
+		var result bool
+		switch {
+		case i == len(q):
+			result = j == len(p)
+		case q[i].isGlobstar() && i == len(q)-1:
+			// A trailing '**' matches one or more names, and so covers any non-empty remainder of p.
+			result = j < len(p)
+		case q[i].isGlobstar():
+			// Any other '**' matches zero or more names, and so may absorb any number of p's elements.
+			result = coversAt(i+1, j) || (j < len(p) && coversAt(i, j+1))
+		case j == len(p) || p[j].isGlobstar():
+			result = false
+		default:
+			result = stepCovers(q[i], p[j]) && coversAt(i+1, j+1)
+		}
+		memo[key] = result
+		return result
+	}
+	return coversAt(0, 0)
+}
+
+// fold reports whether p matches without regard to case.
+func (p pattern) fold() bool {
+	for _, s := range p {
+		if s.matcher != nil {
+			return s.matcher.fold
+		}
+	}
+	return false
+}
+
+// excludeCovers reports whether every path excluded by p is also excluded by q. Because an exclude pattern that
+// matches a directory excludes the directory's contents, q covers p if it covers any prefix of p.
+func excludeCovers(q, p pattern) bool {
+	for k := 1; k <= len(p); k++ {
+		if covers(q, p[:k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// stepCovers reports whether every name matched by p is also matched by q.
+func stepCovers(q, p step) bool {
+	switch {
+	case q.text == p.text:
+		return true
+	case q.matcher.kind == segmentAny:
+		return true
+	case p.matcher.kind == segmentLiteral:
+		return q.match(p.matcher.literal)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	diags := Check(
+		[]string{"src/**/*.go", "src/main.go", "[", "docs/*", "docs/*", "**", "a/**/b"},
+		[]string{"**/testdata", "src/testdata/x.go", "vendor/**", "vendor/a/b"},
+	)
+
+	var messages []string
+	for _, d := range diags {
+		messages = append(messages, d.String())
+	}
+	assert.Equal(t, []string{
+		`include "[": syntax error at offset 0: unterminated character class`,
+		`include "src/**/*.go": redundant: every path it matches is also matched by "**"`,
+		`include "src/main.go": redundant: every path it matches is also matched by "src/**/*.go"`,
+		`include "docs/*": redundant: every path it matches is also matched by "**"`,
+		`include "docs/*": duplicates pattern 3`,
+		`include "a/**/b": redundant: every path it matches is also matched by "**"`,
+		`exclude "src/testdata/x.go": redundant: every path it matches is also matched by "**/testdata"`,
+		`exclude "vendor/a/b": redundant: every path it matches is also matched by "vendor/**"`,
+	}, messages)
+}
+
+func TestCheckInnerGlobstar(t *testing.T) {
+	diags := Check([]string{"src/foo**bar/*.go", `a/\**`, "a/[**]x", "**/b", "x**"}, nil)
+	assert.Equal(t, []Diagnostic{
+		{Pattern: "src/foo**bar/*.go", Message: `'**' within element "foo**bar" matches like '*' and does not cross directories`},
+		{Pattern: "x**", Index: 4, Message: `'**' within element "x**" matches like '*' and does not cross directories`},
+	}, diags)
+}
+
+func TestCovers(t *testing.T) {
+	cases := []struct {
+		q, p     string
+		expected bool
+	}{
+		{"**", "a/b/c", true},
+		{"**", "**/a", true},
+		{"a/**", "a", false},
+		{"a/**", "a/b", true},
+		{"a/**", "a/**/b", true},
+		{"**/*.go", "src/main.go", true},
+		{"**/*.go", "src/*.go", true},
+		{"**/*.go", "src/*.c", false},
+		{"src/*", "src/[ab]", true},
+		{"src/a*", "src/[ab]", false},
+		{"src/[a-c]", "src/b", true},
+		{"*/x", "**/x", false},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/**/b", true},
+		{"a/**/b", "a/b", true},
+		{"a/**", "a", false},
+		{"(?i)*.md", "*.md", true},
+		{"*.md", "(?i)*.md", false},
+		{"(?-d)**", "a/b", false},
+		{"**", "(?-d)a/b", true},
+		{"src/**{0,2}/*.go", "src/a/*.go", true},
+		{"src/**{0,2}/*.go", "src/**{1}/*.go", true},
+		{"src/**{1}/*.go", "src/**{0,2}/*.go", false},
+		{"src/**", "src/**{1,3}", true},
+	}
+	for _, c := range cases {
+		q, err := compilePattern(c.q, Options{})
+		require.NoError(t, err)
+		p, err := compilePattern(c.p, Options{})
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, coversAll(q, p, covers), "%v covers %v", c.q, c.p)
+	}
+}
+
+func TestCheckShadowing(t *testing.T) {
+	diags := Check(
+		[]string{"src/**/*.go", "build/out/*.o", "docs/*.md", "gen/*.go"},
+		[]string{"build", "gen/*.go", "vendor/**", "docs/*.txt", "**/testdata", "src/*.c"},
+	)
+	assert.Equal(t, []Diagnostic{
+		{Pattern: "build/out/*.o", Index: 1, Message: `shadowed: every path it matches is excluded by "build"`},
+		{Pattern: "gen/*.go", Index: 3, Message: `shadowed: every path it matches is excluded by "gen/*.go"`},
+		{Pattern: "vendor/**", Exclude: true, Index: 2, Message: "has no effect: it matches no path that an include pattern may match"},
+		{Pattern: "docs/*.txt", Exclude: true, Index: 3, Message: "has no effect: it matches no path that an include pattern may match"},
+	}, diags)
+
+	// Negated excludes may re-include shadowed paths, but an exclude that no include reaches still has no effect.
+	diags = Check([]string{"build/**"}, []string{"build", "!build/keep", "!docs"})
+	assert.Equal(t, []Diagnostic{
+		{Pattern: "!docs", Exclude: true, Index: 2, Message: "has no effect: it matches no path that an include pattern may match"},
+	}, diags)
+}
+
+func TestMayExclude(t *testing.T) {
+	cases := []struct {
+		q, p     string
+		expected bool
+	}{
+		{"a", "a/b", true},
+		{"a/b", "a", false},
+		{"a/b", "a/**", true},
+		{"a/b", "a/*", true},
+		{"b", "a/**", false},
+		{"**/b", "a/*.go", false},
+		{"**/b", "a/**/x", true},
+		{"*.go", "src/*.go", false},
+		{"src/*.go", "src/*.c", false},
+		{"src/*.go", "src/**/*.c", true},
+		{"src/**", "src/**/*.c", true},
+		{"src/a/**", "src/**/*.c", true},
+		{"(?i)SRC", "src/*.go", true},
+		{"**", "a", true},
+		{"*.go", "x*", true},
+		{"x*", "y*", false},
+		{"x*", "xy*", true},
+		{"*.tar.gz", "*.gz", true},
+	}
+	for _, c := range cases {
+		q, err := compilePattern(c.q, Options{})
+		require.NoError(t, err)
+		p, err := compilePattern(c.p, Options{})
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, mayExclude(q[0], p[0]), "%v excludes %v", c.q, c.p)
+	}
+}
@@ -0,0 +1,78 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobResume(t *testing.T) {
+	paths := []string{
+		"a/0/aa.go",
+		"a/0/ab.txt",
+		"a/1/aa.go",
+		"a/1/x/y/z.go",
+		"a/b.go",
+		"b.go",
+		"c/d/e.go",
+		"c/d/f.go",
+		"d.go",
+	}
+
+	cases := []struct {
+		includes, excludes []string
+	}{
+		{[]string{"**"}, nil},
+		{[]string{"**/*.go"}, []string{"a/1"}},
+		{[]string{"a/*/aa.go", "c/d/*.go", "a/1/x/y/z.go"}, nil},
+		{[]string{"c/d/*.go"}, nil},
+	}
+	for _, c := range cases {
+		g, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+
+		for _, mode := range []DirMode{DirsNone, DirsPre, DirsPost, DirsBoth} {
+			for _, dir := range []string{"root", "./root/"} {
+				fsys := newReadDirFS(prefixPaths("root", paths)...)
+
+				// Record the output of an uninterrupted match and the number of paths yielded at each checkpoint.
+				type checkpoint struct {
+					dir   string
+					index int
+				}
+				var expected []string
+				var checkpoints []checkpoint
+				options := MatchOptions{Dirs: mode, IncludeRoot: true, Checkpoint: func(dir string) {
+					checkpoints = append(checkpoints, checkpoint{dir, len(expected)})
+				}}
+				for p, err := range MatchWith(g, fsys, dir, options) {
+					require.NoError(t, err)
+					expected = append(expected, p)
+				}
+				require.NotEmpty(t, checkpoints)
+
+				for _, cp := range checkpoints {
+					fsys := newReadDirFS(prefixPaths("root", paths)...)
+					matches, err := Collect(MatchWith(g, fsys, dir, MatchOptions{Dirs: mode, IncludeRoot: true, Resume: cp.dir}))
+					require.NoError(t, err)
+					assert.Equal(t, expected[cp.index:], append([]string{}, matches...), "%v %v %v %v", c.includes, c.excludes, mode, cp.dir)
+
+					// The directories that were finished are not read again.
+					for read := range fsys.reads {
+						assert.False(t, read == cp.dir || len(read) > len(cp.dir) && read[:len(cp.dir)+1] == cp.dir+"/", "%v read after %v", read, cp.dir)
+					}
+				}
+			}
+		}
+	}
+}
+
+// prefixPaths joins each of the given paths to dir.
+func prefixPaths(dir string, paths []string) []string {
+	prefixed := make([]string, len(paths))
+	for i, p := range paths {
+		prefixed[i] = dir + "/" + p
+	}
+	return prefixed
+}
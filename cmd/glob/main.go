@@ -0,0 +1,132 @@
+// Command glob prints the paths in a directory tree that match a set of patterns.
+//
+// Usage:
+//
+//	glob [-x exclude]... [-d] [--null] [--json] [--from-file file] PATTERN... [DIR]
+//
+// Each PATTERN is an include pattern in the syntax accepted by glob.New. If the last argument names an existing
+// directory and is not the only pattern, that directory is searched instead of the current directory.
+//
+// The flags are:
+//
+//	-x pattern
+//		Exclude paths that match pattern. May be repeated.
+//	-d
+//		Print matching directories before their contents.
+//	--null
+//		Terminate each path with a NUL character instead of a newline.
+//	--json
+//		Print each result as a JSON object on its own line.
+//	--from-file file
+//		Read additional patterns from file, one per line. Blank lines and lines that begin with '#' are ignored, and
+//		lines that begin with '!' are excludes. A file of "-" reads patterns from standard input.
+//		See glob.ParseIgnoreFile for the full syntax.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pgavlin/glob"
+	"github.com/pgavlin/glob/internal/cmdflags"
+)
+
+// result is the JSON form of a single match.
+type result struct {
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("glob", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: glob [-x exclude]... [-d] [--null] [--json] [--from-file file] PATTERN... [DIR]")
+		flags.PrintDefaults()
+	}
+
+	var excludes, fromFiles cmdflags.Strings
+	flags.Var(&excludes, "x", "exclude paths that match `pattern` (may be repeated)")
+	flags.Var(&fromFiles, "from-file", "read patterns from `file` (may be repeated)")
+	dirs := flags.Bool("d", false, "print matching directories before their contents")
+	null := flags.Bool("null", false, "terminate each path with a NUL character")
+	jsonOutput := flags.Bool("json", false, "print each result as a JSON object")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	includes := flags.Args()
+	root := "."
+	if len(includes) > 1 || len(includes) == 1 && len(fromFiles) != 0 {
+		if info, err := os.Stat(includes[len(includes)-1]); err == nil && info.IsDir() {
+			root, includes = includes[len(includes)-1], includes[:len(includes)-1]
+		}
+	}
+
+	for _, f := range fromFiles {
+		fileIncludes, fileExcludes, err := cmdflags.ReadPatternFile(f, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "glob: %v\n", err)
+			return 2
+		}
+		includes, excludes = append(includes, fileIncludes...), append(excludes, fileExcludes...)
+	}
+	if len(includes) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	g, err := glob.New(includes, excludes)
+	if err != nil {
+		fmt.Fprintf(stderr, "glob: %v\n", err)
+		return 2
+	}
+
+	var options glob.OSOptions
+	if *dirs {
+		options.Dirs = glob.DirsPre
+	}
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	encoder := json.NewEncoder(w)
+
+	status := 0
+	for p, err := range glob.MatchOS(g, root, options) {
+		if err != nil {
+			status = 1
+		}
+
+		switch {
+		case *jsonOutput:
+			r := result{Path: p}
+			if err != nil {
+				r.Error = err.Error()
+			}
+			if err := encoder.Encode(r); err != nil {
+				fmt.Fprintf(stderr, "glob: %v\n", err)
+				return 1
+			}
+		case err != nil:
+			fmt.Fprintf(stderr, "glob: %v: %v\n", p, err)
+		default:
+			terminator := "\n"
+			if *null {
+				terminator = "\x00"
+			}
+			if _, err := io.WriteString(w, p+terminator); err != nil {
+				fmt.Fprintf(stderr, "glob: %v\n", err)
+				return 1
+			}
+		}
+	}
+	return status
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTree(t *testing.T, paths ...string) string {
+	root := t.TempDir()
+	for _, p := range paths {
+		p = filepath.Join(root, filepath.FromSlash(p))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, nil, 0o644))
+	}
+	return root
+}
+
+func runGlob(t *testing.T, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	root := makeTree(t, "a/b.go", "a/b_test.go", "a/c/d.go", "e.txt")
+	j := func(elems ...string) string { return filepath.Join(append([]string{root}, elems...)...) }
+
+	status, stdout, _ := runGlob(t, "", "-x", "**/*_test.go", "**/*.go", root)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, j("a", "b.go")+"\n"+j("a", "c", "d.go")+"\n", stdout)
+
+	status, stdout, _ = runGlob(t, "", "-d", "--null", "a/**", root)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, []string{j("a", "b.go"), j("a", "b_test.go"), j("a", "c"), j("a", "c", "d.go"), ""}, strings.Split(stdout, "\x00"))
+
+	status, stdout, _ = runGlob(t, "", "--json", "*.txt", root)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, `{"path":"`+j("e.txt")+`"}`+"\n", stdout)
+
+	status, stdout, _ = runGlob(t, "# comment\n\n**/*.go\n!a/c/**\n", "--from-file", "-", root)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, j("a", "b.go")+"\n"+j("a", "b_test.go")+"\n", stdout)
+}
+
+func TestRunErrors(t *testing.T) {
+	status, _, stderr := runGlob(t, "")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "usage")
+
+	status, _, stderr = runGlob(t, "", "[")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "syntax error in pattern")
+
+	status, _, stderr = runGlob(t, "", "--from-file", filepath.Join(t.TempDir(), "missing"), "*")
+	assert.Equal(t, 2, status)
+	assert.NotEmpty(t, stderr)
+}
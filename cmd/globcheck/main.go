@@ -0,0 +1,125 @@
+// Command globcheck validates and explains sets of glob patterns.
+//
+// Usage:
+//
+//	globcheck check [-i include]... [-x exclude]... [--from-file file]...
+//	globcheck why [-i include]... [-x exclude]... [--from-file file]... PATH...
+//
+// The check subcommand reports malformed and redundant patterns and exits with a non-zero status if it finds any. The
+// why subcommand reports whether each PATH is matched and which patterns are responsible.
+//
+// The flags are:
+//
+//	-i pattern
+//		Include paths that match pattern. May be repeated.
+//	-x pattern
+//		Exclude paths that match pattern. May be repeated.
+//	--from-file file
+//		Read patterns from file, one per line. Blank lines and lines that begin with '#' are ignored, and lines that
+//		begin with '!' are excludes. A file of "-" reads patterns from standard input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pgavlin/glob"
+	"github.com/pgavlin/glob/internal/cmdflags"
+)
+
+const usage = `usage:
+  globcheck check [-i include]... [-x exclude]... [--from-file file]...
+  globcheck why [-i include]... [-x exclude]... [--from-file file]... PATH...
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || (args[0] != "check" && args[0] != "why") {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("globcheck "+command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	var includes, excludes, fromFiles cmdflags.Strings
+	flags.Var(&includes, "i", "include paths that match `pattern` (may be repeated)")
+	flags.Var(&excludes, "x", "exclude paths that match `pattern` (may be repeated)")
+	flags.Var(&fromFiles, "from-file", "read patterns from `file` (may be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	for _, f := range fromFiles {
+		fileIncludes, fileExcludes, err := cmdflags.ReadPatternFile(f, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "globcheck: %v\n", err)
+			return 2
+		}
+		includes, excludes = append(includes, fileIncludes...), append(excludes, fileExcludes...)
+	}
+
+	if command == "check" {
+		if flags.NArg() != 0 {
+			flags.Usage()
+			return 2
+		}
+		return check(includes, excludes, stdout)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	return why(includes, excludes, flags.Args(), stdout, stderr)
+}
+
+func check(includes, excludes []string, stdout io.Writer) int {
+	diags := glob.Check(includes, excludes)
+	for _, d := range diags {
+		fmt.Fprintln(stdout, d)
+	}
+	if len(diags) != 0 {
+		return 1
+	}
+	return 0
+}
+
+func why(includes, excludes, paths []string, stdout, stderr io.Writer) int {
+	g, err := glob.New(includes, excludes)
+	if err != nil {
+		fmt.Fprintf(stderr, "globcheck: %v\n", err)
+		return 2
+	}
+
+	for _, p := range paths {
+		e := glob.ExplainPath(g, p)
+		switch {
+		case e.Matched:
+			fmt.Fprintf(stdout, "%v: matched by include %v\n", p, quoteAll(e.Includes))
+		case len(e.Includes) == 0:
+			fmt.Fprintf(stdout, "%v: not matched: no include pattern matches\n", p)
+		default:
+			fmt.Fprintf(stdout, "%v: not matched: included by %v but excluded by %v\n", p, quoteAll(e.Includes), quoteAll(e.Excludes))
+		}
+	}
+	return 0
+}
+
+func quoteAll(patterns []string) string {
+	quoted := make([]string, len(patterns))
+	for i, p := range patterns {
+		quoted[i] = fmt.Sprintf("%q", p)
+	}
+	return strings.Join(quoted, ", ")
+}
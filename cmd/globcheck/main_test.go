@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runGlobcheck(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestCheck(t *testing.T) {
+	status, stdout, _ := runGlobcheck("", "check", "-i", "src/**", "-i", "src/main.go", "-x", "[")
+	assert.Equal(t, 1, status)
+	assert.Equal(t, `exclude "[": syntax error at offset 0: unterminated character class
+include "src/main.go": redundant: every path it matches is also matched by "src/**"
+`, stdout)
+
+	status, stdout, _ = runGlobcheck("src/**\n!**/testdata\n", "check", "--from-file", "-")
+	assert.Equal(t, 0, status)
+	assert.Empty(t, stdout)
+}
+
+func TestWhy(t *testing.T) {
+	status, stdout, _ := runGlobcheck("# config\nsrc/**/*.go\n!**/testdata\n", "why", "--from-file", "-",
+		"src/a/main.go", "src/testdata/x.go", "lib/x.go")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, `src/a/main.go: matched by include "src/**/*.go"
+src/testdata/x.go: not matched: included by "src/**/*.go" but excluded by "**/testdata"
+lib/x.go: not matched: no include pattern matches
+`, stdout)
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"frob"}, {"why"}, {"check", "x"}, {"check", "-z"}} {
+		status, _, stderr := runGlobcheck("", args...)
+		assert.Equal(t, 2, status, "%v", args)
+		assert.Contains(t, stderr, "usage", "%v", args)
+	}
+}
@@ -0,0 +1,202 @@
+package glob
+
+import (
+	"io/fs"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+)
+
+// A coverage describes how much of a subtree a glob matches.
+type coverage int
+
+const (
+	// coverSome means that the glob may match some paths in the subtree, so each path must be matched.
+	coverSome coverage = iota
+	// coverNone means that the glob matches no path in the subtree.
+	coverNone
+	// coverAll means that the glob matches every path in the subtree.
+	coverAll
+)
+
+// A complementNode is the state of a glob at a directory visited by MatchComplement.
+type complementNode interface {
+	// coverage reports how much of the subtree beneath the directory the glob matches.
+	coverage() coverage
+	// child returns the node for the subdirectory with the given name.
+	child(name string) complementNode
+	// matchFile reports whether the glob matches the file in the directory with the given name.
+	matchFile(name string) bool
+}
+
+// newComplementNode returns the node for the starting directory of a match of g.
+func newComplementNode(g Glob) complementNode {
+	switch g := g.(type) {
+	case *matchGlob:
+		return patternNode{include: g.include, exclude: g.exclude}
+	case allGlob:
+		return patternNode{include: allPatterns}
+	case noneGlob:
+		return patternNode{}
+	default:
+		return globNode{g: g, scope: scopeFunc(g)}
+	}
+}
+
+// A patternNode tracks the include and exclude patterns that apply beneath a directory, as MatchPath does.
+type patternNode struct {
+	include []pattern
+	exclude []pattern
+	e       exclusion
+}
+
+func (n patternNode) coverage() coverage {
+	switch {
+	case len(n.include) == 0 || n.e.final(n.exclude) || excludesAll(n.e, n.exclude):
+		return coverNone
+	case always(n.include) && len(n.exclude) == 0 && !n.e.excluded():
+		return coverAll
+	default:
+		return coverSome
+	}
+}
+
+func (n patternNode) child(name string) complementNode {
+	next := patternNode{e: n.e}
+	for _, p := range n.exclude {
+		if p.matchDir(name, &next.exclude) {
+			next.e = next.e.update(p)
+		}
+	}
+	for _, p := range n.include {
+		p.matchDir(name, &next.include)
+	}
+	return next
+}
+
+func (n patternNode) matchFile(name string) bool {
+	var rest []pattern
+	e := n.e
+	for _, p := range n.exclude {
+		if p.matchDir(name, &rest) {
+			e = e.update(p)
+		}
+	}
+	if e.excluded() {
+		return false
+	}
+	for _, p := range n.include {
+		if p.matchDir(name, &rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// A globNode matches the paths beneath a directory with MatchPath. It is used for globs whose patterns are not
+// available, such as Layered globs.
+type globNode struct {
+	g     Glob
+	scope func(names []string) (bool, bool)
+	names []string
+}
+
+func (n globNode) coverage() coverage {
+	if _, mayContain := n.scope(n.names); !mayContain {
+		return coverNone
+	}
+	return coverSome
+}
+
+func (n globNode) child(name string) complementNode {
+	return globNode{g: n.g, scope: n.scope, names: append(slices.Clip(n.names), name)}
+}
+
+func (n globNode) matchFile(name string) bool {
+	return n.g.MatchPath(strings.Join(append(slices.Clip(n.names), name), "/"))
+}
+
+// MatchComplement returns a sequence of (string, error) pairs for the files under dir that g does not match: the files
+// that MatchWith would not yield, in the order in which MatchWith visits them. A license scanner, for example, can use
+// MatchComplement to find every file that is not covered by a list of allowed patterns.
+//
+// The traversal is performed with the given options, which apply as they do to MatchWith, so that, for example, Dirs
+// yields the directories that g does not match and MaxOps bounds the filesystem operations performed. MatchComplement
+// reads every directory that may contain a file that g does not match. A directory beneath which g matches every path,
+// as it does beneath 'vendor' for the include pattern 'vendor/**', is not read, and the files beneath a directory in
+// which g matches no path, such as a directory excluded by an exclude pattern, are yielded without being matched. For
+// globs that were not created by New or NewWithOptions, every directory is read, and each path is matched with
+// MatchPath.
+//
+// As with MatchWith, the error portion of a pair is only non-nil when the path portion is a directory that cannot be
+// read; the traversal continues with the directory's siblings.
+func MatchComplement(g Glob, fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
+	dir = path.Clean(dir)
+	return func(yield func(string, error) bool) {
+		t := &complementTracker{nodes: []complementNode{newComplementNode(g)}}
+		t.coverages = []coverage{t.nodes[0].coverage()}
+		if t.coverages[0] == coverAll {
+			return
+		}
+
+		// Directories beneath which g matches every path are not entered, and the paths that g matches are not yielded.
+		options.descend = allowDescent(options.descend, func(p string, _ fs.DirEntry) bool {
+			_, c := t.node(splitPath(relativePath(dir, p)))
+			return c != coverAll
+		})
+		filter := options.filter
+		options.filter = func(p string) bool {
+			return (filter == nil || filter(p)) && t.excluded(splitPath(relativePath(dir, p)))
+		}
+
+		w := newWalker(fsys, options, yield)
+		w.walk(dir, allPatterns, nil)
+	}
+}
+
+// A complementTracker holds the complement nodes for the directories between the starting directory and the most
+// recently matched path, so that each path is matched by its name alone. The nodes are kept on a stack rather than
+// computed recursively, as the traversal itself is.
+type complementTracker struct {
+	// dirs holds the names of the directories on the stack, and nodes and coverages hold the node and its coverage for
+	// the starting directory and for each of those directories.
+	dirs      []string
+	nodes     []complementNode
+	coverages []coverage
+}
+
+// node returns the node and coverage for the directory with the given names relative to the starting directory.
+func (t *complementTracker) node(names []string) (complementNode, coverage) {
+	shared := 0
+	for shared < len(names) && shared < len(t.dirs) && names[shared] == t.dirs[shared] {
+		shared++
+	}
+	t.dirs, t.nodes, t.coverages = t.dirs[:shared], t.nodes[:shared+1], t.coverages[:shared+1]
+	for _, name := range names[shared:] {
+		n, c := t.nodes[len(t.nodes)-1], t.coverages[len(t.coverages)-1]
+		if c != coverNone {
+			n = n.child(name)
+			c = n.coverage()
+		}
+		t.dirs, t.nodes, t.coverages = append(t.dirs, name), append(t.nodes, n), append(t.coverages, c)
+	}
+	return t.nodes[len(t.nodes)-1], t.coverages[len(t.coverages)-1]
+}
+
+// excluded reports whether the path with the given names relative to the starting directory is not matched by the
+// glob.
+func (t *complementTracker) excluded(names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	n, c := t.node(names[:len(names)-1])
+	switch c {
+	case coverNone:
+		return true
+	case coverAll:
+		return false
+	default:
+		return !n.matchFile(names[len(names)-1])
+	}
+}
@@ -0,0 +1,156 @@
+package glob
+
+import (
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchComplement(t *testing.T) {
+	fsys := fstest.MapFS{
+		"LICENSE":                 {},
+		"README.md":               {},
+		".github/ci.yml":          {},
+		"src/main.go":             {},
+		"src/main_test.go":        {},
+		"src/lib/lib.go":          {},
+		"src/lib/data.bin":        {},
+		"src/testdata/x.go":       {},
+		"vendor/a/a.go":           {},
+		"vendor/a/LICENSE":        {},
+		"vendor/b/sub/b.go":       {},
+		"docs/guide/intro.md":     {},
+		"docs/guide/image.png":    {},
+		"docs/generated/api.html": {},
+	}
+
+	cases := []struct {
+		includes, excludes []string
+		options            Options
+	}{
+		{[]string{"**/*.go"}, nil, Options{}},
+		{[]string{"**/*.go", "vendor/**", "LICENSE"}, []string{"**/testdata"}, Options{}},
+		{[]string{"**"}, []string{"docs", "!docs/guide", "**/*.png"}, Options{}},
+		{[]string{"**/*.go"}, []string{"vendor/**", "!vendor"}, Options{}},
+		{[]string{"**/*.go"}, []string{"vendor/**", "!vendor/a"}, Options{}},
+		{[]string{"**"}, nil, Options{ExplicitDot: true}},
+		{[]string{"**"}, nil, Options{}},
+		{[]string{"src"}, nil, Options{}},
+		{[]string{"*.md"}, []string{"**"}, Options{}},
+		{nil, nil, Options{}},
+	}
+	for _, c := range cases {
+		g, err := NewWithOptions(c.includes, c.excludes, c.options)
+		require.NoError(t, err)
+
+		// The complement contains exactly the files that MatchPath does not match.
+		var expected []string
+		err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && !g.MatchPath(p) {
+				expected = append(expected, p)
+			}
+			return err
+		})
+		require.NoError(t, err)
+
+		for _, g := range []Glob{g, foreignGlob{g}} {
+			actual, err := Collect(MatchComplement(g, fsys, ".", MatchOptions{}))
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual, "%v %v", c.includes, c.excludes)
+		}
+	}
+
+	layered, err := NewLayered(Layer{Includes: []string{"**"}}, Layer{Excludes: []string{"**/*.html"}})
+	require.NoError(t, err)
+	actual, err := Collect(MatchComplement(layered, fsys, "docs", MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/generated/api.html"}, actual)
+}
+
+func TestMatchComplementTraversal(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"src/main.go":         {},
+			"src/notes.txt":       {},
+			"vendor/a/a.go":       {},
+			"vendor/b/b.go":       {},
+			"third_party/x/x.txt": {},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+
+	g, err := New([]string{"**/*.go", "vendor/**"}, []string{"third_party"})
+	require.NoError(t, err)
+	actual, err := Collect(MatchComplement(g, fsys, ".", MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/notes.txt", "third_party/x/x.txt"}, actual)
+
+	// Directories whose contents are all matched are not read.
+	assert.Equal(t, []string{".", "src", "third_party", "third_party/x"}, slices.Sorted(maps.Keys(fsys.reads)))
+
+	failing := readDirFailFS{MapFS: fsys.MapFS, dir: "src"}
+	var paths []string
+	var errs []error
+	for p, err := range MatchComplement(g, failing, ".", MatchOptions{}) {
+		paths = append(paths, p)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	assert.Equal(t, []string{"src", "third_party/x/x.txt"}, paths)
+	assert.Len(t, errs, 1)
+}
+
+func TestMatchComplementOptions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":     {},
+		"src/main.go":   {},
+		"src/notes.txt": {},
+		"src/lib/a.go":  {},
+		"vendor/a/a.go": {},
+		"docs/x.md":     {},
+	}
+	g, err := New([]string{"**/*.go", "vendor/**"}, nil)
+	require.NoError(t, err)
+
+	// Directories that g does not match are yielded before their contents.
+	var expected []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && p != "." && !g.MatchPath(p) && !strings.HasPrefix(p, "vendor/") {
+			expected = append(expected, p)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	actual, err := Collect(MatchComplement(g, fsys, ".", MatchOptions{Dirs: DirsPre}))
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	scope, err := New([]string{"src"}, nil)
+	require.NoError(t, err)
+	actual, err = Collect(MatchComplement(g, fsys, ".", MatchOptions{Scope: scope}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/notes.txt"}, actual)
+
+	_, err = Collect(MatchComplement(g, fsys, ".", MatchOptions{MaxOps: 1}))
+	var budget *BudgetExceededError
+	assert.ErrorAs(t, err, &budget)
+}
+
+func TestMatchComplementDeep(t *testing.T) {
+	// The traversal does not recurse once per directory.
+	deep := strings.Repeat("d/", 2000) + "x.txt"
+	fsys := fstest.MapFS{deep: {}, "a.go": {}}
+	g, err := New([]string{"*.go"}, nil)
+	require.NoError(t, err)
+	actual, err := Collect(MatchComplement(g, fsys, ".", MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{deep}, actual)
+}
@@ -0,0 +1,45 @@
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Complete returns the completions of a partially typed path that may still lead to a match of g. The partial path is
+// slash-separated and relative to the root of fsys; its last element is the prefix of the name being typed, and the
+// elements before it name the directory whose entries are completed. Each completion is the partial path with its last
+// element replaced by the name of an entry of that directory that begins with the prefix. An entry is offered if g
+// matches it or, if it is a directory, if g may match a path beneath it; directory completions end in a slash.
+//
+// Complete reads only the directory being completed. If that directory does not exist or g cannot match anything
+// beneath it, Complete returns no completions.
+func Complete(g Glob, fsys fs.FS, partial string) ([]string, error) {
+	typed, prefix := "", partial
+	if i := strings.LastIndexByte(partial, '/'); i != -1 {
+		typed, prefix = partial[:i+1], partial[i+1:]
+	}
+	dir := path.Clean("./" + typed)
+
+	entries, err := NewFilterFS(fsys, g).ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var completions []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		completion := typed + e.Name()
+		if e.IsDir() {
+			completion += "/"
+		}
+		completions = append(completions, completion)
+	}
+	return completions, nil
+}
@@ -0,0 +1,55 @@
+package glob
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplete(t *testing.T) {
+	g, err := New([]string{"src/**/*.go", "docs/*.md"}, []string{"src/vendor"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		partial  string
+		expected []string
+	}{
+		{"", []string{"docs/", "src/"}},
+		{"s", []string{"src/"}},
+		{"src/", []string{"src/a.go", "src/lib/", "src/main.go"}},
+		{"src/m", []string{"src/main.go"}},
+		{"./src/l", []string{"./src/lib/"}},
+		{"src/lib/", []string{"src/lib/c.go"}},
+		{"docs/", []string{"docs/d.md"}},
+		{"src/vendor/", nil},
+		{"lib/", nil},
+		{"missing/", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.partial, func(t *testing.T) {
+			fsys := &countingFS{MapFS: fstest.MapFS{
+				"src/a.go":        {},
+				"src/main.go":     {},
+				"src/b.txt":       {},
+				"src/lib/c.go":    {},
+				"src/vendor/v.go": {},
+				"docs/d.md":       {},
+				"README.md":       {},
+			}, reads: map[string]int{}, stats: map[string]int{}}
+
+			completions, err := Complete(g, fsys, c.partial)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, completions)
+			for _, n := range fsys.reads {
+				assert.Equal(t, 1, n)
+			}
+			assert.LessOrEqual(t, len(fsys.reads), 1)
+		})
+	}
+
+	_, err = Complete(g, fstest.MapFS{}, "src/\xff/")
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+}
@@ -0,0 +1,56 @@
+package glob
+
+// DepthRange reports the minimum and maximum depths, as computed by Depth, at which g may match a path beneath the
+// directory being matched. If g may match paths at any depth, as it may if one of its include patterns contains '**',
+// max is -1. If g matches no paths, ok is false. Walkers and watchers that do not use this package can use the range to
+// bound their recursion.
+//
+// The range is conservative: g need not match a path at every depth within it, as exclude patterns are not considered.
+// The starting directory has depth 0 and is only in range if g has an include pattern of '**'. A glob not created by
+// this package may match paths at any depth.
+func DepthRange(g Glob) (min, max int, ok bool) {
+	switch g := g.(type) {
+	case *matchGlob:
+		if excludesAll(exclusion{}, g.exclude) {
+			return 0, 0, false
+		}
+		return patternDepths(g.include)
+	case noneGlob:
+		return 0, 0, false
+	case *Layered:
+		return DepthRange(g.union)
+	default:
+		return 0, -1, true
+	}
+}
+
+// patternDepths returns the range of depths at which the given patterns may match.
+func patternDepths(patterns []pattern) (minDepth, maxDepth int, ok bool) {
+	unbounded := false
+	for i, p := range patterns {
+		lo, hi := 0, 0
+		for j, s := range p {
+			switch {
+			case !s.isGlobstar():
+				lo, hi = lo+1, hi+1
+			case len(p) == 1:
+				// A lone '**' also matches the starting directory.
+				unbounded = true
+			case j == len(p)-1:
+				// A final '**' must match at least one name.
+				lo, unbounded = lo+1, true
+			default:
+				// A '**' before the end of a pattern may match no names.
+				unbounded = true
+			}
+		}
+		if i == 0 {
+			minDepth, maxDepth = lo, hi
+		}
+		minDepth, maxDepth = min(minDepth, lo), max(maxDepth, hi)
+	}
+	if unbounded {
+		maxDepth = -1
+	}
+	return minDepth, maxDepth, len(patterns) != 0
+}
@@ -0,0 +1,42 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepthRange(t *testing.T) {
+	cases := []struct {
+		includes, excludes []string
+		min, max           int
+		ok                 bool
+	}{
+		{[]string{"*.go"}, nil, 1, 1, true},
+		{[]string{"src/*.go", "cmd/*/main.go"}, nil, 2, 3, true},
+		{[]string{"**"}, nil, 0, -1, true},
+		{[]string{"**"}, []string{"vendor"}, 0, -1, true},
+		{[]string{"**/*.go"}, nil, 1, -1, true},
+		{[]string{"src/**"}, nil, 2, -1, true},
+		{[]string{"a/**/b"}, nil, 2, -1, true},
+		{[]string{"a/**{1,2}/b"}, nil, 3, 4, true},
+		{[]string{"*/*/x", "y"}, nil, 1, 3, true},
+		{[]string{"src/*.go"}, []string{"**"}, 0, 0, false},
+	}
+	for _, c := range cases {
+		g, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+
+		min, max, ok := DepthRange(g)
+		assert.Equal(t, []any{c.min, c.max, c.ok}, []any{min, max, ok}, "%v %v", c.includes, c.excludes)
+	}
+
+	layered, err := NewLayered(Layer{Includes: []string{"docs/*.md"}}, Layer{Includes: []string{"README.md"}})
+	require.NoError(t, err)
+	min, max, ok := DepthRange(layered)
+	assert.Equal(t, []any{1, 2, true}, []any{min, max, ok})
+
+	min, max, ok = DepthRange(foreignGlob{layered})
+	assert.Equal(t, []any{0, -1, true}, []any{min, max, ok})
+}
@@ -0,0 +1,120 @@
+package glob
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Describe returns a human-readable description of how a match of g traverses a directory tree.
+//
+// The description is a tree of the directories that a match may visit, starting with the directory being matched.
+// Each directory is described by how the match treats it:
+//
+//   - "read": the directory's entries are read and matched against the listed include and exclude patterns. The
+//     directories beneath it that are named by literal pattern elements are described beneath it; any other
+//     subdirectory that matches a pattern is read in the same manner.
+//   - "read all": the directory and all of its subdirectories are read, and every entry that is not excluded matches.
+//   - "skipped": the directory is named by a literal pattern element, so the match proceeds directly to the
+//     directory beneath it without reading it.
+//   - "stat": the directory's only candidate is a single literal name, which is looked up directly.
+//   - "excluded": the directory and its contents are excluded.
+//
+// A "read all" or a "read" with a leading '**' include marks a directory beneath which every subdirectory is read.
+func Describe(g Glob) string {
+	var b strings.Builder
+	switch g := g.(type) {
+	case *matchGlob:
+		describeDir(&b, ".", g.include, g.exclude, exclusion{}, 0)
+	case allGlob:
+		b.WriteString(".: read all\n")
+	case noneGlob:
+		b.WriteString(".: nothing matches\n")
+	case *Layered:
+		b.WriteString("layered: the match is bounded by the union of the layers' includes\n")
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(Describe(g.union), "\n"), "\n") {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	default:
+		fmt.Fprintf(&b, "%T: structure unknown\n", g)
+	}
+	return b.String()
+}
+
+// describeDir describes the traversal of the named directory, which is matched against the given patterns. e records
+// the exclude pattern that last matched the directory or one of its ancestors.
+func describeDir(b *strings.Builder, name string, include, exclude []pattern, e exclusion, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	if always(include) {
+		fmt.Fprintf(b, "%v%v: read all\n", indent, name)
+		describePatterns(b, indent, nil, exclude)
+		return
+	}
+
+	if lit, nextInclude, ok := literal(include); ok {
+		if len(nextInclude) == 0 {
+			fmt.Fprintf(b, "%v%v: stat %q\n", indent, name, lit)
+			return
+		}
+		fmt.Fprintf(b, "%v%v: skipped\n", indent, name)
+		describeChild(b, lit, include, exclude, e, depth+1)
+		return
+	}
+
+	fmt.Fprintf(b, "%v%v: read\n", indent, name)
+	describePatterns(b, indent, include, exclude)
+
+	var children []string
+	for _, p := range include {
+		if lit := p[0].text; !hasMeta(lit) && p[0].matcher.exact() && len(p) > 1 && !slices.Contains(children, lit) {
+			children = append(children, lit)
+		}
+	}
+	slices.Sort(children)
+	for _, child := range children {
+		describeChild(b, child, include, exclude, e, depth+1)
+	}
+}
+
+// describeChild describes the traversal of the named subdirectory of a directory that is matched against the given
+// patterns.
+func describeChild(b *strings.Builder, name string, include, exclude []pattern, e exclusion, depth int) {
+	var nextInclude, nextExclude []pattern
+	for _, p := range exclude {
+		if p.matchDir(name, &nextExclude) {
+			e = e.update(p)
+		}
+	}
+	if e.final(nextExclude) || excludesAll(e, nextExclude) {
+		fmt.Fprintf(b, "%v%v: excluded\n", strings.Repeat("  ", depth), name)
+		return
+	}
+	for _, p := range include {
+		p.matchDir(name, &nextInclude)
+	}
+	describeDir(b, name, nextInclude, nextExclude, e, depth)
+}
+
+// describePatterns lists the include and exclude patterns that apply to a directory.
+func describePatterns(b *strings.Builder, indent string, include, exclude []pattern) {
+	if len(include) != 0 {
+		fmt.Fprintf(b, "%v  include: %v\n", indent, joinPatterns(include))
+	}
+	if len(exclude) != 0 {
+		fmt.Fprintf(b, "%v  exclude: %v\n", indent, joinPatterns(exclude))
+	}
+}
+
+// joinPatterns formats a list of patterns.
+func joinPatterns(patterns []pattern) string {
+	texts := make([]string, len(patterns))
+	for i, p := range patterns {
+		texts[i] = p.String()
+		if p[0].negate {
+			texts[i] = "!" + texts[i]
+		}
+	}
+	return strings.Join(texts, ", ")
+}
@@ -0,0 +1,92 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	cases := []struct {
+		includes, excludes []string
+		expected           string
+	}{
+		{
+			includes: []string{"src/**/*.go", "docs/*.md", "cmd/glob/main.go", "cmd/glob/*.txt"},
+			excludes: []string{"**/testdata", "docs/internal"},
+			expected: `.: read
+  include: src/**/*.go, docs/*.md, cmd/glob/main.go, cmd/glob/*.txt
+  exclude: **/testdata, testdata, docs/internal
+  cmd: skipped
+    glob: read
+      include: main.go, *.txt
+      exclude: **/testdata, testdata
+  docs: read
+    include: *.md
+    exclude: **/testdata, testdata, internal
+  src: read
+    include: **/*.go, *.go
+    exclude: **/testdata, testdata
+`,
+		},
+		{
+			includes: []string{"a/b/c/*.go"},
+			expected: `.: skipped
+  a: skipped
+    b: skipped
+      c: read
+        include: *.go
+`,
+		},
+		{
+			includes: []string{"a/b.go"},
+			expected: `.: skipped
+  a: stat "b.go"
+`,
+		},
+		{
+			includes: []string{"vendor/x/*"},
+			excludes: []string{"vendor", "!vendor/x/keep"},
+			expected: `.: skipped
+  vendor: skipped
+    x: read
+      include: *
+      exclude: !keep
+`,
+		},
+		{
+			includes: []string{"vendor/x/*"},
+			excludes: []string{"vendor"},
+			expected: `.: skipped
+  vendor: excluded
+`,
+		},
+		{
+			includes: []string{"**"},
+			excludes: []string{"**/testdata"},
+			expected: `.: read all
+  exclude: **/testdata, testdata
+`,
+		},
+		{includes: []string{"**"}, expected: ".: read all\n"},
+		{includes: nil, expected: ".: nothing matches\n"},
+	}
+	for _, c := range cases {
+		g, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, Describe(g), "%v %v", c.includes, c.excludes)
+	}
+
+	layered, err := NewLayered(Layer{Includes: []string{"a/*"}}, Layer{Includes: []string{"a/b/*"}})
+	require.NoError(t, err)
+	assert.Equal(t, `layered: the match is bounded by the union of the layers' includes
+  .: skipped
+    a: read
+      include: *, b/*
+      b: read
+        include: *
+`, Describe(layered))
+
+	assert.Equal(t, "glob.pathGlob: structure unknown\n", Describe(pathGlob("a")))
+}
@@ -0,0 +1,135 @@
+package glob
+
+import "strings"
+
+// A GlobDiff describes the differences between the patterns of two globs. Patterns are compared by meaning rather than
+// by text: a pattern is unchanged if the other glob has a pattern of the same kind that matches exactly the same paths,
+// as 'a/**/**/b' and 'a/**/b' do, and reordering the patterns does not change them. The comparison is conservative: a
+// pattern that it cannot prove equivalent to some pattern in the other glob is reported as removed or added.
+type GlobDiff struct {
+	// AddedIncludes lists the include patterns of the new glob that are not equivalent to any include pattern of the
+	// old glob.
+	AddedIncludes []string
+	// RemovedIncludes lists the include patterns of the old glob that are not equivalent to any include pattern of the
+	// new glob.
+	RemovedIncludes []string
+	// AddedExcludes lists the exclude patterns of the new glob that are not equivalent to any exclude pattern of the
+	// old glob.
+	AddedExcludes []string
+	// RemovedExcludes lists the exclude patterns of the old glob that are not equivalent to any exclude pattern of the
+	// new glob.
+	RemovedExcludes []string
+	// Reordered is true if the globs have equivalent exclude patterns in an order that changes their meaning. The order
+	// of exclude patterns is only significant if some of them are negated.
+	Reordered bool
+	// OptionsChanged is true if the globs were compiled with different options.
+	OptionsChanged bool
+}
+
+// Changed reports whether the globs may match different paths.
+func (d GlobDiff) Changed() bool {
+	return len(d.AddedIncludes) != 0 || len(d.RemovedIncludes) != 0 || len(d.AddedExcludes) != 0 ||
+		len(d.RemovedExcludes) != 0 || d.Reordered || d.OptionsChanged
+}
+
+// DiffGlobs compares the patterns of two globs. If either glob was not created by New or NewWithOptions, DiffGlobs
+// returns false.
+func DiffGlobs(old, new Glob) (GlobDiff, bool) {
+	o, ok := old.(sourcedGlob)
+	if !ok {
+		return GlobDiff{}, false
+	}
+	n, ok := new.(sourcedGlob)
+	if !ok {
+		return GlobDiff{}, false
+	}
+
+	oldIncludes, oldExcludes := o.patterns()
+	newIncludes, newExcludes := n.patterns()
+	return diffSources(
+		sources{oldIncludes, oldExcludes, o.compileOptions()},
+		sources{newIncludes, newExcludes, n.compileOptions()},
+	), true
+}
+
+// DiffPatterns compares two sets of include and exclude patterns as they would be compiled by New.
+func DiffPatterns(oldIncludes, oldExcludes, newIncludes, newExcludes []string) GlobDiff {
+	return diffSources(sources{oldIncludes, oldExcludes, Options{}}, sources{newIncludes, newExcludes, Options{}})
+}
+
+func diffSources(old, new sources) GlobDiff {
+	oldIncludes, oldExcludes := diffPatterns(old.includes, old.options, false), diffPatterns(old.excludes, old.options, true)
+	newIncludes, newExcludes := diffPatterns(new.includes, new.options, false), diffPatterns(new.excludes, new.options, true)
+
+	d := GlobDiff{
+		AddedIncludes:   unmatched(newIncludes, oldIncludes),
+		RemovedIncludes: unmatched(oldIncludes, newIncludes),
+		AddedExcludes:   unmatched(newExcludes, oldExcludes),
+		RemovedExcludes: unmatched(oldExcludes, newExcludes),
+		OptionsChanged:  !old.options.equal(new.options),
+	}
+	if len(d.AddedExcludes) == 0 && len(d.RemovedExcludes) == 0 && (hasNegations(old.excludes) || hasNegations(new.excludes)) {
+		// Each exclude must be equivalent to the exclude at the same position.
+		d.Reordered = len(oldExcludes) != len(newExcludes)
+		for i := 0; !d.Reordered && i < len(oldExcludes); i++ {
+			d.Reordered = !oldExcludes[i].equivalent(newExcludes[i])
+		}
+	}
+	return d
+}
+
+// A diffPattern is a pattern being compared by DiffGlobs.
+type diffPattern struct {
+	text   string
+	negate bool
+	// alts holds the pattern's alternatives. It is nil if the pattern does not compile.
+	alts []pattern
+}
+
+// diffPatterns compiles the given patterns for comparison.
+func diffPatterns(ps []string, options Options, exclude bool) []diffPattern {
+	patterns := make([]diffPattern, len(ps))
+	for i, p := range ps {
+		text, negate := p, false
+		if exclude && strings.HasPrefix(p, "!") {
+			text, negate = p[1:], true
+		}
+		alts, err := compilePattern(text, options)
+		if err != nil {
+			alts = nil
+		}
+		patterns[i] = diffPattern{text: p, negate: negate, alts: alts}
+	}
+	return patterns
+}
+
+// equivalent reports whether p and q match exactly the same paths. Patterns that do not compile are equivalent only if
+// their text is identical.
+func (p diffPattern) equivalent(q diffPattern) bool {
+	switch {
+	case p.text == q.text:
+		return true
+	case p.negate != q.negate || p.alts == nil || q.alts == nil:
+		return false
+	default:
+		return coversAll(p.alts, q.alts, covers) && coversAll(q.alts, p.alts, covers)
+	}
+}
+
+// unmatched returns the text of the patterns in ps that are not equivalent to any pattern in qs.
+func unmatched(ps, qs []diffPattern) []string {
+	var texts []string
+	for _, p := range ps {
+		found := false
+		for _, q := range qs {
+			if p.equivalent(q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			texts = append(texts, p.text)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,88 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPatterns(t *testing.T) {
+	cases := []struct {
+		name                                               string
+		oldIncludes, oldExcludes, newIncludes, newExcludes []string
+		expected                                           GlobDiff
+	}{
+		{
+			name:        "reordered",
+			oldIncludes: []string{"src/**/*.go", "docs/*.md"},
+			oldExcludes: []string{"**/testdata", "vendor"},
+			newIncludes: []string{"docs/*.md", "src/**/*.go"},
+			newExcludes: []string{"vendor", "**/testdata"},
+		},
+		{
+			name:        "equivalent",
+			oldIncludes: []string{"a/**/**/b", "src/**{1}/*.go", "(?i)readme"},
+			newIncludes: []string{"a/**/b", "src/*/*.go", "(?i)README"},
+		},
+		{
+			name:        "added and removed",
+			oldIncludes: []string{"src/*.go", "docs/*.md"},
+			oldExcludes: []string{"**/testdata"},
+			newIncludes: []string{"src/**/*.go", "docs/*.md"},
+			newExcludes: []string{"**/testdata", "!**/testdata/keep"},
+			expected: GlobDiff{
+				AddedIncludes:   []string{"src/**/*.go"},
+				RemovedIncludes: []string{"src/*.go"},
+				AddedExcludes:   []string{"!**/testdata/keep"},
+			},
+		},
+		{
+			name:        "negation reordered",
+			oldIncludes: []string{"**"},
+			oldExcludes: []string{"build", "!build/keep"},
+			newIncludes: []string{"**"},
+			newExcludes: []string{"!build/keep", "build"},
+			expected:    GlobDiff{Reordered: true},
+		},
+		{
+			name:        "negation",
+			oldIncludes: []string{"**"},
+			oldExcludes: []string{"build"},
+			newIncludes: []string{"**"},
+			newExcludes: []string{"!build"},
+			expected:    GlobDiff{AddedExcludes: []string{"!build"}, RemovedExcludes: []string{"build"}},
+		},
+		{
+			name:        "invalid",
+			oldIncludes: []string{"[", "a"},
+			newIncludes: []string{"a", "["},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := DiffPatterns(c.oldIncludes, c.oldExcludes, c.newIncludes, c.newExcludes)
+			assert.Equal(t, c.expected, d)
+			assert.Equal(t, c.expected.Changed(), d.Changed())
+		})
+	}
+}
+
+func TestDiffGlobs(t *testing.T) {
+	old, err := New([]string{"*.go"}, nil)
+	require.NoError(t, err)
+	new, err := NewWithOptions([]string{"*.go"}, nil, Options{CaseInsensitive: true})
+	require.NoError(t, err)
+
+	d, ok := DiffGlobs(old, old)
+	require.True(t, ok)
+	assert.False(t, d.Changed())
+
+	d, ok = DiffGlobs(old, new)
+	require.True(t, ok)
+	assert.Equal(t, GlobDiff{OptionsChanged: true}, d)
+	assert.True(t, d.Changed())
+
+	_, ok = DiffGlobs(old, pathGlob("a"))
+	assert.False(t, ok)
+}
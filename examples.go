@@ -0,0 +1,125 @@
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+// exampleRunes are the candidate characters used to instantiate wildcards when generating examples.
+const exampleRunes = "xyzabcdefghijklmnopqrstuvw0123456789_-."
+
+// Examples generates sample paths that illustrate the behavior of a Glob. The accepted paths are matched by the glob;
+// the rejected paths are near misses that the glob does not match, such as paths with a slightly different name or
+// paths that are removed by an exclude pattern.
+//
+// The examples are formed by instantiating the wildcards in the glob's patterns with simple names and are not
+// exhaustive. Each path is listed at most once.
+func Examples(g Glob) (accepted, rejected []string) {
+	var include, exclude []pattern
+	switch g := g.(type) {
+	case allGlob:
+		include = []pattern{{globstar}}
+	case noneGlob:
+		exclude = []pattern{{globstar}}
+	case *matchGlob:
+		include, exclude = g.include, g.exclude
+	default:
+		return nil, nil
+	}
+
+	seen := map[string]bool{}
+	add := func(list *[]string, p string, matches bool) {
+		if p == "" || seen[p] || g.MatchPath(p) != matches {
+			return
+		}
+		seen[p] = true
+		*list = append(*list, p)
+	}
+
+	for _, p := range include {
+		for _, e := range p.examples() {
+			add(&accepted, e, true)
+		}
+	}
+	for _, p := range exclude {
+		for _, e := range p.examples() {
+			add(&rejected, e, false)
+		}
+	}
+	for _, e := range accepted {
+		for _, miss := range nearMisses(e) {
+			add(&rejected, miss, false)
+		}
+	}
+	return accepted, rejected
+}
+
+// examples returns paths matched by p. Every '**' is instantiated as both a single directory and as no directories.
+func (p pattern) examples() []string {
+	var one, none []string
+	for _, step := range p {
+		if step.isGlobstar() {
+			one = append(one, "x")
+			continue
+		}
+		name, ok := exampleName(step.text)
+		if !ok {
+			return nil
+		}
+		one, none = append(one, name), append(none, name)
+	}
+	return []string{path.Join(one...), path.Join(none...)}
+}
+
+// exampleName returns a name that matches the given pattern step, if one can be found.
+func exampleName(step string) (string, bool) {
+	var b strings.Builder
+	for _, t := range newCaptureSegment(step, Options{}).tokens {
+		switch t.kind {
+		case tokenLiteral:
+			b.WriteString(t.text)
+		case tokenStar:
+			b.WriteByte('x')
+		default:
+			r, ok := exampleChar(t.text)
+			if !ok {
+				return "", false
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), true
+}
+
+// exampleChar returns a character that matches the given single-character wildcard.
+func exampleChar(wildcard string) (rune, bool) {
+	candidates := exampleRunes
+	if strings.HasPrefix(wildcard, "[") && !strings.HasPrefix(wildcard, "[^") && !strings.HasPrefix(wildcard, "[!") {
+		// Prefer the first character in a class.
+		first := strings.TrimPrefix(wildcard[1:], `\`)
+		candidates = first + candidates
+	}
+	for _, r := range candidates {
+		if match(wildcard, string(r)) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// nearMisses returns paths that are similar to the given path.
+func nearMisses(p string) []string {
+	dir, base := path.Split(p)
+	misses := []string{
+		p + "x",
+		dir + "x" + base,
+		path.Join("x", p),
+	}
+	if i := strings.IndexByte(p, '/'); i != -1 {
+		misses = append(misses, p[i+1:])
+	}
+	if dir != "" {
+		misses = append(misses, path.Join(path.Dir(path.Clean(dir)), "x", base))
+	}
+	return misses
+}
@@ -0,0 +1,39 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExamples(t *testing.T) {
+	g, err := New([]string{"src/**/*.go", "docs/[a-c]?.md"}, []string{"**/testdata/**"})
+	require.NoError(t, err)
+
+	accepted, rejected := Examples(g)
+	assert.Equal(t, []string{"src/x/x.go", "src/x.go", "docs/ax.md"}, accepted)
+	assert.Contains(t, rejected, "x/testdata/x")
+	assert.Contains(t, rejected, "src/x/x.gox")
+	assert.Contains(t, rejected, "x/src/x/x.go")
+	for _, p := range accepted {
+		assert.True(t, g.MatchPath(p), p)
+	}
+	for _, p := range rejected {
+		assert.False(t, g.MatchPath(p), p)
+	}
+}
+
+func TestExamplesAllNone(t *testing.T) {
+	all, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+	accepted, rejected := Examples(all)
+	assert.Equal(t, []string{"x"}, accepted)
+	assert.Empty(t, rejected)
+
+	none, err := New([]string{"**"}, []string{"**"})
+	require.NoError(t, err)
+	accepted, rejected = Examples(none)
+	assert.Empty(t, accepted)
+	assert.Equal(t, []string{"x"}, rejected)
+}
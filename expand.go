@@ -0,0 +1,266 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxClassExpansion is the largest character class that Expand will enumerate. Larger classes are left in place.
+const maxClassExpansion = 64
+
+// maxExpansions is the largest number of patterns that Expand will produce for a single input.
+const maxExpansions = 1 << 16
+
+// Expand expands the parts of a pattern that do not depend on the contents of a filesystem into a list of patterns.
+//
+// Expand replaces brace alternations of the form '{' alt { ',' alt } '}' with each of their alternatives (braces may
+// nest) and replaces character classes that are not negated and contain at most 64 characters with each of their
+// characters. A brace group that contains no top-level ',' is left as-is, as are the bounds of a bounded '**' element
+// such as '**{1,3}' outside of any brace group. Enumerated characters that are metacharacters are escaped in the
+// results.
+//
+// The results are listed in expansion order without duplicates. Each result is a valid pattern for New; results that
+// contain no metacharacters are literal paths.
+func Expand(pattern string) ([]string, error) {
+	// Braces are not metacharacters for path.Match, so this validates everything but the brace structure.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	seq, _, err := parseExpansion(pattern, false)
+	if err != nil {
+		return nil, err
+	}
+
+	expansions, err := seq.expand()
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", pattern, err)
+	}
+
+	var results []string
+	seen := map[string]bool{}
+	for _, e := range expansions {
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		results = append(results, e)
+	}
+	return results, nil
+}
+
+// An expansionSeq is a sequence of expansion terms.
+type expansionSeq []expansionTerm
+
+// An expansionTerm is one of a literal run of pattern text, a brace alternation, or a character class. Exactly one of
+// text, alts, or chars is set.
+type expansionTerm struct {
+	text  string
+	alts  []expansionSeq
+	chars []string
+}
+
+func (s expansionSeq) expand() ([]string, error) {
+	results := []string{""}
+	for _, t := range s {
+		var options []string
+		switch {
+		case t.alts != nil:
+			for _, alt := range t.alts {
+				expanded, err := alt.expand()
+				if err != nil {
+					return nil, err
+				}
+				options = append(options, expanded...)
+			}
+		case t.chars != nil:
+			options = t.chars
+		default:
+			options = []string{t.text}
+		}
+
+		if len(results)*len(options) > maxExpansions {
+			return nil, errors.New("pattern expands to too many alternatives")
+		}
+		next := make([]string, 0, len(results)*len(options))
+		for _, r := range results {
+			for _, o := range options {
+				next = append(next, r+o)
+			}
+		}
+		results = next
+	}
+	return results, nil
+}
+
+// parseExpansion parses a sequence of terms from p. If inBraces is true, parsing stops at the first top-level ',' or
+// '}', and the remaining input (starting with that character) is returned.
+func parseExpansion(p string, inBraces bool) (expansionSeq, string, error) {
+	var seq expansionSeq
+	var text strings.Builder
+
+	// element is the input consumed since the start of the current pattern element, which is used to recognize bounded
+	// '**' elements.
+	var element strings.Builder
+	flush := func() {
+		if text.Len() != 0 {
+			seq = append(seq, expansionTerm{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for len(p) > 0 {
+		if p[0] == '/' {
+			element.Reset()
+		} else {
+			element.WriteByte(p[0])
+		}
+
+		switch p[0] {
+		case '\\':
+			if len(p) == 1 {
+				return nil, "", path.ErrBadPattern
+			}
+			text.WriteString(p[:2])
+			p = p[2:]
+		case '[':
+			end, err := classEnd(p)
+			if err != nil {
+				return nil, "", err
+			}
+			flush()
+			seq = append(seq, expandClass(p[:end]))
+			p = p[end:]
+		case '{':
+			if end := strings.IndexByte(p, '}'); !inBraces && element.String() == "**{" && end != -1 {
+				// The bounds of a bounded '**' element are not an alternation.
+				text.WriteString(p[:end+1])
+				p = p[end+1:]
+				continue
+			}
+			term, rest, err := parseBraces(p)
+			if err != nil {
+				return nil, "", err
+			}
+			flush()
+			seq = append(seq, term)
+			p = rest
+		case ',', '}':
+			if inBraces {
+				flush()
+				return seq, p, nil
+			}
+			text.WriteByte(p[0])
+			p = p[1:]
+		default:
+			text.WriteByte(p[0])
+			p = p[1:]
+		}
+	}
+	if inBraces {
+		return nil, "", fmt.Errorf("%w: unterminated '{'", path.ErrBadPattern)
+	}
+	flush()
+	return seq, "", nil
+}
+
+// parseBraces parses a brace alternation at the start of p. A group with a single alternative expands to that
+// alternative surrounded by literal braces.
+func parseBraces(p string) (expansionTerm, string, error) {
+	var alts []expansionSeq
+	rest := p[1:]
+	for {
+		alt, r, err := parseExpansion(rest, true)
+		if err != nil {
+			return expansionTerm{}, "", err
+		}
+		if alt == nil {
+			alt = expansionSeq{}
+		}
+		alts = append(alts, alt)
+		if r[0] == '}' {
+			rest = r[1:]
+			break
+		}
+		rest = r[1:]
+	}
+	if len(alts) == 1 {
+		alts[0] = slices.Concat(expansionSeq{{text: "{"}}, alts[0], expansionSeq{{text: "}"}})
+	}
+	return expansionTerm{alts: alts}, rest, nil
+}
+
+// classEnd returns the length of the character class at the start of p.
+func classEnd(p string) (int, error) {
+	i := 1
+	if i < len(p) && (p[i] == '^' || p[i] == '!') {
+		i++
+	}
+	for i < len(p) {
+		switch p[i] {
+		case ']':
+			return i + 1, nil
+		case '\\':
+			i += 2
+		default:
+			i++
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated '['", path.ErrBadPattern)
+}
+
+// expandClass returns a term for the given character class. Negated or large classes are returned as literal pattern
+// text.
+func expandClass(class string) expansionTerm {
+	raw := expansionTerm{text: class}
+	if strings.HasPrefix(class, "[^") || strings.HasPrefix(class, "[!") {
+		return raw
+	}
+
+	var chars []string
+	body := class[1 : len(class)-1]
+	readChar := func() (rune, bool) {
+		if body == "" {
+			return 0, false
+		}
+		if body[0] == '\\' {
+			body = body[1:]
+		}
+		r, n := utf8.DecodeRuneInString(body)
+		body = body[n:]
+		return r, true
+	}
+	for body != "" {
+		lo, ok := readChar()
+		if !ok {
+			return raw
+		}
+		hi := lo
+		if len(body) > 0 && body[0] == '-' {
+			body = body[1:]
+			if hi, ok = readChar(); !ok || hi < lo {
+				return raw
+			}
+		}
+		if len(chars)+int(hi-lo)+1 > maxClassExpansion {
+			return raw
+		}
+		for c := lo; c <= hi; c++ {
+			chars = append(chars, escapeChar(c))
+		}
+	}
+	slices.Sort(chars)
+	return expansionTerm{chars: slices.Compact(chars)}
+}
+
+// escapeChar returns the pattern text that matches exactly the character c.
+func escapeChar(c rune) string {
+	if strings.ContainsRune(`*?[\`, c) {
+		return `\` + string(c)
+	}
+	return string(c)
+}
@@ -0,0 +1,73 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"foo/bar", []string{"foo/bar"}},
+		{"*.{go,mod}", []string{"*.go", "*.mod"}},
+		{"{a,b{c,d}}/x", []string{"a/x", "bc/x", "bd/x"}},
+		{"{a,}b", []string{"ab", "b"}},
+		{"{a}", []string{"{a}"}},
+		{"{{a,b}}", []string{"{a}", "{b}"}},
+		{"x{a,a}", []string{"xa"}},
+		{"log[0-2].txt", []string{"log0.txt", "log1.txt", "log2.txt"}},
+		{"[*?]", []string{`\*`, `\?`}},
+		{"[^ab]", []string{"[^ab]"}},
+		{"[!ab]", []string{"[!ab]"}},
+		{"[a-z][a-z]", nil},
+		{"[\x00-\x7f]", []string{"[\x00-\x7f]"}},
+		{`\{a,b\}`, []string{`\{a,b\}`}},
+		{"a,b}", []string{"a,b}"}},
+		{"src/**{1,2}/*.go", []string{"src/**{1,2}/*.go"}},
+		{"**{0,1}/*.{c,h}", []string{"**{0,1}/*.c", "**{0,1}/*.h"}},
+		{"a**{1,2}", []string{"a**1", "a**2"}},
+		{"{a,b}/**{2}", []string{"a/**{2}", "b/**{2}"}},
+	}
+	for _, c := range cases {
+		t.Run(c.pattern, func(t *testing.T) {
+			actual, err := Expand(c.pattern)
+			require.NoError(t, err)
+			if c.expected == nil {
+				assert.Len(t, actual, 26*26)
+			} else {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	for _, p := range []string{"{a,b", "[a", `a\`, "{[a,b}", "{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}"} {
+		_, err := Expand(p)
+		assert.Error(t, err, p)
+	}
+}
+
+func TestExpandBoundedGlobstar(t *testing.T) {
+	// The bounds of a bounded '**' survive expansion, so each result has the same bounds.
+	expanded, err := Expand("src/**{1,2}/*.{go,s}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/**{1,2}/*.go", "src/**{1,2}/*.s"}, expanded)
+
+	g, err := New(expanded, nil)
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("src/a/b.go"))
+	assert.True(t, g.MatchPath("src/a/b/c.s"))
+	assert.False(t, g.MatchPath("src/b.go"))
+	assert.False(t, g.MatchPath("src/a/b/c/d.go"))
+
+	m, err := NewMapping([]MappingRule{{Pattern: expanded[0], Template: "out/$1/$2.o"}})
+	require.NoError(t, err)
+	dest, ok := m.Map("src/a/b/c.go")
+	assert.True(t, ok)
+	assert.Equal(t, "out/a/b/c.o", dest)
+}
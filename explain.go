@@ -0,0 +1,192 @@
+package glob
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// An Explanation describes why a Glob does or does not match a path.
+type Explanation struct {
+	// Path is the path that was explained.
+	Path string
+	// Matched is true if the glob matches the path.
+	Matched bool
+	// Includes lists the include patterns that match the path.
+	Includes []string
+	// Excludes lists the exclude patterns that match the path or one of its ancestor directories, including negated
+	// patterns.
+	Excludes []string
+}
+
+// ExplainPath reports which of g's include and exclude patterns match the given path. If g was not created by this
+// package, only the Matched field of the result is set.
+func ExplainPath(g Glob, p string) Explanation {
+	e := Explanation{Path: p, Matched: g.MatchPath(p)}
+
+	sg, ok := g.(sourcedGlob)
+	if !ok {
+		return e
+	}
+	includes, excludes := sg.patterns()
+	options := sg.compileOptions()
+
+	names := splitPath(p)
+	for _, include := range includes {
+		if patternMatches(include, options, names, false) {
+			e.Includes = append(e.Includes, include)
+		}
+	}
+	for _, exclude := range excludes {
+		if patternMatches(strings.TrimPrefix(exclude, "!"), options, names, true) {
+			e.Excludes = append(e.Excludes, exclude)
+		}
+	}
+	return e
+}
+
+// patternMatches reports whether the given pattern matches names. If prefix is true, patternMatches also reports true
+// if the pattern matches any prefix of names.
+func patternMatches(p string, options Options, names []string, prefix bool) bool {
+	var patterns []pattern
+	if err := newPattern(p, options, &patterns); err != nil || len(names) == 0 {
+		return false
+	}
+	return matchNames(patterns, names, prefix)
+}
+
+// A Mismatch describes why a Glob does not match a path, pattern by pattern.
+type Mismatch struct {
+	// Path is the path that was explained.
+	Path string
+	// Matched is true if the glob matches the path.
+	Matched bool
+	// Includes describes how each of the glob's include patterns matches the path, in order.
+	Includes []PatternMatch
+	// Excludes describes how each of the glob's exclude patterns matches the path, in order. An exclude pattern is
+	// matched if it would fire for the path: that is, if it matches the path or one of its ancestor directories.
+	// Negated patterns are listed with their leading '!'.
+	Excludes []PatternMatch
+}
+
+// A PatternMatch describes how a single pattern matches a path. If the pattern does not match, it records the first
+// element of the path that the pattern failed to match.
+type PatternMatch struct {
+	// Pattern is the pattern's source text.
+	Pattern string
+	// Matched is true if the pattern matches the path.
+	Matched bool
+	// Index is the index of the first element of the path that the pattern failed to match. If the path ended before
+	// the pattern did, Index is the number of elements in the path. Index is zero if the pattern matches or is invalid.
+	Index int
+	// Prefix is the portion of the path that the pattern matched before it failed.
+	Prefix string
+	// Name is the element of the path that the pattern failed to match, or the empty string if the path ended before
+	// the pattern did.
+	Name string
+	// Expected lists the pattern elements that could have matched Name, or that expected another element if the path
+	// ended.
+	Expected []string
+}
+
+// String describes the pattern match in a single sentence.
+func (m PatternMatch) String() string {
+	expected := make([]string, len(m.Expected))
+	for i, e := range m.Expected {
+		expected[i] = strconv.Quote(e)
+	}
+	at := "the start of the path"
+	if m.Prefix != "" {
+		at = strconv.Quote(m.Prefix + "/")
+	}
+
+	switch {
+	case m.Matched:
+		return fmt.Sprintf("pattern %q matches", m.Pattern)
+	case len(expected) == 0:
+		return fmt.Sprintf("pattern %q is invalid", m.Pattern)
+	case m.Prefix == "" && m.Name == "":
+		return fmt.Sprintf("pattern %q expects %v, but the path is empty", m.Pattern, strings.Join(expected, " or "))
+	case m.Name == "":
+		return fmt.Sprintf("pattern %q expects %v after %q, but the path ends", m.Pattern, strings.Join(expected, " or "), m.Prefix)
+	default:
+		return fmt.Sprintf("pattern %q expects %v at %v, but the path has %q", m.Pattern, strings.Join(expected, " or "), at, m.Name)
+	}
+}
+
+// ExplainMismatch reports where each of g's include patterns stops matching the given path and whether each of its
+// exclude patterns would fire for the path. It complements ExplainPath: where ExplainPath lists the patterns that
+// match, ExplainMismatch explains those that do not. If g was not created by this package, only the Path and Matched
+// fields of the result are set.
+func ExplainMismatch(g Glob, p string) Mismatch {
+	m := Mismatch{Path: p, Matched: g.MatchPath(p)}
+
+	sg, ok := g.(sourcedGlob)
+	if !ok {
+		return m
+	}
+	includes, excludes := sg.patterns()
+	options := sg.compileOptions()
+
+	names := splitPath(p)
+	for _, include := range includes {
+		m.Includes = append(m.Includes, explainPattern(include, include, options, names, false))
+	}
+	for _, exclude := range excludes {
+		m.Excludes = append(m.Excludes, explainPattern(exclude, strings.TrimPrefix(exclude, "!"), options, names, true))
+	}
+	return m
+}
+
+// explainPattern describes how the given pattern matches names. If prefix is true, the pattern also matches if it
+// matches any prefix of names.
+func explainPattern(source, p string, options Options, names []string, prefix bool) PatternMatch {
+	m := PatternMatch{Pattern: source}
+
+	var patterns []pattern
+	if err := newPattern(p, options, &patterns); err != nil {
+		return m
+	}
+	if len(names) == 0 {
+		m.Matched = always(patterns)
+		m.Expected = expectedSteps(patterns)
+		return m
+	}
+
+	for i, name := range names {
+		var next []pattern
+		for _, p := range patterns {
+			if p.matchDir(name, &next) && (prefix || i == len(names)-1) {
+				return PatternMatch{Pattern: source, Matched: true}
+			}
+		}
+
+		// If the pattern could have ended at the last name, blame the name rather than the end of the path.
+		var final []pattern
+		if i == len(names)-1 {
+			final = slices.DeleteFunc(slices.Clone(patterns), func(p pattern) bool { return len(p) != 1 })
+		}
+		if len(final) == 0 && len(next) == 0 {
+			final = patterns
+		}
+		if len(final) != 0 {
+			m.Index, m.Prefix, m.Name, m.Expected = i, strings.Join(names[:i], "/"), name, expectedSteps(final)
+			return m
+		}
+		patterns = next
+	}
+	m.Index, m.Prefix, m.Expected = len(names), strings.Join(names, "/"), expectedSteps(patterns)
+	return m
+}
+
+// expectedSteps returns the distinct texts of the first steps of the given patterns.
+func expectedSteps(patterns []pattern) []string {
+	var texts []string
+	for _, p := range patterns {
+		if !slices.Contains(texts, p[0].text) {
+			texts = append(texts, p[0].text)
+		}
+	}
+	return texts
+}
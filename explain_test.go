@@ -0,0 +1,69 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainPath(t *testing.T) {
+	g, err := New([]string{"src/**/*.go", "**/main.go", "docs/*"}, []string{"**/testdata", "*.tmp"})
+	require.NoError(t, err)
+
+	assert.Equal(t, Explanation{
+		Path:     "src/cmd/main.go",
+		Matched:  true,
+		Includes: []string{"src/**/*.go", "**/main.go"},
+	}, ExplainPath(g, "src/cmd/main.go"))
+
+	assert.Equal(t, Explanation{
+		Path:     "src/testdata/main.go",
+		Includes: []string{"src/**/*.go", "**/main.go"},
+		Excludes: []string{"**/testdata"},
+	}, ExplainPath(g, "src/testdata/main.go"))
+
+	assert.Equal(t, Explanation{Path: "lib/x.go"}, ExplainPath(g, "lib/x.go"))
+
+	all, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Explanation{Path: "x", Matched: true, Includes: []string{"**"}}, ExplainPath(all, "x"))
+}
+
+func TestExplainMismatch(t *testing.T) {
+	g, err := New([]string{"src/**/*.go", "src/cmd/*/main.go"}, []string{"**/testdata", "!**/testdata/keep"})
+	require.NoError(t, err)
+
+	m := ExplainMismatch(g, "lib/x.go")
+	assert.Equal(t, Mismatch{
+		Path: "lib/x.go",
+		Includes: []PatternMatch{
+			{Pattern: "src/**/*.go", Name: "lib", Expected: []string{"src"}},
+			{Pattern: "src/cmd/*/main.go", Name: "lib", Expected: []string{"src"}},
+		},
+		Excludes: []PatternMatch{
+			{Pattern: "**/testdata", Index: 1, Prefix: "lib", Name: "x.go", Expected: []string{"testdata"}},
+			{Pattern: "!**/testdata/keep", Index: 2, Prefix: "lib/x.go", Expected: []string{"**", "testdata"}},
+		},
+	}, m)
+	assert.Equal(t, `pattern "src/**/*.go" expects "src" at the start of the path, but the path has "lib"`, m.Includes[0].String())
+
+	m = ExplainMismatch(g, "src/cmd/tool/main.txt")
+	assert.Equal(t, []PatternMatch{
+		{Pattern: "src/**/*.go", Index: 3, Prefix: "src/cmd/tool", Name: "main.txt", Expected: []string{"*.go"}},
+		{Pattern: "src/cmd/*/main.go", Index: 3, Prefix: "src/cmd/tool", Name: "main.txt", Expected: []string{"main.go"}},
+	}, m.Includes)
+	assert.Equal(t, `pattern "src/cmd/*/main.go" expects "main.go" at "src/cmd/tool/", but the path has "main.txt"`, m.Includes[1].String())
+
+	m = ExplainMismatch(g, "src/cmd")
+	assert.Equal(t, PatternMatch{Pattern: "src/cmd/*/main.go", Index: 2, Prefix: "src/cmd", Expected: []string{"*"}}, m.Includes[1])
+	assert.Equal(t, `pattern "src/cmd/*/main.go" expects "*" after "src/cmd", but the path ends`, m.Includes[1].String())
+
+	m = ExplainMismatch(g, "src/testdata/a.go")
+	assert.False(t, m.Matched)
+	assert.Equal(t, []PatternMatch{{Pattern: "src/**/*.go", Matched: true}, m.Includes[1]}, m.Includes)
+	assert.Equal(t, PatternMatch{Pattern: "**/testdata", Matched: true}, m.Excludes[0])
+	assert.Equal(t, `pattern "**/testdata" matches`, m.Excludes[0].String())
+
+	assert.Equal(t, Mismatch{Path: "src/a.go", Matched: true}, ExplainMismatch(foreignGlob{g}, "src/a.go"))
+}
@@ -0,0 +1,231 @@
+package glob
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// A FilterFS is a view of a filesystem that contains only the paths matched by a glob. A file is visible if the glob
+// matches it. A directory is visible if the glob matches it or may match a path beneath it; a directory whose contents
+// are all hidden may therefore be visible but empty, though a directory that g excludes along with everything beneath it
+// is hidden. Paths are matched relative to the root of the filesystem.
+//
+// FilterFS implements fs.ReadDirFS, fs.StatFS, fs.ReadFileFS, and fs.GlobFS, passing each operation through to the
+// underlying filesystem, so consumers that type-assert for those interfaces, such as http.FS, do not fall back to
+// slower paths. Hidden paths are reported as not existing.
+type FilterFS struct {
+	fsys  fs.FS
+	g     Glob
+	scope func([]string) (bool, bool)
+}
+
+var (
+	_ = fs.ReadDirFS((*FilterFS)(nil))
+	_ = fs.StatFS((*FilterFS)(nil))
+	_ = fs.ReadFileFS((*FilterFS)(nil))
+	_ = fs.GlobFS((*FilterFS)(nil))
+)
+
+// NewFilterFS returns a view of fsys that contains only the paths matched by g.
+func NewFilterFS(fsys fs.FS, g Glob) *FilterFS {
+	return &FilterFS{fsys: fsys, g: g, scope: scopeFunc(g)}
+}
+
+// visibility reports whether the named path is visible if it is a file and whether it is visible if it is a
+// directory.
+func (f *FilterFS) visibility(name string) (file, dir bool) {
+	names := splitPath(name)
+	if len(names) == 0 {
+		return false, true
+	}
+	matched, mayContain := f.scope(names)
+	return matched, matched || mayContain && !pruned(f.g, names)
+}
+
+// pruned reports whether g excludes the directory with the given names and every path beneath it.
+func pruned(g Glob, names []string) bool {
+	mg, ok := g.(*matchGlob)
+	if !ok {
+		return false
+	}
+
+	exclude, e := mg.exclude, exclusion{}
+	for _, name := range names {
+		var next []pattern
+		for _, p := range exclude {
+			if p.matchDir(name, &next) {
+				e = e.update(p)
+			}
+		}
+		if e.final(next) || excludesAll(e, next) {
+			return true
+		}
+		exclude = next
+	}
+	return false
+}
+
+// visible reports whether the named path, which is a directory if isDir is true, is visible.
+func (f *FilterFS) visible(name string, isDir bool) bool {
+	file, dir := f.visibility(name)
+	if isDir {
+		return dir
+	}
+	return file
+}
+
+// check validates the given name and reports an error if it is certainly hidden.
+func (f *FilterFS) check(op, name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if file, dir := f.visibility(name); !file && !dir {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// Open opens the named file. Reading the entries of a directory returns only its visible entries.
+func (f *FilterFS) Open(name string) (fs.File, error) {
+	if err := f.check("open", name); err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !f.visible(name, info.IsDir()) {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if d, ok := file.(fs.ReadDirFile); ok && info.IsDir() {
+		return &filterDir{ReadDirFile: d, fsys: f, name: name}, nil
+	}
+	return file, nil
+}
+
+// ReadDir reads the named directory and returns its visible entries.
+func (f *FilterFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.check("readdir", name); err != nil {
+		return nil, err
+	}
+	if !f.visible(name, true) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	return f.filter(name, entries), err
+}
+
+// filter removes the hidden entries from the given entries of the named directory.
+func (f *FilterFS) filter(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	visible := entries[:0]
+	for _, e := range entries {
+		if f.visible(path.Join(dir, e.Name()), e.IsDir()) {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+// Stat returns information about the named file.
+func (f *FilterFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.check("stat", name); err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if !f.visible(name, info.IsDir()) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+// ReadFile reads the named file.
+func (f *FilterFS) ReadFile(name string) ([]byte, error) {
+	if err := f.check("readfile", name); err != nil {
+		return nil, err
+	}
+	if !f.visible(name, false) {
+		// Reading a directory fails, so a path that is not visible as a file is either hidden or a directory.
+		if info, err := fs.Stat(f.fsys, name); err == nil && info.IsDir() {
+			return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("is a directory")}
+		}
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+	return fs.ReadFile(f.fsys, name)
+}
+
+// Glob returns the names of the visible files that match pattern, using the syntax of path.Match.
+func (f *FilterFS) Glob(pattern string) ([]string, error) {
+	if _, ok := f.fsys.(fs.GlobFS); !ok {
+		// Match against the visible entries of each directory.
+		return fs.Glob(readDirOnlyFS{f}, pattern)
+	}
+
+	names, err := fs.Glob(f.fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	visible := names[:0]
+	for _, name := range names {
+		switch file, dir := f.visibility(name); {
+		case file && dir:
+			visible = append(visible, name)
+		case file || dir:
+			if info, err := fs.Stat(f.fsys, name); err == nil && info.IsDir() == dir {
+				visible = append(visible, name)
+			}
+		}
+	}
+	return visible, nil
+}
+
+// readDirOnlyFS hides every method of a FilterFS other than Open and ReadDir.
+type readDirOnlyFS struct {
+	f *FilterFS
+}
+
+func (r readDirOnlyFS) Open(name string) (fs.File, error) {
+	return r.f.Open(name)
+}
+
+func (r readDirOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return r.f.ReadDir(name)
+}
+
+// A filterDir is an open directory of a FilterFS.
+type filterDir struct {
+	fs.ReadDirFile
+	fsys *FilterFS
+	name string
+}
+
+// ReadDir reads the visible entries of the directory.
+func (d *filterDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		return d.fsys.filter(d.name, entries), err
+	}
+
+	var visible []fs.DirEntry
+	for len(visible) < n {
+		entries, err := d.ReadDirFile.ReadDir(n - len(visible))
+		visible = append(visible, d.fsys.filter(d.name, entries)...)
+		if err != nil {
+			if len(visible) != 0 && errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return visible, err
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,72 @@
+package glob
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterFS(t *testing.T) {
+	tree := fstest.MapFS{
+		"src/a.go":         {Data: []byte("a")},
+		"src/b.txt":        {},
+		"src/lib/c.go":     {},
+		"src/vendor/d.go":  {},
+		"docs/e.md":        {},
+		"README.md":        {},
+		"src/lib/f.go/g.x": {},
+	}
+
+	g, err := New([]string{"src/**/*.go"}, []string{"src/vendor"})
+	require.NoError(t, err)
+
+	for _, fsys := range []fs.FS{tree, globless{tree}} {
+		filtered := NewFilterFS(fsys, g)
+		require.NoError(t, fstest.TestFS(filtered, "src/a.go", "src/lib/c.go"))
+
+		_, err = filtered.Stat("src/b.txt")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = filtered.Stat("README.md")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = filtered.ReadFile("docs/e.md")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = filtered.Stat("src/vendor")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		// A directory that matches is visible, though its contents are not.
+		entries, err := filtered.ReadDir("src/lib/f.go")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		data, err := filtered.ReadFile("src/a.go")
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(data))
+
+		names, err := filtered.Glob("src/*")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"src/a.go", "src/lib"}, names)
+
+		entries, err = filtered.ReadDir(".")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"src"}, entryNames(entries))
+	}
+}
+
+// globless hides the Glob method of a MapFS.
+type globless struct {
+	fstest.MapFS
+}
+
+func (globless) Glob() {}
+
+func entryNames(entries []fs.DirEntry) []string {
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
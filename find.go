@@ -0,0 +1,360 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"path"
+	"time"
+)
+
+// An Expr is a condition on the files visited by Find, in the manner of the expressions accepted by the find command.
+// Expressions are built from predicates such as Name, Type, and LargerThan and combined with And, Or, and Not.
+type Expr interface {
+	// eval reports whether the given file satisfies the expression.
+	eval(f *findFile) (bool, error)
+
+	// mayContain reports whether a file beneath the directory with the given names, relative to the starting
+	// directory, may satisfy the expression.
+	mayContain(names []string) bool
+
+	// err returns the error encountered while building the expression, if any.
+	err() error
+}
+
+// A findFile is a file visited by Find.
+type findFile struct {
+	path  string
+	names []string
+	entry fs.DirEntry
+	info  fs.FileInfo
+}
+
+// stat returns the file's information, fetching it from its directory entry on first use.
+func (f *findFile) stat() (fs.FileInfo, error) {
+	if f.info == nil {
+		info, err := f.entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		f.info = info
+	}
+	return f.info, nil
+}
+
+type nameExpr struct {
+	step  step
+	error error
+}
+
+// Name matches files whose names match the given pattern, which is a single pattern element that may begin with a
+// flag group, as accepted by New. The name of the starting directory is the last element of its path. If the pattern
+// is malformed or contains a '/', Find yields the error.
+func Name(pattern string) Expr {
+	alternatives, err := compilePattern(pattern, Options{})
+	switch {
+	case err != nil:
+		return nameExpr{error: err}
+	case len(alternatives) != 1 || len(alternatives[0]) != 1:
+		return nameExpr{error: fmt.Errorf("glob: name pattern %q must be a single element", pattern)}
+	}
+	return nameExpr{step: alternatives[0][0]}
+}
+
+func (e nameExpr) eval(f *findFile) (bool, error) {
+	return e.step.match(path.Base(f.path)), nil
+}
+
+func (e nameExpr) mayContain(names []string) bool {
+	return true
+}
+
+func (e nameExpr) err() error {
+	return e.error
+}
+
+type pathExpr struct {
+	g     Glob
+	scope func(names []string) (bool, bool)
+}
+
+// Path matches files whose paths, relative to the starting directory, are matched by g. If Path is one of the
+// expressions of the top-level And passed to Find, or is itself that expression, Find matches g against the tree, so
+// directories that g cannot match beneath are not read.
+func Path(g Glob) Expr {
+	return pathExpr{g: g, scope: scopeFunc(g)}
+}
+
+func (e pathExpr) eval(f *findFile) (bool, error) {
+	matched, _ := e.scope(f.names)
+	return matched, nil
+}
+
+func (e pathExpr) mayContain(names []string) bool {
+	_, mayContain := e.scope(names)
+	return mayContain
+}
+
+func (e pathExpr) err() error {
+	return nil
+}
+
+// A predicate is an expression that tests a single file.
+type predicate func(f *findFile) (bool, error)
+
+func (e predicate) eval(f *findFile) (bool, error) {
+	return e(f)
+}
+
+func (e predicate) mayContain(names []string) bool {
+	return true
+}
+
+func (e predicate) err() error {
+	return nil
+}
+
+// infoPredicate returns a predicate that tests a file's information. The information is fetched at most once per file.
+func infoPredicate(test func(info fs.FileInfo) bool) Expr {
+	return predicate(func(f *findFile) (bool, error) {
+		info, err := f.stat()
+		if err != nil {
+			return false, err
+		}
+		return test(info), nil
+	})
+}
+
+// Type matches files whose type bits equal t: 0 for regular files, fs.ModeDir for directories, fs.ModeSymlink for
+// symbolic links, and so on. Types are read from directory entries, so symbolic links are not followed.
+func Type(t fs.FileMode) Expr {
+	return predicate(func(f *findFile) (bool, error) {
+		return f.entry.Type() == t&fs.ModeType, nil
+	})
+}
+
+// LargerThan matches files whose size is greater than n bytes.
+func LargerThan(n int64) Expr {
+	return infoPredicate(func(info fs.FileInfo) bool { return info.Size() > n })
+}
+
+// SmallerThan matches files whose size is less than n bytes.
+func SmallerThan(n int64) Expr {
+	return infoPredicate(func(info fs.FileInfo) bool { return info.Size() < n })
+}
+
+// ModifiedAfter matches files that were last modified after t.
+func ModifiedAfter(t time.Time) Expr {
+	return infoPredicate(func(info fs.FileInfo) bool { return info.ModTime().After(t) })
+}
+
+// ModifiedBefore matches files that were last modified before t.
+func ModifiedBefore(t time.Time) Expr {
+	return infoPredicate(func(info fs.FileInfo) bool { return info.ModTime().Before(t) })
+}
+
+type depthExpr struct {
+	min, max int
+}
+
+// MinDepth matches files at least n levels below the starting directory, whose depth is 0.
+func MinDepth(n int) Expr {
+	return depthExpr{min: n, max: -1}
+}
+
+// MaxDepth matches files at most n levels below the starting directory, whose depth is 0. Directories at depth n are
+// not read.
+func MaxDepth(n int) Expr {
+	return depthExpr{max: n}
+}
+
+func (e depthExpr) eval(f *findFile) (bool, error) {
+	depth := len(f.names)
+	return depth >= e.min && (e.max < 0 || depth <= e.max), nil
+}
+
+func (e depthExpr) mayContain(names []string) bool {
+	return e.max < 0 || len(names) < e.max
+}
+
+func (e depthExpr) err() error {
+	return nil
+}
+
+type andExpr []Expr
+
+// And matches files that satisfy each of the given expressions. The expressions are evaluated in order, and evaluation
+// stops at the first expression that is not satisfied, so inexpensive expressions should come first. And with no
+// expressions matches every file.
+func And(exprs ...Expr) Expr {
+	return andExpr(exprs)
+}
+
+func (e andExpr) eval(f *findFile) (bool, error) {
+	for _, x := range e {
+		if ok, err := x.eval(f); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (e andExpr) mayContain(names []string) bool {
+	for _, x := range e {
+		if !x.mayContain(names) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e andExpr) err() error {
+	var errs []error
+	for _, x := range e {
+		errs = append(errs, x.err())
+	}
+	return errors.Join(errs...)
+}
+
+type orExpr []Expr
+
+// Or matches files that satisfy any of the given expressions. The expressions are evaluated in order, and evaluation
+// stops at the first expression that is satisfied. Or with no expressions matches no files.
+func Or(exprs ...Expr) Expr {
+	return orExpr(exprs)
+}
+
+func (e orExpr) eval(f *findFile) (bool, error) {
+	for _, x := range e {
+		if ok, err := x.eval(f); ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (e orExpr) mayContain(names []string) bool {
+	for _, x := range e {
+		if x.mayContain(names) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e orExpr) err() error {
+	return andExpr(e).err()
+}
+
+type notExpr struct {
+	x Expr
+}
+
+// Not matches files that do not satisfy x.
+func Not(x Expr) Expr {
+	return notExpr{x}
+}
+
+func (e notExpr) eval(f *findFile) (bool, error) {
+	ok, err := e.x.eval(f)
+	return !ok && err == nil, err
+}
+
+func (e notExpr) mayContain(names []string) bool {
+	return true
+}
+
+func (e notExpr) err() error {
+	return e.x.err()
+}
+
+// traversal returns the glob that Find matches against the tree for the given expression: the glob of a Path that must
+// be satisfied by every file that satisfies the expression, or nil if there is none.
+func traversal(e Expr) Glob {
+	switch e := e.(type) {
+	case pathExpr:
+		return e.g
+	case andExpr:
+		for _, x := range e {
+			if g := traversal(x); g != nil {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// FindOptions controls the behavior of Find.
+type FindOptions struct {
+	// Prune, if non-nil, prevents Find from descending into the directories beneath the starting directory that satisfy
+	// it. Pruned directories are not yielded. If Prune cannot be evaluated for a directory, the directory is not
+	// pruned, and the error is yielded if the directory is visited.
+	Prune Expr
+}
+
+// Find returns a sequence of (string, error) pairs for the files and directories under dir, including dir itself, that
+// satisfy expr, in the order in which a match would yield them. It is a replacement for the find command that performs
+// a single traversal of the tree: a directory is read only if a file beneath it may satisfy expr, so expressions such as
+// MaxDepth and Path bound the traversal.
+//
+// The error portion of a pair is non-nil if a directory cannot be read or if a file's information cannot be fetched to
+// evaluate a predicate such as LargerThan. If expr or options.Prune is malformed, Find yields the error paired with dir
+// and stops.
+func Find(fsys fs.FS, dir string, expr Expr, options FindOptions) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		dir = path.Clean(dir)
+		var errs []error
+		if errs = append(errs, expr.err()); options.Prune != nil {
+			errs = append(errs, options.Prune.err())
+		}
+		if err := errors.Join(errs...); err != nil {
+			yield(dir, err)
+			return
+		}
+
+		pruned := func(f *findFile) (bool, error) {
+			if options.Prune == nil || len(f.names) == 0 || !f.entry.IsDir() {
+				return false, nil
+			}
+			return options.Prune.eval(f)
+		}
+
+		g := traversal(expr)
+		if g == nil {
+			g = allGlob{}
+		}
+		matchOptions := MatchOptions{
+			Dirs:        DirsPre,
+			IncludeRoot: true,
+			descend: func(p string, entry fs.DirEntry) bool {
+				f := &findFile{path: p, names: splitPath(relativePath(dir, p)), entry: entry}
+				prune, _ := pruned(f)
+				return !prune && expr.mayContain(f.names)
+			},
+		}
+		for r := range MatchResults(g, fsys, dir, matchOptions) {
+			if r.Err != nil {
+				if !yield(r.Path, r.Err) {
+					return
+				}
+				continue
+			}
+
+			f := &findFile{path: r.Path, names: splitPath(relativePath(dir, r.Path)), entry: r.DirEntry}
+			prune, err := pruned(f)
+			if prune {
+				continue
+			}
+			if err == nil {
+				var ok bool
+				if ok, err = expr.eval(f); !ok && err == nil {
+					continue
+				}
+			}
+			if !yield(r.Path, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package glob
+
+import (
+	"io/fs"
+	"maps"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"README.md":            {Data: []byte("readme"), ModTime: now},
+		"src/main.go":          {Data: make([]byte, 2048), ModTime: now.Add(-time.Hour)},
+		"src/main_test.go":     {Data: make([]byte, 100), ModTime: now},
+		"src/lib/lib.go":       {Data: make([]byte, 4096), ModTime: now.Add(-48 * time.Hour)},
+		"src/lib/deep/deep.go": {Data: make([]byte, 10), ModTime: now},
+		".git/objects/ab/cdef": {Data: make([]byte, 8192)},
+		"build/out.bin":        {Data: make([]byte, 1<<20)},
+		"link.go":              {Data: []byte("src/main.go"), Mode: fs.ModeSymlink},
+	}
+
+	g, err := New([]string{"src/**"}, []string{"**/deep"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		expr     Expr
+		options  FindOptions
+		expected []string
+	}{
+		{"name", Name("*.go"), FindOptions{}, []string{"link.go", "src/lib/deep/deep.go", "src/lib/lib.go", "src/main.go", "src/main_test.go"}},
+		{"name flags", Name("(?i)readme.*"), FindOptions{}, []string{"README.md"}},
+		{"type", And(Name("*.go"), Type(0)), FindOptions{}, []string{"src/lib/deep/deep.go", "src/lib/lib.go", "src/main.go", "src/main_test.go"}},
+		{"dirs", And(MinDepth(1), Type(fs.ModeDir)), FindOptions{Prune: Name(".git")}, []string{"build", "src", "src/lib", "src/lib/deep"}},
+		{"size", And(Type(0), LargerThan(1000), SmallerThan(5000)), FindOptions{}, []string{"src/lib/lib.go", "src/main.go"}},
+		{"mtime", And(Name("*.go"), Type(0), ModifiedBefore(now.Add(-time.Minute))), FindOptions{}, []string{"src/lib/lib.go", "src/main.go"}},
+		{"mtime after", And(Type(0), ModifiedAfter(now.Add(-2*time.Hour)), Not(ModifiedAfter(now.Add(-time.Minute)))), FindOptions{}, []string{"src/main.go"}},
+		{"depth", And(MaxDepth(2), Name("*.go")), FindOptions{}, []string{"link.go", "src/main.go", "src/main_test.go"}},
+		{"or", Or(Name("*.md"), Name("*_test.go")), FindOptions{}, []string{"README.md", "src/main_test.go"}},
+		{"not", And(Type(0), Not(Or(Name("*.go"), Name("*.md")))), FindOptions{Prune: Or(Name(".git"), Name("build"))}, nil},
+		{"path", And(Path(g), Type(0), Not(Name("*_test.go"))), FindOptions{}, []string{"src/lib/lib.go", "src/main.go"}},
+		{"root", MaxDepth(0), FindOptions{}, []string{"."}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			matches, err := CollectSorted(Find(fsys, ".", c.expr, c.options))
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, matches)
+		})
+	}
+}
+
+func TestFindTraversal(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"a/b/c/d.go":  {},
+			"a/x.go":      {},
+			"vendor/v.go": {},
+			"other/o.go":  {},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+
+	// MaxDepth bounds the traversal.
+	matches, err := CollectSorted(Find(fsys, ".", And(Name("*.go"), MaxDepth(2)), FindOptions{Prune: Name("vendor")}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/x.go", "other/o.go"}, matches)
+	assert.Equal(t, []string{".", "a", "other"}, slices.Sorted(maps.Keys(fsys.reads)))
+
+	// A top-level Path is matched against the tree.
+	clear(fsys.reads)
+	g, err := New([]string{"a/b/**/*.go"}, nil)
+	require.NoError(t, err)
+	matches, err = CollectSorted(Find(fsys, ".", And(Type(0), Path(g)), FindOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b/c/d.go"}, matches)
+	assert.NotContains(t, fsys.reads, "other")
+	assert.NotContains(t, fsys.reads, "vendor")
+}
+
+func TestFindErrors(t *testing.T) {
+	fsys := fstest.MapFS{"a.go": {}}
+
+	for _, expr := range []Expr{Name("["), Name("a/b"), And(Type(0), Not(Name("**{2}")))} {
+		var paths []string
+		for p, err := range Find(fsys, ".", expr, FindOptions{}) {
+			assert.Error(t, err)
+			paths = append(paths, p)
+		}
+		assert.Equal(t, []string{"."}, paths)
+	}
+
+	_, err := Collect(Find(fsys, ".", Type(0), FindOptions{Prune: Name("[")}))
+	assert.Error(t, err)
+
+	// Directories that cannot be read are reported, and the traversal continues.
+	failing := readDirFailFS{MapFS: fstest.MapFS{"a/b.go": {}, "c.go": {}}, dir: "a"}
+	matches, err := Collect(Find(failing, ".", Name("*.go"), FindOptions{}))
+	assert.Error(t, err)
+	assert.Equal(t, []string{"c.go"}, matches)
+}
@@ -0,0 +1,37 @@
+package fsadapter
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/spf13/afero"
+)
+
+// FromAfero exposes an afero.Fs as an fs.FS. The names of files in the returned filesystem are passed to fsys as-is;
+// to expose a subtree of an operating system filesystem, wrap it in an afero.BasePathFs.
+//
+// The returned filesystem also implements fs.StatFS and fs.ReadDirFS.
+func FromAfero(fsys afero.Fs) fs.FS {
+	return adapter{b: aferoBackend{fsys: fsys}}
+}
+
+type aferoBackend struct {
+	fsys afero.Fs
+}
+
+func (b aferoBackend) stat(name string) (fs.FileInfo, error) {
+	return b.fsys.Stat(name)
+}
+
+func (b aferoBackend) readDir(name string) ([]fs.FileInfo, error) {
+	f, err := b.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdir(-1)
+}
+
+func (b aferoBackend) open(name string) (io.ReadCloser, error) {
+	return b.fsys.Open(name)
+}
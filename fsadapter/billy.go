@@ -0,0 +1,32 @@
+package fsadapter
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+// FromBilly exposes a billy.Filesystem as an fs.FS. The names of files in the returned filesystem are relative to the
+// root of fsys.
+//
+// The returned filesystem also implements fs.StatFS and fs.ReadDirFS.
+func FromBilly(fsys billy.Filesystem) fs.FS {
+	return adapter{b: billyBackend{fsys: fsys}}
+}
+
+type billyBackend struct {
+	fsys billy.Filesystem
+}
+
+func (b billyBackend) stat(name string) (fs.FileInfo, error) {
+	return b.fsys.Stat(name)
+}
+
+func (b billyBackend) readDir(name string) ([]fs.FileInfo, error) {
+	return b.fsys.ReadDir(name)
+}
+
+func (b billyBackend) open(name string) (io.ReadCloser, error) {
+	return b.fsys.Open(name)
+}
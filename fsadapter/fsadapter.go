@@ -0,0 +1,170 @@
+// Package fsadapter exposes other filesystem abstractions as io/fs filesystems that can be matched by package glob.
+//
+// The adapters implement fs.FS, fs.StatFS, and fs.ReadDirFS with the semantics that io/fs requires of them: names are
+// validated with fs.ValidPath, directories can be opened and read, directory entries are sorted by name, reading the
+// entries of a file fails, and errors are *fs.PathErrors that carry the io/fs name of the file.
+package fsadapter
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+var (
+	errNotDir = errors.New("not a directory")
+	errIsDir  = errors.New("is a directory")
+)
+
+// A backend is a filesystem abstraction that is adapted to io/fs. Names passed to a backend are valid io/fs names.
+type backend interface {
+	// stat returns information about the named file, following symbolic links.
+	stat(name string) (fs.FileInfo, error)
+	// readDir returns information about the entries of the named directory in any order.
+	readDir(name string) ([]fs.FileInfo, error)
+	// open opens the named regular file for reading.
+	open(name string) (io.ReadCloser, error)
+}
+
+// An adapter implements io/fs on top of a backend.
+type adapter struct {
+	b backend
+}
+
+func (a adapter) Open(name string) (fs.File, error) {
+	info, err := a.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dir{a: a, name: name, info: info}, nil
+	}
+	r, err := a.b.open(name)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return &file{r: r, info: info}, nil
+}
+
+func (a adapter) Stat(name string) (fs.FileInfo, error) {
+	return a.stat("stat", name)
+}
+
+func (a adapter) stat(op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := a.b.stat(name)
+	if err != nil {
+		return nil, pathError(op, name, err)
+	}
+	// Backends disagree about the name of the root directory.
+	if name == "." && info.Name() != "." {
+		info = rootInfo{info}
+	}
+	return info, nil
+}
+
+func (a adapter) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, err := a.stat("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	infos, err := a.b.readDir(name)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fs.FileInfoToDirEntry(info)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// pathError wraps an error returned by a backend in an *fs.PathError for the given io/fs name. If the error is already
+// an *fs.PathError, its backend-specific path is replaced.
+func pathError(op, name string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// rootInfo renames a backend's root directory to ".".
+type rootInfo struct {
+	fs.FileInfo
+}
+
+func (rootInfo) Name() string {
+	return "."
+}
+
+// A file is an open regular file.
+type file struct {
+	r    io.ReadCloser
+	info fs.FileInfo
+}
+
+func (f *file) Read(b []byte) (int, error) {
+	return f.r.Read(b)
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	return f.r.Close()
+}
+
+// A dir is an open directory. Its entries are read when they are first requested.
+type dir struct {
+	a       adapter
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *dir) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errIsDir}
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.a.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,121 @@
+package fsadapter
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/pgavlin/glob"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var files = map[string]string{
+	"src/main.go":       "package main",
+	"src/lib/lib.go":    "package lib",
+	"src/lib/b.txt":     "b",
+	"src/lib/a.txt":     "a",
+	"docs/readme.md":    "# readme",
+	"docs/guide/zz.md":  "zz",
+	"docs/guide/aa.md":  "aa",
+	"docs/guide/mm.txt": "mm",
+}
+
+func TestAdapters(t *testing.T) {
+	adapters := []struct {
+		name  string
+		newFS func(t *testing.T) fs.FS
+		// modTimes is false if the backend reports the current time as the modification time of every file, which
+		// fstest.TestFS rejects.
+		modTimes bool
+	}{
+		{"afero-mem", func(t *testing.T) fs.FS {
+			fsys := afero.NewMemMapFs()
+			for name, data := range files {
+				require.NoError(t, afero.WriteFile(fsys, name, []byte(data), 0o644))
+			}
+			return FromAfero(fsys)
+		}, true},
+		{"afero-os", func(t *testing.T) fs.FS {
+			fsys := afero.NewBasePathFs(afero.NewOsFs(), t.TempDir())
+			for name, data := range files {
+				require.NoError(t, fsys.MkdirAll(path.Dir(name), 0o755))
+				require.NoError(t, afero.WriteFile(fsys, name, []byte(data), 0o644))
+			}
+			return FromAfero(fsys)
+		}, true},
+		{"billy-mem", func(t *testing.T) fs.FS {
+			fsys := memfs.New()
+			for name, data := range files {
+				f, err := fsys.Create(name)
+				require.NoError(t, err)
+				_, err = f.Write([]byte(data))
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			}
+			return FromBilly(fsys)
+		}, false},
+		{"billy-os", func(t *testing.T) fs.FS {
+			fsys := osfs.New(t.TempDir(), osfs.WithBoundOS())
+			for name, data := range files {
+				require.NoError(t, fsys.MkdirAll(path.Dir(name), 0o755))
+				f, err := fsys.Create(name)
+				require.NoError(t, err)
+				_, err = f.Write([]byte(data))
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			}
+			return FromBilly(fsys)
+		}, true},
+	}
+
+	for _, a := range adapters {
+		t.Run(a.name, func(t *testing.T) {
+			fsys := a.newFS(t)
+
+			if a.modTimes {
+				var expected []string
+				for name := range files {
+					expected = append(expected, name)
+				}
+				require.NoError(t, fstest.TestFS(fsys, expected...))
+			}
+
+			var walked []string
+			err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+				require.NoError(t, err)
+				walked = append(walked, p)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{
+				".",
+				"docs", "docs/guide", "docs/guide/aa.md", "docs/guide/mm.txt", "docs/guide/zz.md", "docs/readme.md",
+				"src", "src/lib", "src/lib/a.txt", "src/lib/b.txt", "src/lib/lib.go", "src/main.go",
+			}, walked)
+
+			_, err = fs.ReadDir(fsys, "src/main.go")
+			assert.Error(t, err)
+
+			_, err = fs.Stat(fsys, "missing")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+
+			_, err = fs.Stat(fsys, "/src")
+			assert.ErrorIs(t, err, fs.ErrInvalid)
+
+			info, err := fs.Stat(fsys, ".")
+			require.NoError(t, err)
+			assert.Equal(t, ".", info.Name())
+
+			g, err := glob.New([]string{"**/*.md", "src/lib/*"}, []string{"docs/guide/z*"})
+			require.NoError(t, err)
+			paths, err := glob.Collect(g.Match(fsys, ".", false))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"docs/guide/aa.md", "docs/readme.md", "src/lib/a.txt", "src/lib/b.txt", "src/lib/lib.go"}, paths)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/pgavlin/glob/fsadapter
+
+go 1.24.0
+
+require (
+	github.com/go-git/go-billy/v5 v5.7.0
+	github.com/pgavlin/glob v0.0.0-00010101000000-000000000000
+	github.com/spf13/afero v1.15.0
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/cyphar/filepath-securejoin v0.3.6 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pgavlin/fx/v2 v2.0.11 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/pgavlin/glob => ../
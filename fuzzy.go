@@ -0,0 +1,153 @@
+package glob
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"path"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scores awarded by FuzzyScore.
+const (
+	// fuzzyMatch is awarded for each matched character.
+	fuzzyMatch = 1
+	// fuzzyStart is awarded for matching the first character of the name.
+	fuzzyStart = 10
+	// fuzzyBoundary is awarded for matching the first character of a word: a character that follows a separator, or an
+	// upper-case letter that follows a lower-case letter.
+	fuzzyBoundary = 6
+	// fuzzyConsecutive is awarded for matching the character after the previous match.
+	fuzzyConsecutive = 6
+	// fuzzyGap is deducted for each character skipped between matches, and for each character before the first match
+	// up to fuzzyMaxLeadingGap characters.
+	fuzzyGap           = 1
+	fuzzyMaxLeadingGap = 3
+)
+
+// FuzzyScore reports whether query is a subsequence of name, ignoring case, and if so, how well it matches. Higher
+// scores are better matches: characters that match at the start of the name, at the start of a word within the name,
+// or immediately after the previous match score more, and skipped characters score less. An empty query matches every
+// name with a score of zero.
+//
+// For example, the query "fb" matches "fbar.go" better than "foo_bar.go", and both better than "xfxb.go".
+func FuzzyScore(query, name string) (int, bool) {
+	q, n := []rune(query), []rune(name)
+	if len(q) == 0 {
+		return 0, true
+	}
+	if len(q) > len(n) {
+		return 0, false
+	}
+
+	// scores[j] is the best score of the query so far with its last character matched at n[j], or -1 if there is no
+	// such match. Scores are offset by the worst possible penalty so that they are non-negative.
+	offset := fuzzyGap * (len(n) + fuzzyMaxLeadingGap)
+	scores, next := make([]int, len(n)), make([]int, len(n))
+	for j, c := range n {
+		scores[j] = -1
+		if equalFold(q[0], c) {
+			scores[j] = offset + fuzzyBonus(n, j) - fuzzyGap*min(j, fuzzyMaxLeadingGap)
+		}
+	}
+	for _, qc := range q[1:] {
+		// best is the best score of a match at or before n[j-2], adjusted so that subtracting j yields the gap penalty
+		// for a match at n[j].
+		best := -1
+		for j, c := range n {
+			next[j] = -1
+			if j >= 2 && scores[j-2] >= 0 {
+				best = max(best, scores[j-2]+fuzzyGap*(j-1))
+			}
+			if !equalFold(qc, c) {
+				continue
+			}
+			if best >= 0 {
+				next[j] = best - fuzzyGap*j + fuzzyBonus(n, j)
+			}
+			if j >= 1 && scores[j-1] >= 0 {
+				next[j] = max(next[j], scores[j-1]+fuzzyBonus(n, j)+fuzzyConsecutive)
+			}
+		}
+		scores, next = next, scores
+	}
+
+	score := slices.Max(scores)
+	if score < 0 {
+		return 0, false
+	}
+	return score - offset, true
+}
+
+// fuzzyBonus returns the score for matching the character n[j].
+func fuzzyBonus(n []rune, j int) int {
+	switch {
+	case j == 0:
+		return fuzzyMatch + fuzzyStart
+	case isSeparator(n[j-1]) || unicode.IsLower(n[j-1]) && unicode.IsUpper(n[j]):
+		return fuzzyMatch + fuzzyBoundary
+	default:
+		return fuzzyMatch
+	}
+}
+
+// isSeparator reports whether c separates the words of a name.
+func isSeparator(c rune) bool {
+	switch c {
+	case '_', '-', '.', ' ':
+		return true
+	}
+	return false
+}
+
+// equalFold reports whether a and b are equal under simple case folding.
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	if a < utf8.RuneSelf && b < utf8.RuneSelf {
+		return 'A' <= a && a <= 'Z' && a+'a'-'A' == b || 'A' <= b && b <= 'Z' && b+'a'-'A' == a
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// A FuzzyMatch is a path found by MatchFuzzy.
+type FuzzyMatch struct {
+	// Path is the matching path.
+	Path string
+	// Score is the score of the path's name as computed by FuzzyScore.
+	Score int
+}
+
+// MatchFuzzy matches g against dir and returns the matching files whose names match query as scored by FuzzyScore,
+// best match first. Matches with equal scores are ordered by the length of their names and then by path. The query is
+// matched against the last element of each path only, so the traversal is the same as that of g.Match: an editor's
+// quick-open can reuse the globs that bound its workspace and rank the results by the user's input. Errors reading
+// directories are returned alongside the matches.
+func MatchFuzzy(g Glob, fsys fs.FS, dir, query string) ([]FuzzyMatch, error) {
+	var matches []FuzzyMatch
+	var errs []error
+	for p, err := range g.Match(fsys, dir, false) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if score, ok := FuzzyScore(query, path.Base(p)); ok {
+			matches = append(matches, FuzzyMatch{Path: p, Score: score})
+		}
+	}
+	slices.SortFunc(matches, func(a, b FuzzyMatch) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(len(path.Base(a.Path)), len(path.Base(b.Path))),
+			cmp.Compare(a.Path, b.Path))
+	})
+	return matches, errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package glob
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	score := func(query, name string) int {
+		s, ok := FuzzyScore(query, name)
+		require.True(t, ok, "%q %q", query, name)
+		return s
+	}
+
+	assert.Greater(t, score("fb", "fbar.go"), score("fb", "foo_bar.go"))
+	assert.Greater(t, score("fb", "foo_bar.go"), score("fb", "xfxb.go"))
+	assert.Greater(t, score("fb", "FooBar.go"), score("fb", "foobar.go"))
+	assert.Greater(t, score("main", "main.go"), score("main", "domain.go"))
+	assert.Equal(t, score("FB", "fbar.go"), score("fb", "fbar.go"))
+	assert.Equal(t, score("éb", "Ébar"), score("eb", "ebar"))
+	assert.Equal(t, 0, score("", "x.go"))
+
+	for _, c := range [][2]string{{"fb", "buffer.go"}, {"main.go", "main"}, {"xyz", "x_y.go"}} {
+		_, ok := FuzzyScore(c[0], c[1])
+		assert.False(t, ok, "%q %q", c[0], c[1])
+	}
+}
+
+func TestMatchFuzzy(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"src/fbar.go":        {},
+			"src/foo_bar.go":     {},
+			"src/lib/xfxb.go":    {},
+			"src/buffer.go":      {},
+			"src/fb.txt":         {},
+			"node_modules/fb.go": {},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+	g, err := New([]string{"**/*.go"}, []string{"node_modules"})
+	require.NoError(t, err)
+
+	matches, err := MatchFuzzy(g, fsys, ".", "fb")
+	require.NoError(t, err)
+
+	var paths []string
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	assert.Equal(t, []string{"src/fbar.go", "src/foo_bar.go", "src/lib/xfxb.go"}, paths)
+	assert.NotContains(t, fsys.reads, "node_modules")
+}
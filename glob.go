@@ -6,297 +6,573 @@ package glob
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
 	"iter"
 	"path"
 	"slices"
+	"strconv"
 	"strings"
-
-	"github.com/pgavlin/fx/v2"
+	"sync"
+	"unicode/utf8"
 )
 
+// segments caches the compiled form of each pattern element, as every element is matched against many names.
+var segments sync.Map // map[string]*segmentMatcher
+
 func match(pattern, name string) bool {
-	ok, _ := path.Match(pattern, name)
-	return ok
+	m, ok := segments.Load(pattern)
+	if !ok {
+		compiled, err := compileSegment(pattern)
+		if err != nil {
+			return false
+		}
+		m, _ = segments.LoadOrStore(pattern, compiled)
+	}
+	return m.(*segmentMatcher).match(name)
+}
+
+// A step is a single compiled element of a pattern.
+type step struct {
+	text    string
+	matcher *segmentMatcher
+
+	// hideDot is true if the step does not match names that begin with '.'.
+	hideDot bool
+
+	// rule is the 1-based position of the exclude pattern that contains the step, and negate is true if that pattern
+	// re-includes the paths it matches. Both are zero for include patterns.
+	rule   int
+	negate bool
+}
+
+// globstar is the step for '**'.
+var globstar = step{text: "**"}
+
+// newStep compiles a single pattern element.
+func newStep(text string, options Options) (step, error) {
+	hideDot := options.ExplicitDot && !strings.HasPrefix(text, ".") && !strings.HasPrefix(text, `\.`)
+
+	// Note that '**' is a valid element pattern, so we don't need to check for it explicitly.
+	if text == "**" {
+		return step{text: text, hideDot: hideDot}, nil
+	}
+	m, err := compileSegmentWith(text, options)
+	if err != nil {
+		return step{}, err
+	}
+	return step{text: text, matcher: m, hideDot: hideDot}, nil
+}
+
+func (s step) isGlobstar() bool {
+	return s.matcher == nil
+}
+
+// match reports whether the step matches the given name. A '**' step matches any name.
+func (s step) match(name string) bool {
+	if s.hideDot && strings.HasPrefix(name, ".") {
+		return false
+	}
+	return s.isGlobstar() || s.matcher.match(name)
 }
 
 // A pattern represents a single glob pattern.
 //
 // The first entry in the pattern represents the pattern to apply to each entry in the current directory; the rest of
 // the entries apply to child directories.
-type pattern []string
+type pattern []step
 
 func (p pattern) String() string {
-	return path.Join(p...)
+	texts := make([]string, len(p))
+	for i, s := range p {
+		texts[i] = s.text
+	}
+	return path.Join(texts...)
 }
 
-// newPattern creates a new pattern from the given string.
-func newPattern(p string, patterns *[]pattern) error {
-	// Validate the pattern. Note that '**' is a valid path pattern, so we don't need to check for it explicitly.
-	_, err := path.Match(p, "")
+// GoString formats the pattern for debugging. The pattern's text is followed by its rule and any flags that apply to it.
+func (p pattern) GoString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pattern(%q", p.String())
+	if len(p) != 0 {
+		s := p[0]
+		if s.rule != 0 {
+			fmt.Fprintf(&b, " rule=%d", s.rule)
+		}
+		if s.negate {
+			b.WriteString(" negate")
+		}
+		if s.hideDot {
+			b.WriteString(" hideDot")
+		}
+		if p.fold() {
+			b.WriteString(" fold")
+		}
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// maxAlternatives is the maximum number of alternatives a single pattern may expand to.
+const maxAlternatives = 1024
+
+// compilePattern splits a pattern into its consituent elements, strips out any empty elements, and compiles each
+// element. Unlike newPattern, it does not add advancements for leading '**' elements.
+//
+// A pattern that contains bounded '**' elements expands to one alternative for each combination of depths, where each
+// level is matched by a '*' step. Other patterns have a single alternative.
+func compilePattern(p string, options Options) ([]pattern, error) {
+	source := p
+	p, options, err := parseFlags(p, options)
 	if err != nil {
-		return err
+		return nil, locateError(err, source, false, 0)
+	}
+
+	// In basename mode, a pattern that names a single file or directory matches at any depth.
+	basename := options.Basename && p != "" && !strings.Contains(p, "/")
+
+	// offset is the position of each element within the source text.
+	alternatives, offset := []pattern{nil}, len(source)-len(p)
+	for text := range strings.SplitSeq(p, "/") {
+		start := offset
+		if offset += len(text) + 1; text == "" {
+			continue
+		}
+
+		min, max, bounded, err := parseBounds(text)
+		if err != nil {
+			return nil, locateError(shiftError(err, start), source, false, 0)
+		}
+		if !bounded {
+			s, err := newStep(text, options)
+			if err != nil {
+				return nil, locateError(shiftError(err, start), source, false, 0)
+			}
+			for i, alt := range alternatives {
+				// Consecutive '**' elements are equivalent to a single '**'.
+				if s.isGlobstar() && len(alt) != 0 && alt[len(alt)-1].isGlobstar() {
+					continue
+				}
+				alternatives[i] = append(alt, s)
+			}
+			continue
+		}
+
+		basename = false
+		if len(alternatives)*(max-min+1) > maxAlternatives {
+			return nil, locateError(syntaxError(start, start+len(text), "too many alternatives"), source, false, 0)
+		}
+		star, _ := newStep("*", options)
+		var expanded []pattern
+		for _, alt := range alternatives {
+			for n := min; n <= max; n++ {
+				expanded = append(expanded, append(slices.Clip(alt), slices.Repeat([]step{star}, n)...))
+			}
+		}
+		alternatives = expanded
+	}
+	for i, steps := range alternatives {
+		if len(steps) == 0 {
+			s, _ := newStep("", options)
+			alternatives[i] = pattern{s}
+		}
+	}
+	if basename && !alternatives[0][0].isGlobstar() {
+		globstar, _ := newStep("**", options)
+		alternatives[0] = append(pattern{globstar}, alternatives[0]...)
 	}
+	return alternatives, nil
+}
 
-	// Split the pattern into its consituent elements and strip out any empty patterns.
-	steps := slices.Collect(fx.Filter(strings.SplitSeq(p, "/"), func(s string) bool { return s != "" }))
-	if len(steps) == 0 {
-		steps = []string{""}
+// parseBounds parses a bounded '**' element of the form '**{n}', which matches exactly n levels of directories, or
+// '**{min,max}', which matches between min and max levels. If text is not a bounded '**', parseBounds returns false.
+func parseBounds(text string) (min, max int, bounded bool, err error) {
+	bounds, ok := strings.CutPrefix(text, "**{")
+	if !ok {
+		return 0, 0, false, nil
+	}
+	bounds, ok = strings.CutSuffix(bounds, "}")
+	if !ok {
+		return 0, 0, false, syntaxError(0, len(text), "unterminated '**' bounds")
+	}
+
+	lo, hi, isRange := strings.Cut(bounds, ",")
+	if min, err = strconv.Atoi(lo); err != nil || min < 0 {
+		return 0, 0, false, syntaxError(3, 3+len(lo), "invalid '**' bound %q", lo)
+	}
+	max = min
+	if isRange {
+		if max, err = strconv.Atoi(hi); err != nil || max < min {
+			return 0, 0, false, syntaxError(4+len(lo), len(text)-1, "invalid '**' bound %q", hi)
+		}
+	}
+	return min, max, true, nil
+}
+
+// parseFlags removes a leading flag group from p and applies its flags to options. A flag group has the form '(?flags)'
+// or '(?flags-flags)'; flags before the '-' are set, and flags after it are cleared. The flags are:
+//
+//	i    match without regard to case (Options.CaseInsensitive)
+//	d    allow wildcards to match a leading '.' (the opposite of Options.ExplicitDot)
+//	b    match a pattern without a '/' against names at any depth (Options.Basename)
+func parseFlags(p string, options Options) (string, Options, error) {
+	if !strings.HasPrefix(p, "(?") {
+		return p, options, nil
+	}
+	end := strings.IndexByte(p, ')')
+	if end == -1 {
+		return "", Options{}, syntaxError(0, len(p), "unterminated flag group")
+	}
+
+	set := true
+	for i, c := range p[2:end] {
+		switch c {
+		case '-':
+			if !set {
+				return "", Options{}, syntaxError(i+2, i+3, "repeated '-' in flag group")
+			}
+			set = false
+		case 'i':
+			options.CaseInsensitive = set
+		case 'd':
+			options.ExplicitDot = !set
+		case 'b':
+			options.Basename = set
+		default:
+			return "", Options{}, syntaxError(i+2, i+2+utf8.RuneLen(c), "unknown flag %q", c)
+		}
+	}
+	return p[end+1:], options, nil
+}
+
+// newPattern creates a new pattern from the given string.
+func newPattern(p string, options Options, patterns *[]pattern) error {
+	alternatives, err := compilePattern(p, options)
+	if err != nil {
+		return err
 	}
 
 	// Append the pattern. If the pattern starts with "**", also append its advancement. This allows "**/foo" to match "foo" in the root directory.
-	*patterns = append(*patterns, pattern(steps))
-	if steps[0] == "**" && len(steps) != 1 {
-		*patterns = append(*patterns, pattern(steps[1:]))
+	for _, steps := range alternatives {
+		*patterns = append(*patterns, steps)
+		if steps[0].isGlobstar() && len(steps) != 1 {
+			*patterns = append(*patterns, steps[1:])
+		}
 	}
 	return nil
 }
 
 // newPatterns is a convenience function to create a list of patterns from a list of strings.
-func newPatterns(ps []string) ([]pattern, error) {
+func newPatterns(ps []string, options Options) ([]pattern, error) {
 	var patterns []pattern
 	var errs []error
-	for _, i := range ps {
-		if err := newPattern(i, &patterns); err != nil {
-			errs = append(errs, err)
+	for i, p := range ps {
+		if err := newPattern(p, options, &patterns); err != nil {
+			errs = append(errs, locateError(err, p, false, i))
 		}
 	}
 	return patterns, errors.Join(errs...)
 }
 
+// newExcludePatterns creates a list of exclude patterns from a list of strings. A pattern that begins with '!' is
+// negated: it re-includes paths that are excluded by earlier patterns.
+func newExcludePatterns(ps []string, options Options) ([]pattern, error) {
+	var patterns []pattern
+	var errs []error
+	for i, p := range ps {
+		text, negate := strings.CutPrefix(p, "!")
+
+		start := len(patterns)
+		if err := newPattern(text, options, &patterns); err != nil {
+			errs = append(errs, locateError(err, p, true, i))
+			continue
+		}
+		for _, pat := range patterns[start:] {
+			for j := range pat {
+				pat[j].rule, pat[j].negate = i+1, negate
+			}
+		}
+	}
+	return patterns, errors.Join(errs...)
+}
+
+// hasNegations reports whether any of the given exclude patterns is negated.
+func hasNegations(excludes []string) bool {
+	return slices.ContainsFunc(excludes, func(p string) bool { return strings.HasPrefix(p, "!") })
+}
+
+// patternListPool holds pattern lists for reuse during traversal. Each directory visited by a traversal needs scratch
+// lists to hold the patterns that apply to its children; pooling these lists avoids allocating new lists at every
+// level of the tree.
+var patternListPool = sync.Pool{
+	New: func() any { return new([]pattern) },
+}
+
+// getPatternList returns an empty pattern list from the pool.
+func getPatternList() *[]pattern {
+	return patternListPool.Get().(*[]pattern)
+}
+
+// putPatternList returns a pattern list to the pool.
+func putPatternList(l *[]pattern) {
+	clear(*l)
+	*l = (*l)[:0]
+	patternListPool.Put(l)
+}
+
 // matchDir attempts to match p against the given directory name.
 //
 // If the current step matches and there are more steps in the pattern, match appends the rest of the pattern to patterns.
 func (p pattern) matchDir(name string, patterns *[]pattern) bool {
 	step, rest := p[0], p[1:]
-	if step == "**" {
-		// If the current step is "**", we always continue matching the pattern.
-		*patterns = append(*patterns, p)
-	} else if !match(step, name) {
+	if !step.match(name) {
 		// If the pattern does not match, we're done.
 		return false
 	}
+	if step.isGlobstar() {
+		// If the current step is "**", we always continue matching the pattern.
+		*patterns = append(*patterns, p)
+	}
 	// If there are no more steps in the pattern, we have a match.
 	if len(rest) == 0 {
 		return true
 	}
 
-	// Otherwise, continue matching.
+	// Otherwise, continue matching. If the rest of the pattern starts with a '**' that is not the last step, also
+	// append its advancement. This allows "a/**/b" to match "a/b".
 	*patterns = append(*patterns, rest)
+	if rest[0].isGlobstar() && len(rest) != 1 {
+		*patterns = append(*patterns, rest[1:])
+	}
 	return false
 }
 
 // matchFile attempts to match p against the given filename.
 func (p pattern) matchFile(name string) bool {
-	return len(p) == 1 && (p[0] == "**" || match(p[0], name))
+	return len(p) == 1 && p[0].match(name)
 }
 
 func always(patterns []pattern) bool {
 	for _, p := range patterns {
-		if len(p) == 1 && p[0] == "**" {
+		if len(p) == 1 && p[0].isGlobstar() && !p[0].hideDot {
 			return true
 		}
 	}
 	return false
 }
 
+// An exclusion records the last exclude pattern that matched a path or one of its ancestors.
+type exclusion struct {
+	rule   int
+	negate bool
+}
+
+// excluded reports whether the path is excluded.
+func (e exclusion) excluded() bool {
+	return e.rule != 0 && !e.negate
+}
+
+// update returns the exclusion that results from matching the given exclude pattern.
+func (e exclusion) update(p pattern) exclusion {
+	if p[0].rule > e.rule {
+		return exclusion{rule: p[0].rule, negate: p[0].negate}
+	}
+	return e
+}
+
+// final reports whether e applies to every path beneath a directory whose remaining exclude patterns are given by
+// exclude. This is the case if the directory is excluded and no later negated pattern may re-include its contents.
+func (e exclusion) final(exclude []pattern) bool {
+	if !e.excluded() {
+		return false
+	}
+	for _, p := range exclude {
+		if p[0].negate && p[0].rule > e.rule {
+			return false
+		}
+	}
+	return true
+}
+
+// excludesAll reports whether every path beneath a directory is excluded, given the exclusion e of the directory and
+// its remaining exclude patterns. A remaining pattern of '**' excludes every path only if it overrides e and no later
+// negated pattern may re-include a path.
+func excludesAll(e exclusion, exclude []pattern) bool {
+	all := e
+	for _, p := range exclude {
+		if len(p) == 1 && p[0].isGlobstar() && !p[0].hideDot && !p[0].negate {
+			all = all.update(p)
+		}
+	}
+	return all.final(exclude)
+}
+
 // hasMeta reports whether p contains any of the metacharacters recognized by path.Match.
 func hasMeta(p string) bool {
 	return strings.ContainsAny(p, "*?[\\")
 }
 
+// literal reports whether the next element of every pattern is the same literal name. If it is, literal returns the
+// name and the patterns that match beneath it. If there are multiple patterns, each must have more elements, so that
+// the literal names a directory.
 func literal(patterns []pattern) (string, []pattern, bool) {
-	if len(patterns) != 1 {
+	if len(patterns) == 0 {
 		return "", nil, false
 	}
 
-	p := patterns[0]
-	if hasMeta(p[0]) {
-		return "", nil, false
+	name := patterns[0][0].text
+	for _, p := range patterns {
+		if p[0].text != name || hasMeta(name) || !p[0].matcher.exact() || len(patterns) > 1 && len(p) == 1 {
+			return "", nil, false
+		}
 	}
 
+	// The literal step always matches, so the next patterns are those that matchDir would produce.
 	var next []pattern
-	if len(p) > 1 {
-		next = []pattern{p[1:]}
+	for _, p := range patterns {
+		p.matchDir(name, &next)
+	}
+	return name, next, true
+}
+
+// matchOptions returns the options that correspond to a call to Match.
+func matchOptions(includeDirs bool) MatchOptions {
+	if includeDirs {
+		return MatchOptions{Dirs: DirsPre}
 	}
-	return p[0], next, true
+	return MatchOptions{}
+}
+
+// sources holds the include and exclude patterns and the options from which a glob was created.
+type sources struct {
+	includes []string
+	excludes []string
+	options  Options
+}
+
+func (s sources) patterns() (includes, excludes []string) {
+	return s.includes, s.excludes
+}
+
+func (s sources) compileOptions() Options {
+	return s.options
+}
+
+// A sourcedGlob is a Glob that retains the patterns and options from which it was created.
+type sourcedGlob interface {
+	Glob
+
+	patterns() (includes, excludes []string)
+	compileOptions() Options
 }
 
 // A matchGlob is a glob formed by a list of patterns to include and a list of patterns to exclude.
 type matchGlob struct {
+	sources
+
 	include []pattern
 	exclude []pattern
+
+	// includeIndex and excludeIndex index the top-level patterns. They are built once so that MatchPath need not
+	// rebuild them for each path.
+	includeIndex patternIndex
+	excludeIndex patternIndex
 }
 
 func (g *matchGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	return g.MatchWith(fsys, dir, matchOptions(includeDirs))
+}
+
+func (g *matchGlob) MatchWith(fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
-		matchStep(fsys, dir, false, includeDirs, g.include, g.exclude, yield)
+		w := newWalker(fsys, options, yield)
+		w.walk(dir, g.include, g.exclude)
 	}
 }
 
 func (g *matchGlob) MatchPath(p string) bool {
-	names := slices.Collect(fx.Filter(strings.SplitSeq(p, "/"), func(s string) bool { return s != "" }))
+	names := splitPath(p)
 	if len(names) == 0 {
 		return false
 	}
 
-	include, exclude := g.include, g.exclude
+	// The pattern lists for each level are double-buffered: the lists for the next level are built from the lists for
+	// the current level, and then the two sets of lists are swapped.
+	nextInclude, nextExclude := getPatternList(), getPatternList()
+	currentInclude, currentExclude := getPatternList(), getPatternList()
+	defer putPatternList(nextInclude)
+	defer putPatternList(nextExclude)
+	defer putPatternList(currentInclude)
+	defer putPatternList(currentExclude)
+
+	// Only the top-level patterns are indexed. The patterns that remain beneath the first level are usually few, so
+	// they are scanned linearly.
+	include, exclude := g.includeIndex, g.excludeIndex
+
+	var e exclusion
 	for _, dir := range names[:len(names)-1] {
-		var nextInclude, nextExclude []pattern
-		for _, p := range exclude {
-			if p.matchDir(dir, &nextExclude) {
-				return false
+		*nextInclude, *nextExclude = (*nextInclude)[:0], (*nextExclude)[:0]
+		for p := range exclude.candidates(dir) {
+			if p.matchDir(dir, nextExclude) {
+				e = e.update(p)
 			}
 		}
-		for _, p := range include {
-			p.matchDir(dir, &nextInclude)
+		if e.final(*nextExclude) {
+			return false
 		}
-		if len(nextInclude) == 0 {
+		for p := range include.candidates(dir) {
+			p.matchDir(dir, nextInclude)
+		}
+		if len(*nextInclude) == 0 {
 			return false
 		}
-		include, exclude = nextInclude, nextExclude
+		include, exclude = patternIndex{others: *nextInclude}, patternIndex{others: *nextExclude}
+		nextInclude, currentInclude = currentInclude, nextInclude
+		nextExclude, currentExclude = currentExclude, nextExclude
 	}
 
-	var nextInclude, nextExclude []pattern
+	*nextInclude, *nextExclude = (*nextInclude)[:0], (*nextExclude)[:0]
 	last := names[len(names)-1]
-	for _, p := range exclude {
-		if p.matchDir(last, &nextExclude) {
-			return false
+	for p := range exclude.candidates(last) {
+		if p.matchDir(last, nextExclude) {
+			e = e.update(p)
 		}
 	}
-	for _, p := range include {
-		if p.matchDir(last, &nextInclude) {
+	if e.excluded() {
+		return false
+	}
+	for p := range include.candidates(last) {
+		if p.matchDir(last, nextInclude) {
 			return true
 		}
 	}
 	return false
 }
 
-// matchStep advances the current matches against the contents of dir.
-func matchStep(fsys fs.FS, dir string, yieldDir, includeDirs bool, include, exclude []pattern, yield func(string, error) bool) bool {
-	var nextInclude, nextExclude []pattern
-
-	if always(include) {
-		if len(exclude) == 0 {
-			return allStep(fsys, dir, yieldDir, includeDirs, yield)
-		}
-		include = []pattern{{"**"}}
-	} else if name, nextInclude, ok := literal(include); ok {
-		for _, p := range exclude {
-			if p.matchFile(name) {
-				return true
-			}
-		}
-
-		info, err := fs.Stat(fsys, path.Join(dir, name))
-		if err != nil {
-			if errors.Is(err, fs.ErrNotExist) {
-				return true
-			}
-			return yield(dir, err)
-		}
-		if info.IsDir() {
-			for _, p := range exclude {
-				p.matchDir(name, &nextExclude)
-			}
-			if len(nextInclude) != 0 && !always(nextExclude) {
-				return matchStep(fsys, path.Join(dir, name), includeDirs, includeDirs, nextInclude, nextExclude, yield)
-			}
-			if !includeDirs {
-				return true
-			}
-		}
-		return yield(path.Join(dir, name), nil)
-	}
-
-	infos, err := fs.ReadDir(fsys, dir)
-	if err != nil {
-		return yield(dir, err)
-	}
-	if yieldDir && includeDirs && !yield(dir, nil) {
-		return false
-	}
-
-match:
-	for _, i := range infos {
-		nextInclude, nextExclude = nextInclude[:0], nextExclude[:0]
+type allGlob struct{ sources }
 
-		var included bool
-		if !i.IsDir() {
-			for _, p := range exclude {
-				if p.matchFile(i.Name()) {
-					continue match
-				}
-			}
-			for _, p := range include {
-				if p.matchFile(i.Name()) {
-					included = true
-					break
-				}
-			}
-		} else {
-			for _, p := range exclude {
-				if p.matchDir(i.Name(), &nextExclude) {
-					continue match
-				}
-			}
-			for _, p := range include {
-				if p.matchDir(i.Name(), &nextInclude) {
-					included = includeDirs
-				}
-			}
-
-			if len(nextInclude) != 0 && !always(nextExclude) {
-				// If there is more to do, the caller will yield the matched directory.
-				if !matchStep(fsys, path.Join(dir, i.Name()), included, includeDirs, nextInclude, nextExclude, yield) {
-					return false
-				}
-				included = false
-			}
-		}
-		if included && !yield(path.Join(dir, i.Name()), nil) {
-			return false
-		}
-	}
-	return true
+func (g allGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	return g.MatchWith(fsys, dir, matchOptions(includeDirs))
 }
 
-type allGlob struct{}
-
-func (allGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+func (allGlob) MatchWith(fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
-		allStep(fsys, dir, false, includeDirs, yield)
+		w := newWalker(fsys, options, yield)
+		w.walk(dir, allPatterns, nil)
 	}
 }
 
 func (allGlob) MatchPath(p string) bool {
-	return true
+	return len(splitPath(p)) != 0
 }
 
-func allStep(fsys fs.FS, dir string, yieldDir, includeDirs bool, yield func(string, error) bool) bool {
-	infos, err := fs.ReadDir(fsys, dir)
-	if err != nil {
-		return yield(dir, err)
-	}
-	if yieldDir && includeDirs && !yield(dir, nil) {
-		return false
-	}
+type noneGlob struct{ sources }
 
-	for _, i := range infos {
-		if i.IsDir() {
-			if !allStep(fsys, path.Join(dir, i.Name()), true, includeDirs, yield) {
-				return false
-			}
-		} else if !yield(path.Join(dir, i.Name()), nil) {
-			return false
-		}
-	}
-	return true
+func (noneGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	return func(_ func(string, error) bool) {}
 }
 
-type noneGlob struct{}
-
-func (noneGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+func (noneGlob) MatchWith(fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
 	return func(_ func(string, error) bool) {}
 }
 
@@ -309,16 +585,67 @@ type Glob interface {
 	// Match returns a sequence of (string, error) pairs for paths under dir that match the glob's include and exclude
 	// patterns. The error portion of a pair is only non-nil when the path portion is a directory and Match fails to
 	// read the directory's entries. If includeDirs is true, matching directories will be included in the sequence prior
-	// to their contents.
+	// to their contents. Each path is yielded at most once, even if it is matched by more than one include pattern.
+	// Match(fsys, dir, includeDirs) is equivalent to the MatchWith function with a Dirs option of DirsPre if
+	// includeDirs is true.
+	//
+	// Match reads directories with fs.ReadDir and looks up the literal elements of patterns with fs.Stat. If fsys does
+	// not implement fs.StatFS and cannot open directories, Match instead looks up each literal element by reading its
+	// parent directory; in this case, symbolic links are not followed.
 	Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error]
 
-	// MatchPath returns true if the given path matches the glob's includes and excludes.
+	// MatchPath returns true if the given path matches the glob's includes and excludes. The path is slash-separated
+	// and relative to the directory being matched. Empty and '.' elements are ignored, so a path that begins with a
+	// slash is anchored to that directory: "/src/main.go" and "./src/main.go" are matched like "src/main.go". '..'
+	// elements are resolved lexically, so "src/lib/../main.go" is also matched like "src/main.go"; a path that refers to
+	// a location above the directory matches nothing. Use MatchPathWith to reject such paths instead.
 	MatchPath(path string) bool
 }
 
+// An OptionsGlob is a Glob whose traversal can be controlled by MatchOptions. Every Glob created by this package is an
+// OptionsGlob. Use the MatchWith function to match any Glob with options.
+type OptionsGlob interface {
+	Glob
+
+	// MatchWith is like Match, but accepts additional options that control the traversal. With DirsBoth, each matching
+	// directory is yielded twice. Some options yield errors paired with paths that are not directories, as
+	// MatchOptions describes: for example, a file that Sniff cannot read, or the first path that is not valid UTF-8
+	// under InvalidUTF8Fail.
+	MatchWith(fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error]
+}
+
+// MatchWith matches g against the paths under dir with the given options. If g is an OptionsGlob, MatchWith calls its
+// MatchWith method. Otherwise, MatchWith reads every directory beneath dir and yields the paths that g.MatchPath
+// matches, relative to dir.
+func MatchWith(g Glob, fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
+	if og, ok := g.(OptionsGlob); ok {
+		return og.MatchWith(fsys, dir, options)
+	}
+
+	dir = path.Clean(dir)
+	return allGlob{}.MatchWith(fsys, dir, filterOptions(g, dir, options))
+}
+
+// filterOptions returns a copy of the given options that omits the paths under dir that g does not match, so that a
+// match of every path with the returned options matches g.
+func filterOptions(g Glob, dir string, options MatchOptions) MatchOptions {
+	filter := options.filter
+	options.filter = func(p string) bool {
+		return (filter == nil || filter(p)) && g.MatchPath(relativePath(dir, p))
+	}
+	return options
+}
+
 // New creates a new Glob from the given lists of include and exclude patterns.
 //
 // A Glob matches a particular path p if any of its include patterns matches p and none of its exclude patterns match p.
+// An exclude pattern that matches a directory matches the directory's contents.
+//
+// An exclude pattern that begins with '!' is negated: it re-includes paths that are excluded by earlier exclude
+// patterns, as in an ignore file. When an exclude list contains negated patterns, the last exclude pattern that matches
+// a path (or one of its ancestors) decides whether the path is excluded. For example, the excludes 'dist/**' and
+// '!dist/manifest.json' exclude everything beneath 'dist' except 'dist/manifest.json'. An exclude pattern that begins
+// with a literal '!' must escape it.
 //
 // The pattern syntax is:
 //
@@ -327,13 +654,17 @@ type Glob interface {
 //
 //	pathTerm:
 //		'**'        matches any sequence of directory names, including the empty sequence
+//		'**{' n '}'
+//		            matches exactly n directory names
+//		'**{' min ',' max '}'
+//		            matches between min and max directory names
 //		{ term }    matches a sequence of terms against a name
 //
 //	term:
 //		'*'         matches any sequence of non-/ characters
 //		'?'         matches any single non-/ character
-//		'[' [ '^' ] { character-range } ']'
-//		            character class (must be non-empty)
+//		'[' [ '^' | '!' ] { character-range } ']'
+//		            character class (must be non-empty); '^' or '!' negates the class
 //		c           matches character c (c != '*', '?', '\\', '[')
 //		'\\' c      matches character c
 //
@@ -342,20 +673,64 @@ type Glob interface {
 //		'\\' c      matches character c
 //		lo '-' hi   matches character c for lo <= c <= hi
 //
+// A pattern may begin with a flag group that overrides the glob's options for that pattern alone, e.g. '(?i)*.md' to
+// match Markdown files without regard to case. A flag group has the form '(?flags)' or '(?flags-flags)'; flags before
+// the '-' are set, and flags after it are cleared. The flags are 'i', for case-insensitive matching, 'd', which
+// allows wildcards to match a leading '.', and 'b', which matches a pattern without a '/' at any depth as described by
+// Options.Basename. A pattern that begins with a literal '(?' must escape the '(' or '?'.
+//
+// A '**' may match the empty sequence anywhere except at the end of a pattern: 'a/**/b' matches 'a/b', but 'a/**'
+// matches only paths beneath 'a'.
+//
+// A '**' that appears within a path term alongside other characters, as in 'foo**bar', is not a globstar: it matches
+// like '*', and so never matches across directories. Check reports such terms.
+//
 // Patterns require that path terms match all of name, not just a substring. If any error is returned, it will be a list
-// of path.ErrBadPattern errors.
+// of *SyntaxError errors, each of which wraps path.ErrBadPattern.
 func New(includes, excludes []string) (Glob, error) {
-	if len(excludes) == 0 && slices.Contains(includes, "**") {
-		return allGlob{}, nil
+	return NewWithOptions(includes, excludes, Options{})
+}
+
+// NewWithOptions is like New, but accepts options that control how the patterns are compiled.
+func NewWithOptions(includes, excludes []string, options Options) (Glob, error) {
+	if err := options.Limits.check(includes, excludes); err != nil {
+		return nil, err
 	}
-	if len(includes) == 0 || slices.Contains(excludes, "**") {
-		return noneGlob{}, nil
+
+	src := sources{includes, excludes, options}
+	if len(excludes) == 0 && slices.Contains(includes, "**") && !options.ExplicitDot {
+		return allGlob{src}, nil
+	}
+	if len(includes) == 0 || slices.Contains(excludes, "**") && !options.ExplicitDot && !hasNegations(excludes) {
+		return noneGlob{src}, nil
 	}
 
-	includePatterns, inclErr := newPatterns(includes)
-	excludePatterns, exclErr := newPatterns(excludes)
+	includePatterns, inclErr := newPatterns(includes, options)
+	excludePatterns, exclErr := newExcludePatterns(excludes, options)
 	if err := errors.Join(inclErr, exclErr); err != nil {
 		return nil, err
 	}
-	return &matchGlob{include: includePatterns, exclude: excludePatterns}, nil
+	return &matchGlob{
+		sources:      src,
+		include:      includePatterns,
+		exclude:      excludePatterns,
+		includeIndex: newPatternIndex(includePatterns),
+		excludeIndex: newPatternIndex(excludePatterns),
+	}, nil
+}
+
+// MatchPathWith is like g.MatchPath, but accepts options that control how the path is interpreted. If the path is
+// rejected by the options, MatchPathWith returns an *AbsolutePathError or a *DotDotError.
+func MatchPathWith(g Glob, p string, options PathOptions) (bool, error) {
+	original := p
+	if options.WindowsSeparators {
+		p = strings.ReplaceAll(p, `\`, "/")
+	}
+	if options.Absolute == AbsPathReject && strings.HasPrefix(p, "/") {
+		return false, &AbsolutePathError{Path: original}
+	}
+	if options.DotDot == DotDotReject && slices.Contains(strings.Split(p, "/"), "..") {
+		return false, &DotDotError{Path: original}
+	}
+	return g.MatchPath(p), nil
 }
@@ -1,14 +1,22 @@
 package glob
 
 import (
+	"bytes"
 	"cmp"
+	"fmt"
 	"io/fs"
 	"iter"
 	"maps"
+	"math/rand/v2"
+	"os"
 	"path"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/hexops/autogold/v2"
@@ -243,6 +251,298 @@ func TestGlobInternalPrefix(t *testing.T) {
 	testGlob(t, goPaths, []string{"*/*/testdata/script/**"}, nil, false)
 }
 
+func TestGlobManyPatterns(t *testing.T) {
+	testGlob(t, goPaths, []string{
+		"maps/maps.go",
+		"maps/iter.go",
+		"run.bash",
+		"cmd/preprofile/*.go",
+		"cmd/vet/testdata/asm/**",
+		"cmd/vet/testdata/print/print.go",
+		"cmd/vet/testdata/shift/shift.go",
+		"cmd/vet/testdata/atomic/atomic.go",
+		"cmd/vet/testdata/*/go.mod",
+		"cmd/go/testdata/script/*.txt",
+	}, []string{
+		"**/iter.go",
+		"cmd/vet/testdata/asm/*.s",
+		"cmd/vet/testdata/print",
+		"cmd/vet/testdata/shift",
+		"cmd/vet/testdata/atomic",
+		"cmd/vet/testdata/rangeloop",
+		"cmd/vet/testdata/unused",
+		"cmd/vet/testdata/tagtest",
+		"cmd/vet/testdata/structtag",
+	}, false)
+}
+
+func BenchmarkMatchPathManyPatterns(b *testing.B) {
+	var includes, excludes []string
+	for i := range 1000 {
+		includes = append(includes, fmt.Sprintf("pkg%d/**/*.go", i), fmt.Sprintf("cmd/tool%d/*", i))
+		excludes = append(excludes, fmt.Sprintf("pkg%d/**/testdata", i), fmt.Sprintf("cmd/tool%d/*.txt", i))
+	}
+	glob, err := New(includes, excludes)
+	require.NoError(b, err)
+
+	paths := []string{"pkg500/internal/x/x.go", "pkg500/internal/testdata/x.go", "cmd/tool999/main.go", "other/x.go"}
+	for b.Loop() {
+		for _, p := range paths {
+			glob.MatchPath(p)
+		}
+	}
+}
+
+func TestGlobLiteralPrefixDirs(t *testing.T) {
+	testGlob(t, nil, []string{"a/*"}, nil, true)
+}
+
+func TestGlobDeep(t *testing.T) {
+	const depth = 2000
+
+	p := strings.Repeat("d/", depth) + "f"
+	fsys := fstest.MapFS{p: &fstest.MapFile{}, "g": &fstest.MapFile{}}
+
+	glob, err := New([]string{"**/f"}, nil)
+	require.NoError(t, err)
+	matches, err := fxs.TryCollect(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{p}, matches)
+
+	dirs, err := fxs.TryCollect(glob.Match(fsys, ".", true))
+	require.NoError(t, err)
+	assert.Equal(t, []string{p}, dirs)
+}
+
+func TestGlobStop(t *testing.T) {
+	glob, err := New([]string{"**"}, []string{"b"})
+	require.NoError(t, err)
+
+	fsys := newReadDirFS("a/0/aa", "a/1/aa", "b", "c")
+	var matches []string
+	for p, err := range glob.Match(fsys, ".", true) {
+		require.NoError(t, err)
+		matches = append(matches, p)
+		if len(matches) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a", "a/0"}, matches)
+}
+
+func TestGlobBudget(t *testing.T) {
+	glob, err := New([]string{"**/aa"}, nil)
+	require.NoError(t, err)
+
+	fsys := newReadDirFS("a/0/aa", "a/1/aa", "b", "c")
+	var matches []string
+	var errs []error
+	for p, err := range MatchWith(glob, fsys, ".", MatchOptions{MaxOps: 3}) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			matches = append(matches, p)
+		}
+	}
+	assert.Equal(t, []string{"a/0/aa"}, matches)
+	require.Len(t, errs, 1)
+
+	var budgetErr *BudgetExceededError
+	require.ErrorAs(t, errs[0], &budgetErr)
+	assert.Equal(t, 3, budgetErr.Limit)
+	assert.Equal(t, map[string]int{".": 1, "a": 1, "a/0": 1}, fsys.reads)
+}
+
+func TestMatchWithForeignGlob(t *testing.T) {
+	glob, err := New([]string{"src/**/*.go", "docs"}, []string{"**/testdata"})
+	require.NoError(t, err)
+	fsys := fstest.MapFS{
+		"src/main.go":          {},
+		"src/lib/lib.go":       {},
+		"src/testdata/x.go":    {},
+		"docs/guide.md":        {},
+		"README.md":            {},
+		"build/out/generated":  {},
+		"src/lib/data/data.go": {},
+	}
+
+	// A Glob that does not implement OptionsGlob is matched by filtering every path with MatchPath.
+	_, ok := Glob(foreignGlob{glob}).(OptionsGlob)
+	require.False(t, ok)
+	for _, options := range []MatchOptions{{}, {Dirs: DirsBoth}, {Dirs: DirsPost, OmitEmptyDirs: true}, {Dirs: DirsPre, IncludeRoot: true}} {
+		expected, err := Collect(MatchWith(glob, fsys, ".", options))
+		require.NoError(t, err)
+		require.NotEmpty(t, expected)
+		actual, err := Collect(MatchWith(foreignGlob{glob}, fsys, ".", options))
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, "%+v", options)
+	}
+}
+
+func TestGlobUnique(t *testing.T) {
+	glob, err := New([]string{"**", "*", "a/**", "**/aa", "a/0/aa", "2/*/aa/**"}, []string{"c"})
+	require.NoError(t, err)
+
+	fsys := newReadDirFS("a/0/aa", "a/1/aa", "b", "c", "d/0", "d/1/aa", "1/0", "2/0/aa/d/aa")
+	for _, includeDirs := range []bool{false, true} {
+		matches, err := fxs.TryCollect(glob.Match(fsys, ".", includeDirs))
+		require.NoError(t, err)
+		assert.Equal(t, slices.Compact(slices.Sorted(slices.Values(matches))), slices.Sorted(slices.Values(matches)))
+	}
+}
+
+func TestGlobDedup(t *testing.T) {
+	glob, err := New([]string{"**/aa"}, nil)
+	require.NoError(t, err)
+
+	fsys := newReadDirFS("a/0/aa", "a/1/aa")
+	seq := MatchWith(glob, fsys, ".", MatchOptions{Dedup: true})
+
+	// Run the same match over two overlapping roots through a single deduplicating yield.
+	var matches []string
+	yield := dedup(func(p string, err error) bool {
+		require.NoError(t, err)
+		matches = append(matches, p)
+		return true
+	})
+	for _, dir := range []string{"a", "a/0", "./a"} {
+		MatchWith(glob, fsys, dir, MatchOptions{})(yield)
+	}
+	assert.Equal(t, []string{"a/0/aa", "a/1/aa"}, matches)
+
+	matches, err = fxs.TryCollect(seq)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/0/aa", "a/1/aa"}, matches)
+}
+
+func TestGlobIncludeRoot(t *testing.T) {
+	fsys := newReadDirFS("foo/a/b", "foo/c")
+
+	cases := []struct {
+		includes, excludes []string
+		expected           []string
+	}{
+		{[]string{"**"}, nil, []string{"foo", "foo/a", "foo/a/b", "foo/c"}},
+		{[]string{"**"}, []string{"c"}, []string{"foo", "foo/a", "foo/a/b"}},
+		{[]string{"a/**"}, nil, []string{"foo/a/b"}},
+		{[]string{"**"}, []string{"**"}, nil},
+	}
+	for _, c := range cases {
+		glob, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+
+		matches, err := fxs.TryCollect(MatchWith(glob, fsys, "foo", MatchOptions{Dirs: DirsPre, IncludeRoot: true}))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches)
+
+		matches, err = fxs.TryCollect(MatchWith(glob, fsys, "foo", MatchOptions{IncludeRoot: true}))
+		require.NoError(t, err)
+		assert.NotContains(t, matches, "foo")
+	}
+}
+
+func TestGlobDirModes(t *testing.T) {
+	fsys := newReadDirFS("a/0/aa", "a/1/aa", "b")
+
+	cases := []struct {
+		includes, excludes []string
+		mode               DirMode
+		expected           []string
+	}{
+		{[]string{"**"}, nil, DirsNone, []string{"a/0/aa", "a/1/aa", "b"}},
+		{[]string{"**"}, nil, DirsPre, []string{"a", "a/0", "a/0/aa", "a/1", "a/1/aa", "b"}},
+		{[]string{"**"}, nil, DirsPost, []string{"a/0/aa", "a/0", "a/1/aa", "a/1", "a", "b"}},
+		{[]string{"**"}, []string{"b"}, DirsPost, []string{"a/0/aa", "a/0", "a/1/aa", "a/1", "a"}},
+		{[]string{"**"}, []string{"b"}, DirsBoth, []string{"a", "a/0", "a/0/aa", "a/0", "a/1", "a/1/aa", "a/1", "a"}},
+		{[]string{"a/*"}, nil, DirsBoth, []string{"a/0", "a/0", "a/1", "a/1"}},
+		{[]string{"a/0"}, nil, DirsPost, []string{"a/0"}},
+	}
+	for _, c := range cases {
+		glob, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+
+		matches, err := fxs.TryCollect(MatchWith(glob, fsys, ".", MatchOptions{Dirs: c.mode}))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v %v %v", c.includes, c.excludes, c.mode)
+	}
+}
+
+func TestGlobOmitEmptyDirs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/0/aa":      {},
+		"a/1/aa.txt":  {},
+		"a/2":         {Mode: fs.ModeDir},
+		"b/c/d":       {Mode: fs.ModeDir},
+		"e":           {},
+		"vendor/v.go": {},
+	}
+
+	cases := []struct {
+		includes, excludes []string
+		mode               DirMode
+		expected           []string
+	}{
+		{[]string{"**"}, nil, DirsPre, []string{"a", "a/0", "a/0/aa", "a/1", "a/1/aa.txt", "e", "vendor", "vendor/v.go"}},
+		{[]string{"**"}, nil, DirsPost, []string{"a/0/aa", "a/0", "a/1/aa.txt", "a/1", "a", "e", "vendor/v.go", "vendor"}},
+		{[]string{"**"}, []string{"**/*.txt", "vendor/**"}, DirsBoth, []string{"a", "a/0", "a/0/aa", "a/0", "a", "e"}},
+		{[]string{"**/*.go"}, nil, DirsPre, []string{"vendor/v.go"}},
+		{[]string{"a/*"}, nil, DirsPre, nil},
+		{[]string{"**"}, []string{"**/aa*"}, DirsPre, []string{"e", "vendor", "vendor/v.go"}},
+	}
+	for _, c := range cases {
+		glob, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+
+		matches, err := fxs.TryCollect(MatchWith(glob, fsys, ".", MatchOptions{Dirs: c.mode, OmitEmptyDirs: true}))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v %v %v", c.includes, c.excludes, c.mode)
+	}
+
+	// A layered glob omits directories whose files are all excluded by a later layer.
+	layered, err := NewLayered(
+		Layer{Name: "base", Includes: []string{"**"}},
+		Layer{Name: "override", Excludes: []string{"**/aa"}},
+	)
+	require.NoError(t, err)
+	matches, err := fxs.TryCollect(layered.MatchWith(fsys, ".", MatchOptions{Dirs: DirsPre, OmitEmptyDirs: true}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "a/1", "a/1/aa.txt", "e", "vendor", "vendor/v.go"}, matches)
+}
+
+func TestGlobLeafDirs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/001.sql":                    {},
+		"svc/a/migrations/001.sql":              {},
+		"svc/a/migrations/old/migrations/x":     {Mode: fs.ModeDir},
+		"svc/b/migrations/002.sql":              {},
+		"svc/b/db/migrations":                   {Mode: fs.ModeDir},
+		"svc/c/src/main.go":                     {},
+		"vendor/migrations/vendored/migrate.go": {},
+	}
+
+	cases := []struct {
+		includes, excludes []string
+		options            MatchOptions
+		expected           []string
+	}{
+		{[]string{"**/migrations"}, nil, MatchOptions{Dirs: DirsPre}, []string{"migrations", "svc/a/migrations/old/migrations", "svc/b/db/migrations", "svc/b/migrations", "vendor/migrations"}},
+		{[]string{"**/migrations"}, []string{"**/old"}, MatchOptions{Dirs: DirsBoth}, []string{"migrations", "svc/a/migrations", "svc/b/db/migrations", "svc/b/migrations", "vendor/migrations"}},
+		{[]string{"svc/*"}, nil, MatchOptions{Dirs: DirsPost}, []string{"svc/a", "svc/b", "svc/c"}},
+		{[]string{"svc/**"}, nil, MatchOptions{Dirs: DirsPre}, []string{"svc/a/migrations/001.sql", "svc/a/migrations/old/migrations/x", "svc/b/db/migrations", "svc/b/migrations/002.sql", "svc/b/migrations", "svc/c/src/main.go", "svc/c/src"}},
+		{[]string{"svc/**"}, nil, MatchOptions{Dirs: DirsPre, OmitEmptyDirs: true}, []string{"svc/a/migrations/001.sql", "svc/a/migrations", "svc/b/migrations/002.sql", "svc/b/migrations", "svc/c/src/main.go", "svc/c/src"}},
+		{[]string{"**/migrations"}, nil, MatchOptions{Dirs: DirsNone}, nil},
+	}
+	for _, c := range cases {
+		glob, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+
+		c.options.LeafDirs = true
+		matches, err := fxs.TryCollect(MatchWith(glob, fsys, ".", c.options))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v %v", c.includes, c.excludes)
+	}
+}
+
 var goPaths = []string{
 	"maps/iter_test.go",
 	"maps/example_test.go",
@@ -11289,3 +11589,994 @@ var goPaths = []string{
 	"debug/elf/reader.go",
 	"all.bat",
 }
+
+func TestCaseInsensitiveGlob(t *testing.T) {
+	var includes []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "Src"} {
+		includes = append(includes, name+"/*.GO")
+	}
+	glob, err := NewWithOptions(includes, []string{"SRC/Vendor.go"}, Options{CaseInsensitive: true})
+	require.NoError(t, err)
+
+	assert.True(t, glob.MatchPath("src/main.go"))
+	assert.True(t, glob.MatchPath("SRC/MAIN.GO"))
+	assert.False(t, glob.MatchPath("src/VENDOR.GO"))
+
+	fsys := fstest.MapFS{"src/main.go": {}, "SRC/other.Go": {}, "src/vendor.go": {}}
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SRC/other.Go", "src/main.go"}, matches)
+
+	explanation := ExplainPath(glob, "SRC/Main.go")
+	assert.Equal(t, []string{"Src/*.GO"}, explanation.Includes)
+}
+
+func TestBytesGlob(t *testing.T) {
+	glob, err := NewWithOptions([]string{"data/caf?.txt", "data/[\xe0-\xff]*"}, nil, Options{Bytes: true})
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{"data/caf\xe9.txt": {}, "data/café.txt": {}, "data/\xfcber": {}, "data/uber": {}}
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data/caf\xe9.txt", "data/\xfcber"}, matches)
+}
+
+func TestTransformGlob(t *testing.T) {
+	// Strip a revision suffix and fold case.
+	transform := func(name string) string {
+		name, _, _ = strings.Cut(name, ";")
+		return strings.ToLower(name)
+	}
+	options := Options{Transform: transform}
+	glob, err := NewWithOptions([]string{"Src/Main.go", "docs/*.MD"}, []string{"docs/Draft.md"}, options)
+	require.NoError(t, err)
+
+	assert.True(t, glob.MatchPath("src/main.go;3"))
+	assert.True(t, glob.MatchPath("DOCS/readme.md;1"))
+	assert.False(t, glob.MatchPath("docs/draft.md;2"))
+
+	fsys := fstest.MapFS{"SRC/MAIN.GO;3": {}, "src/other.go": {}, "docs/readme.md;1": {}, "docs/DRAFT.MD": {}}
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SRC/MAIN.GO;3", "docs/readme.md;1"}, matches)
+
+	other, err := NewWithOptions([]string{"Src/Main.go", "docs/*.MD"}, []string{"docs/Draft.md"}, options)
+	require.NoError(t, err)
+	diff, ok := DiffGlobs(glob, other)
+	require.True(t, ok)
+	assert.False(t, diff.Changed())
+
+	other, err = NewWithOptions([]string{"Src/Main.go", "docs/*.MD"}, []string{"docs/Draft.md"}, Options{Transform: strings.ToUpper})
+	require.NoError(t, err)
+	diff, ok = DiffGlobs(glob, other)
+	require.True(t, ok)
+	assert.True(t, diff.OptionsChanged)
+}
+
+func TestCustomSegmentGlob(t *testing.T) {
+	options := Options{Segments: []SegmentSyntax{regexpSyntax{}}}
+	glob, err := NewWithOptions([]string{`releases/re:^v\d+$/*.tar`}, []string{"**/re:^old"}, options)
+	require.NoError(t, err)
+
+	assert.True(t, glob.MatchPath("releases/v12/x.tar"))
+	assert.False(t, glob.MatchPath("releases/beta/x.tar"))
+	assert.False(t, glob.MatchPath("releases/v1/old.tar"))
+
+	fsys := &countingFS{MapFS: fstest.MapFS{
+		"releases/v1/a.tar":   {},
+		"releases/v1/old.tar": {},
+		"releases/v22/b.tar":  {},
+		"releases/beta/c.tar": {},
+		"releases/v3":         {},
+		"other/v1/d.tar":      {},
+	}, reads: map[string]int{}, stats: map[string]int{}}
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"releases/v1/a.tar", "releases/v22/b.tar"}, matches)
+	assert.Equal(t, map[string]int{"releases": 1, "releases/v1": 1, "releases/v22": 1}, fsys.reads)
+
+	_, err = NewWithOptions([]string{"a/re:("}, nil, options)
+	var se *SyntaxError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, 2, se.Start)
+	assert.Equal(t, 6, se.End)
+}
+
+// regexpSyntax compiles pattern elements of the form 're:<regexp>'.
+type regexpSyntax struct{}
+
+func (regexpSyntax) CompileSegment(element string) (SegmentMatcher, bool, error) {
+	expr, ok := strings.CutPrefix(element, "re:")
+	if !ok {
+		return nil, false, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	return regexpMatcher{re}, true, nil
+}
+
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexpMatcher) MatchSegment(name string) bool {
+	return m.re.MatchString(name)
+}
+
+func TestBasenameGlob(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.bak":          {},
+		"src/b.bak":      {},
+		"src/lib/c.bak":  {},
+		"src/lib/c.go":   {},
+		"build/out.bak":  {},
+		"docs/notes.txt": {},
+	}
+
+	cases := []struct {
+		includes []string
+		excludes []string
+		expected []string
+	}{
+		{[]string{"*.bak"}, nil, []string{"a.bak", "build/out.bak", "src/b.bak", "src/lib/c.bak"}},
+		{[]string{"/*.bak"}, nil, []string{"a.bak"}},
+		{[]string{"src/*.bak"}, nil, []string{"src/b.bak"}},
+		{[]string{"**"}, []string{"*.bak", "build"}, []string{"docs/notes.txt", "src/lib/c.go"}},
+		{[]string{"**"}, []string{"lib"}, []string{"a.bak", "build/out.bak", "docs/notes.txt", "src/b.bak"}},
+		{[]string{"**{1}"}, nil, []string{"a.bak"}},
+	}
+	for _, c := range cases {
+		glob, err := NewWithOptions(c.includes, c.excludes, Options{Basename: true})
+		require.NoError(t, err)
+
+		matches, err := CollectSorted(glob.Match(fsys, ".", false))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v %v", c.includes, c.excludes)
+		for _, m := range matches {
+			assert.True(t, glob.MatchPath(m), m)
+		}
+	}
+}
+
+func TestGlobInvalidUTF8(t *testing.T) {
+	glob, err := New([]string{"**/*.txt"}, nil)
+	require.NoError(t, err)
+
+	// Note that the contents of c\xfe cannot be read, as its name is not a valid fs.FS path.
+	fsys := fstest.MapFS{"a.txt": {}, "b\xff.txt": {}, "c\xfe/d.txt": {}, "e/f.txt": {}}
+	var warnings []error
+	collect := func(mode InvalidUTF8Mode) ([]string, []error) {
+		var paths []string
+		var errs []error
+		options := MatchOptions{InvalidUTF8: mode, Warn: func(err error) { warnings = append(warnings, err) }}
+		for p, err := range MatchWith(glob, fsys, ".", options) {
+			paths = append(paths, p)
+			errs = append(errs, err)
+		}
+		return paths, errs
+	}
+
+	paths, errs := collect(InvalidUTF8Allow)
+	assert.Equal(t, []string{"a.txt", "b\xff.txt", "c\xfe", "e/f.txt"}, paths)
+	assert.Equal(t, []error{nil, nil, nil}, []error{errs[0], errs[1], errs[3]})
+	assert.ErrorIs(t, errs[2], fs.ErrNotExist)
+
+	paths, errs = collect(InvalidUTF8Skip)
+	assert.Equal(t, []string{"a.txt", "e/f.txt"}, paths)
+	assert.Equal(t, []error{nil, nil}, errs)
+
+	assert.Empty(t, warnings)
+
+	paths, errs = collect(InvalidUTF8Warn)
+	assert.Equal(t, []string{"a.txt", "b\xff.txt", "c\xfe", "e/f.txt"}, paths)
+	assert.Equal(t, []error{nil, nil, nil}, []error{errs[0], errs[1], errs[3]})
+	assert.ErrorIs(t, errs[2], fs.ErrNotExist)
+	assert.Equal(t, []error{&InvalidUTF8Error{Path: "b\xff.txt"}}, warnings)
+	warnings = nil
+
+	paths, errs = collect(InvalidUTF8Fail)
+	assert.Equal(t, []string{"a.txt", "b\xff.txt"}, paths)
+	assert.Equal(t, []error{nil, &InvalidUTF8Error{Path: "b\xff.txt"}}, errs)
+	assert.Empty(t, warnings)
+}
+
+func TestGlobFlags(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.MD":        {},
+		"docs/guide.md":    {},
+		"docs/.draft.md":   {},
+		".github/ci.yml":   {},
+		"src/Main.go":      {},
+		"src/.hidden/x.go": {},
+	}
+
+	cases := []struct {
+		includes []string
+		options  Options
+		expected []string
+	}{
+		{[]string{"(?i)**/*.md"}, Options{}, []string{"README.MD", "docs/.draft.md", "docs/guide.md"}},
+		{[]string{"(?i-d)**/*.md"}, Options{}, []string{"README.MD", "docs/guide.md"}},
+		{[]string{"**/*.md", "(?-i)src/main.go"}, Options{CaseInsensitive: true}, []string{"README.MD", "docs/.draft.md", "docs/guide.md"}},
+		{[]string{"**"}, Options{ExplicitDot: true}, []string{"README.MD", "docs/guide.md", "src/Main.go"}},
+		{[]string{"**/.*"}, Options{ExplicitDot: true}, []string{"docs/.draft.md"}},
+		{[]string{"(?d)**/*.yml", "**/*.go"}, Options{ExplicitDot: true}, []string{".github/ci.yml", "src/Main.go"}},
+		{[]string{"(?b)*.go"}, Options{}, []string{"src/.hidden/x.go", "src/Main.go"}},
+		{[]string{"(?-b)*.MD"}, Options{Basename: true}, []string{"README.MD"}},
+	}
+	for _, c := range cases {
+		glob, err := NewWithOptions(c.includes, nil, c.options)
+		require.NoError(t, err)
+
+		matches, err := CollectSorted(glob.Match(fsys, ".", false))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v", c.includes)
+		for _, m := range matches {
+			assert.True(t, glob.MatchPath(m), m)
+		}
+	}
+
+	for _, p := range []string{"(?x)*", "(?i", "(?i--d)*"} {
+		_, err := New([]string{p}, nil)
+		assert.Error(t, err, p)
+	}
+}
+
+func TestGlobBoundedGlobstar(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/a.go":       {},
+		"src/x/b.go":     {},
+		"src/x/y/c.go":   {},
+		"src/x/y/z/d.go": {},
+		"e.go":           {},
+	}
+
+	cases := []struct {
+		include  string
+		expected []string
+	}{
+		{"src/**{0,2}/*.go", []string{"src/a.go", "src/x/b.go", "src/x/y/c.go"}},
+		{"src/**{1}/*.go", []string{"src/x/b.go"}},
+		{"src/**{2,3}", []string{"src/x/b.go", "src/x/y", "src/x/y/c.go", "src/x/y/z"}},
+		{"**{0,1}/*.go", []string{"e.go", "src/a.go"}},
+	}
+	for _, c := range cases {
+		glob, err := New([]string{c.include}, nil)
+		require.NoError(t, err)
+
+		matches, err := CollectSorted(glob.Match(fsys, ".", true))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, c.include)
+	}
+
+	for _, p := range []string{"a/**{", "a/**{x}", "a/**{2,1}", "a/**{-1}", "**{0,100}/**{0,100}"} {
+		_, err := New([]string{p}, nil)
+		assert.Error(t, err, p)
+	}
+}
+
+func TestGlobInnerGlobstar(t *testing.T) {
+	glob, err := New([]string{"src/a**"}, nil)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{"src/a.go": {}, "src/ab/c.go": {}}
+	matches, err := CollectSorted(glob.Match(fsys, ".", true))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a.go", "src/ab"}, matches)
+}
+
+func TestGlobMiddleGlobstar(t *testing.T) {
+	fsys := fstest.MapFS{"a/b": {}, "a/x/b": {}, "a/x/y/b": {}, "a/c": {}, "b": {}}
+
+	glob, err := New([]string{"a/**/b"}, nil)
+	require.NoError(t, err)
+
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b", "a/x/b", "a/x/y/b"}, matches)
+	assert.True(t, glob.MatchPath("a/b"))
+	assert.False(t, glob.MatchPath("b"))
+
+	// A trailing '**' still requires at least one name.
+	glob, err = New([]string{"a/**"}, nil)
+	require.NoError(t, err)
+	assert.False(t, glob.MatchPath("a"))
+}
+
+func TestGlobConsecutiveGlobstars(t *testing.T) {
+	for _, c := range [][2]string{
+		{"a/**/**/b", "a/**/b"},
+		{"**/**/**", "**"},
+		{"**/**/b", "**/b"},
+		{"a/**/**", "a/**"},
+	} {
+		collapsed, err := newPatterns([]string{c[0]}, Options{})
+		require.NoError(t, err)
+		expected, err := newPatterns([]string{c[1]}, Options{})
+		require.NoError(t, err)
+		assert.Equal(t, expected, collapsed, c[0])
+	}
+
+	fsys := fstest.MapFS{"a/b": {}, "a/x/b": {}, "a/x/y/b": {}}
+	glob, err := New([]string{"a/**/**/b"}, nil)
+	require.NoError(t, err)
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b", "a/x/b", "a/x/y/b"}, matches)
+}
+
+func TestGlobNegatedExcludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dist/manifest.json": {},
+		"dist/a.js":          {},
+		"dist/sub/b.js":      {},
+		"dist/sub/keep.js":   {},
+		"src/main.go":        {},
+		"!bang":              {},
+	}
+
+	cases := []struct {
+		excludes []string
+		expected []string
+	}{
+		{[]string{"dist/**", "!dist/manifest.json"}, []string{"!bang", "dist", "dist/manifest.json", "src", "src/main.go"}},
+		{[]string{"dist", "!dist/sub/**", "dist/sub/b.js"}, []string{"!bang", "dist/sub/keep.js", "src", "src/main.go"}},
+		{[]string{"**", "!src/**"}, []string{"src/main.go"}},
+		{[]string{"!src/main.go", "src"}, []string{"!bang", "dist", "dist/a.js", "dist/manifest.json", "dist/sub", "dist/sub/b.js", "dist/sub/keep.js"}},
+		{[]string{`\!bang`}, []string{"dist", "dist/a.js", "dist/manifest.json", "dist/sub", "dist/sub/b.js", "dist/sub/keep.js", "src", "src/main.go"}},
+	}
+	for _, c := range cases {
+		glob, err := New([]string{"**"}, c.excludes)
+		require.NoError(t, err)
+
+		matches, err := CollectSorted(glob.Match(fsys, ".", true))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v", c.excludes)
+
+		for _, p := range []string{"dist/manifest.json", "dist/a.js", "dist/sub/b.js", "dist/sub/keep.js", "src/main.go", "!bang"} {
+			assert.Equal(t, slices.Contains(matches, p), glob.MatchPath(p), "%v: %v", c.excludes, p)
+		}
+	}
+
+	// Literal prefixes are stat'ed rather than read.
+	glob, err := New([]string{"dist/sub/keep.js", "dist/sub/b.js"}, []string{"dist/sub", "!dist/sub/keep.js"})
+	require.NoError(t, err)
+	matches, err := CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dist/sub/keep.js"}, matches)
+
+	glob, err = New([]string{"dist/sub/keep.js"}, []string{"dist/sub", "!dist/sub/keep.js"})
+	require.NoError(t, err)
+	matches, err = CollectSorted(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dist/sub/keep.js"}, matches)
+}
+
+func TestGlobNegatedExcludesMatchPath(t *testing.T) {
+	// Match agrees with MatchPath when a negated exclude re-includes a directory beneath which an earlier exclude
+	// matches every path.
+	check := func(fsys fstest.MapFS, includes, excludes []string) {
+		glob, err := New(includes, excludes)
+		require.NoError(t, err)
+
+		var expected []string
+		err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && glob.MatchPath(p) {
+				expected = append(expected, p)
+			}
+			return err
+		})
+		require.NoError(t, err)
+
+		matches, err := Collect(glob.Match(fsys, ".", false))
+		require.NoError(t, err)
+		slices.Sort(expected)
+		slices.Sort(matches)
+		assert.Equal(t, expected, matches, "%q %q", includes, excludes)
+	}
+
+	check(fstest.MapFS{"vendor/x.go": {}, "main.go": {}}, []string{"**/*.go"}, []string{"vendor/**", "!vendor"})
+	check(fstest.MapFS{"a/.h/ab": {}, "b/ab": {}}, []string{"ab", "*/*/a*", "*"}, []string{"[ab]/**", "!*"})
+
+	r := rand.New(rand.NewPCG(1, 2))
+	names := []string{"a", "b", "ab", ".h"}
+	elements := []string{"a", "b", "ab", "*", "**", "a*", "[ab]", ".h"}
+	pattern := func() string {
+		steps := make([]string, 1+r.IntN(3))
+		for i := range steps {
+			steps[i] = elements[r.IntN(len(elements))]
+		}
+		return strings.Join(steps, "/")
+	}
+	for range 2000 {
+		fsys := fstest.MapFS{}
+		for range 1 + r.IntN(6) {
+			steps := make([]string, 1+r.IntN(3))
+			for i := range steps {
+				steps[i] = names[r.IntN(len(names))]
+			}
+			fsys[strings.Join(steps, "/")+".f"] = &fstest.MapFile{}
+		}
+		var includes, excludes []string
+		for range 1 + r.IntN(3) {
+			includes = append(includes, pattern())
+		}
+		for range 1 + r.IntN(3) {
+			p := pattern()
+			if r.IntN(2) == 0 {
+				p = "!" + p
+			}
+			excludes = append(excludes, p)
+		}
+		check(fsys, includes, excludes)
+	}
+}
+
+func TestGlobLiteralPrefixFile(t *testing.T) {
+	// A literal prefix that names a file or an excluded directory is not itself a match.
+	glob, err := New([]string{"a/b"}, nil)
+	require.NoError(t, err)
+	matches, err := Collect(glob.Match(fstest.MapFS{"a": {}}, ".", true))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	glob, err = New([]string{"a/b"}, []string{"a/**"})
+	require.NoError(t, err)
+	matches, err = Collect(glob.Match(fstest.MapFS{"a/c": {}}, ".", true))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestGlobSniff(t *testing.T) {
+	elf := []byte("\x7fELF\x02\x01\x01")
+	fsys := fstest.MapFS{
+		"bin/tool":    {Data: elf},
+		"bin/script":  {Data: []byte("#!/bin/sh\necho hi\n")},
+		"bin/empty":   {},
+		"lib/tool.so": {Data: elf},
+	}
+
+	glob, err := New([]string{"bin/*"}, nil)
+	require.NoError(t, err)
+
+	var sniffed []string
+	isELF := func(p string, head []byte) bool {
+		sniffed = append(sniffed, p)
+		return bytes.HasPrefix(head, []byte("\x7fELF"))
+	}
+	matches, err := CollectSorted(MatchWith(glob, fsys, ".", MatchOptions{Sniff: isELF}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bin/tool"}, matches)
+	assert.ElementsMatch(t, []string{"bin/tool", "bin/script", "bin/empty"}, sniffed)
+
+	var heads []string
+	options := MatchOptions{SniffSize: 2, Sniff: func(_ string, head []byte) bool {
+		heads = append(heads, string(head))
+		return true
+	}}
+	matches, err = CollectSorted(MatchWith(glob, fsys, ".", options))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bin/empty", "bin/script", "bin/tool"}, matches)
+	assert.ElementsMatch(t, []string{"", "#!", "\x7fE"}, heads)
+
+	// Each read counts toward the budget.
+	_, err = Collect(MatchWith(glob, fsys, ".", MatchOptions{Sniff: isELF, MaxOps: 3}))
+	var budgetErr *BudgetExceededError
+	assert.ErrorAs(t, err, &budgetErr)
+}
+
+func TestGlobSkipLiteralPrefix(t *testing.T) {
+	// A deep literal prefix costs a single operation.
+	glob, err := New([]string{"a/b/c/d/*.go", "a/b/c/d/e/*.go"}, nil)
+	require.NoError(t, err)
+	fsys := fstest.MapFS{"a/b/c/d/x.go": {}, "a/b/c/d/y.txt": {}, "a/b/c/d/e/z.go": {}}
+	matches, err := Collect(MatchWith(glob, fsys, ".", MatchOptions{MaxOps: 2}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b/c/d/e/z.go", "a/b/c/d/x.go"}, matches)
+
+	glob, err = New([]string{"a/b/c/d.go"}, nil)
+	require.NoError(t, err)
+	matches, err = Collect(MatchWith(glob, fstest.MapFS{"a/b/c/d.go": {}}, ".", MatchOptions{MaxOps: 1}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b/c/d.go"}, matches)
+
+	// A prefix whose elements are missing or name files matches nothing.
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), nil, 0o600))
+	for _, include := range []string{"a/b/*", "a/b/c", "x/y/*", "x/y/z"} {
+		glob, err := New([]string{include}, nil)
+		require.NoError(t, err)
+		matches, err := Collect(glob.Match(os.DirFS(dir), ".", true))
+		require.NoError(t, err, include)
+		assert.Empty(t, matches, include)
+	}
+
+	// Other failures are reported as they would be if each element were inspected in turn.
+	glob, err = New([]string{"a/b/c/*"}, nil)
+	require.NoError(t, err)
+	var errs []error
+	for p, err := range glob.Match(readDirFailFS{MapFS: fstest.MapFS{"a/b/c/d": {}}, dir: "a/b"}, ".", false) {
+		require.Error(t, err)
+		assert.Equal(t, "a/b", p)
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], fs.ErrPermission)
+
+	// A matching directory is yielded even if the patterns beneath it are literal.
+	glob, err = New([]string{"*", "x/y/z"}, nil)
+	require.NoError(t, err)
+	matches, err = Collect(glob.Match(fstest.MapFS{"x/y/z": {}, "w": {}}, ".", true))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"w", "x", "x/y/z"}, matches)
+}
+
+// readDirFailFS is a filesystem in which the named directory and its contents cannot be accessed.
+type readDirFailFS struct {
+	fstest.MapFS
+	dir string
+}
+
+func (fsys readDirFailFS) Stat(name string) (fs.FileInfo, error) {
+	if err := fsys.check("stat", name); err != nil {
+		return nil, err
+	}
+	return fsys.MapFS.Stat(name)
+}
+
+func (fsys readDirFailFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := fsys.check("readdir", name); err != nil {
+		return nil, err
+	}
+	return fsys.MapFS.ReadDir(name)
+}
+
+func (fsys readDirFailFS) check(op, name string) error {
+	if strings.HasPrefix(name, fsys.dir+"/") || name == fsys.dir && op == "readdir" {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+func TestGlobLstat(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "real"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "real", "x.go"), nil, 0o600))
+	require.NoError(t, os.Symlink("real", filepath.Join(dir, "link")))
+
+	mapFS := fstest.MapFS{
+		"real/x.go": {},
+		"link":      {Mode: fs.ModeSymlink, Data: []byte("real")},
+	}
+
+	cases := []struct {
+		include  string
+		follow   []string
+		noFollow []string
+	}{
+		{"link/*.go", []string{"link/x.go"}, nil},
+		{"link/x.go", []string{"link/x.go"}, nil},
+		{"link", []string{"link"}, []string{"link"}},
+		{"real/x.go", []string{"real/x.go"}, []string{"real/x.go"}},
+		{"missing/x.go", nil, nil},
+	}
+	for _, fsys := range []fs.FS{os.DirFS(dir), mapFS, noLstatFS{mapFS}} {
+		for _, c := range cases {
+			glob, err := New([]string{c.include}, nil)
+			require.NoError(t, err)
+
+			matches, err := Collect(MatchWith(glob, fsys, ".", MatchOptions{Dirs: DirsPre}))
+			require.NoError(t, err)
+			assert.Equal(t, c.follow, matches, "%T %v", fsys, c.include)
+
+			matches, err = Collect(MatchWith(glob, fsys, ".", MatchOptions{Dirs: DirsPre, Lstat: true}))
+			require.NoError(t, err)
+			assert.Equal(t, c.noFollow, matches, "%T %v", fsys, c.include)
+		}
+	}
+}
+
+// noLstatFS hides the Lstat method of a filesystem.
+type noLstatFS struct {
+	fsys fs.FS
+}
+
+func (fsys noLstatFS) Open(name string) (fs.File, error) {
+	return fsys.fsys.Open(name)
+}
+
+func (fsys noLstatFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(fsys.fsys, name)
+}
+
+func (fsys noLstatFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(fsys.fsys, name)
+}
+
+func TestGlobWithoutStat(t *testing.T) {
+	fsys := dirOpenFailFS{fstest.MapFS{"a/b.go": {}, "a/c/d.go": {}, "e.go": {}}}
+	_, err := fs.Stat(fsys, "a")
+	require.Error(t, err)
+
+	cases := []struct {
+		include  string
+		expected []string
+	}{
+		{"a/b.go", []string{"a/b.go"}},
+		{"a/c", []string{"a/c"}},
+		{"a/c/d.go", []string{"a/c/d.go"}},
+		{"a/missing", nil},
+		{"e.go/x", nil},
+		{"**/*.go", []string{"a/b.go", "a/c/d.go", "e.go"}},
+	}
+	for _, c := range cases {
+		glob, err := New([]string{c.include}, nil)
+		require.NoError(t, err)
+		matches, err := Collect(glob.Match(fsys, ".", true))
+		require.NoError(t, err, c.include)
+		assert.Equal(t, c.expected, matches, c.include)
+	}
+}
+
+// dirOpenFailFS is a filesystem whose directories can be read but not opened.
+type dirOpenFailFS struct {
+	fsys fstest.MapFS
+}
+
+func (fsys dirOpenFailFS) Open(name string) (fs.File, error) {
+	if info, err := fsys.fsys.Stat(name); err == nil && info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return fsys.fsys.Open(name)
+}
+
+func (fsys dirOpenFailFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fsys.fsys.ReadDir(name)
+}
+
+func TestMatchPathWith(t *testing.T) {
+	glob, err := New([]string{"src/*.go"}, nil)
+	require.NoError(t, err)
+
+	for _, mode := range []AbsPathMode{AbsPathAnchor, AbsPathReject} {
+		matched, err := MatchPathWith(glob, "src/main.go", PathOptions{Absolute: mode})
+		require.NoError(t, err)
+		assert.True(t, matched)
+
+		matched, err = MatchPathWith(glob, "docs/main.go", PathOptions{Absolute: mode})
+		require.NoError(t, err)
+		assert.False(t, matched)
+	}
+
+	assert.True(t, glob.MatchPath("/src/main.go"))
+	matched, err := MatchPathWith(glob, "/src/main.go", PathOptions{})
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, err = MatchPathWith(glob, "/src/main.go", PathOptions{Absolute: AbsPathReject})
+	var absErr *AbsolutePathError
+	require.ErrorAs(t, err, &absErr)
+	assert.Equal(t, "/src/main.go", absErr.Path)
+	assert.False(t, matched)
+}
+
+func TestMatchPathDots(t *testing.T) {
+	glob, err := New([]string{"src/*.go"}, []string{"src/x.go"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		path     string
+		expected bool
+	}{
+		{"./src/main.go", true},
+		{"src/./main.go", true},
+		{"src/lib/../main.go", true},
+		{"docs/../src/main.go", true},
+		{"src/../main.go", false},
+		{"../src/main.go", false},
+		{"src/../../src/main.go", false},
+		{"src/lib/../x.go", false},
+		{"./", false},
+	}
+	all, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+	layered, err := NewLayered(Layer{Includes: []string{"src/*.go"}, Excludes: []string{"src/x.go"}})
+	require.NoError(t, err)
+	for _, c := range cases {
+		assert.Equal(t, c.expected, glob.MatchPath(c.path), c.path)
+		assert.Equal(t, c.expected, layered.MatchPath(c.path), c.path)
+		assert.Equal(t, len(splitPath(c.path)) != 0, all.MatchPath(c.path), c.path)
+	}
+
+	matched, err := MatchPathWith(glob, "src/lib/../main.go", PathOptions{})
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, err = MatchPathWith(glob, "src/lib/../main.go", PathOptions{DotDot: DotDotReject})
+	var dotDotErr *DotDotError
+	require.ErrorAs(t, err, &dotDotErr)
+	assert.Equal(t, "src/lib/../main.go", dotDotErr.Path)
+	assert.False(t, matched)
+
+	matched, err = MatchPathWith(glob, "./src/main.go", PathOptions{DotDot: DotDotReject})
+	require.NoError(t, err)
+	assert.True(t, matched)
+}
+
+func TestMatchPathWindowsSeparators(t *testing.T) {
+	glob, err := New([]string{"src/*.go"}, nil)
+	require.NoError(t, err)
+
+	matched, err := MatchPathWith(glob, `src\main.go`, PathOptions{})
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	options := PathOptions{WindowsSeparators: true}
+	for _, p := range []string{`src\main.go`, `.\src\main.go`, `src\lib\..\main.go`, `src/lib\../main.go`, `\src\main.go`} {
+		matched, err := MatchPathWith(glob, p, options)
+		require.NoError(t, err)
+		assert.True(t, matched, p)
+	}
+
+	_, err = MatchPathWith(glob, `\src\main.go`, PathOptions{WindowsSeparators: true, Absolute: AbsPathReject})
+	var absErr *AbsolutePathError
+	require.ErrorAs(t, err, &absErr)
+	assert.Equal(t, `\src\main.go`, absErr.Path)
+}
+
+func TestGlobTrace(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/a.go":        {},
+		"src/gen/b.go":    {},
+		"src/vendor/c.go": {},
+	}
+
+	g, err := New([]string{"src/**/*.go"}, []string{"src/vendor"})
+	require.NoError(t, err)
+
+	var trace []string
+	matches, err := Collect(MatchWith(g, fsys, ".", MatchOptions{Trace: func(dir, state string) {
+		trace = append(trace, dir+": "+state)
+	}}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a.go", "src/gen/b.go"}, matches)
+	autogold.Expect([]string{
+		`src: frame{dir: "src", include: [pattern("**/*.go"), pattern("*.go")], exclude: [pattern("vendor" rule=1)]}`,
+		`src/gen: frame{dir: "src/gen", include: [pattern("**/*.go"), pattern("*.go")]}`,
+	}).Equal(t, trace)
+
+	p := g.(*matchGlob).include[0]
+	assert.Equal(t, `pattern("src/**/*.go")`, fmt.Sprintf("%#v", p))
+}
+
+func TestGlobStatConcurrency(t *testing.T) {
+	fsys := &concurrencyFS{MapFS: fstest.MapFS{}}
+	var includes, expected []string
+	for i := range 20 {
+		p := fmt.Sprintf("d%02d/sub/x.go", i)
+		includes = append(includes, p)
+		if i%2 == 0 {
+			fsys.MapFS[p] = &fstest.MapFile{}
+			expected = append(expected, p)
+		} else {
+			fsys.MapFS[path.Dir(p)] = &fstest.MapFile{Mode: fs.ModeDir}
+		}
+	}
+
+	g, err := New(includes, nil)
+	require.NoError(t, err)
+
+	matches, err := Collect(MatchWith(g, fsys, ".", MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, expected, matches)
+	assert.Equal(t, int32(1), fsys.max.Load())
+
+	fsys.max.Store(0)
+	matches, err = Collect(MatchWith(g, fsys, ".", MatchOptions{StatConcurrency: 4}))
+	require.NoError(t, err)
+	assert.Equal(t, expected, matches)
+	assert.Equal(t, int32(4), fsys.max.Load())
+	assert.Zero(t, fsys.active.Load())
+}
+
+// concurrencyFS records the maximum number of concurrent calls to Stat.
+type concurrencyFS struct {
+	fstest.MapFS
+	active, max atomic.Int32
+}
+
+func (fsys *concurrencyFS) Stat(name string) (fs.FileInfo, error) {
+	n := fsys.active.Add(1)
+	defer fsys.active.Add(-1)
+	for {
+		m := fsys.max.Load()
+		if n <= m || fsys.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return fsys.MapFS.Stat(name)
+}
+
+func TestGlobStatBatch(t *testing.T) {
+	fsys := &batchFS{MapFS: fstest.MapFS{
+		"a/x.go":     {},
+		"b/c/y.go":   {},
+		"d/z.go":     {},
+		"e/other.go": {},
+	}}
+
+	g, err := New([]string{"a/x.go", "b/c/y.go", "d/missing.go", "e/*.go"}, nil)
+	require.NoError(t, err)
+
+	matches, err := Collect(MatchWith(g, fsys, ".", MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/x.go", "b/c/y.go", "e/other.go"}, matches)
+	assert.Equal(t, [][]string{{"a/x.go", "b/c/y.go", "d/missing.go"}}, fsys.batches)
+	assert.Zero(t, fsys.stats)
+
+	// The batch counts as a single operation: one read of the root, one batch, and one read of e.
+	fsys.batches = nil
+	matches, err = Collect(MatchWith(g, fsys, ".", MatchOptions{MaxOps: 3}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/x.go", "b/c/y.go", "e/other.go"}, matches)
+
+	// Batches are not used if symbolic links must not be followed.
+	fsys.batches = nil
+	matches, err = Collect(MatchWith(g, fsys, ".", MatchOptions{Lstat: true}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/x.go", "b/c/y.go", "e/other.go"}, matches)
+	assert.Empty(t, fsys.batches)
+}
+
+// batchFS records the calls to its StatBatch and Stat methods.
+type batchFS struct {
+	fstest.MapFS
+	batches [][]string
+	stats   int
+}
+
+func (fsys *batchFS) Stat(name string) (fs.FileInfo, error) {
+	fsys.stats++
+	return fsys.MapFS.Stat(name)
+}
+
+func (fsys *batchFS) StatBatch(names []string) ([]fs.FileInfo, []error) {
+	fsys.batches = append(fsys.batches, names)
+	infos, errs := make([]fs.FileInfo, len(names)), make([]error, len(names))
+	for i, name := range names {
+		infos[i], errs[i] = fsys.MapFS.Stat(name)
+	}
+	return infos, errs
+}
+
+func TestGlobReadDirPrefix(t *testing.T) {
+	fsys := &prefixFS{MapFS: fstest.MapFS{
+		"reports/report-1.csv":   {},
+		"reports/report-2.csv":   {},
+		"reports/report-2.txt":   {},
+		"reports/summary.csv":    {},
+		"reports/rep/report.csv": {},
+		"logs/a.log":             {},
+	}}
+
+	cases := []struct {
+		includes []string
+		options  Options
+		expected []string
+		prefixes []string
+	}{
+		{[]string{"reports/report-*.csv"}, Options{}, []string{"reports/report-1.csv", "reports/report-2.csv"}, []string{"reports report-"}},
+		{[]string{"reports/report-1.*", "reports/rep*/*.csv"}, Options{}, []string{"reports/rep/report.csv", "reports/report-1.csv"}, []string{"reports rep"}},
+		{[]string{"reports/report-*.csv", "reports/*.csv"}, Options{}, []string{"reports/report-1.csv", "reports/report-2.csv", "reports/summary.csv"}, nil},
+		{[]string{"reports/REPORT-*.csv"}, Options{CaseInsensitive: true}, []string{"reports/report-1.csv", "reports/report-2.csv"}, nil},
+		{[]string{"reports/report-\\*"}, Options{}, nil, []string{"reports report-*"}},
+	}
+	for _, c := range cases {
+		g, err := NewWithOptions(c.includes, nil, c.options)
+		require.NoError(t, err)
+
+		fsys.prefixes = nil
+		matches, err := Collect(g.Match(fsys, ".", false))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v", c.includes)
+		assert.Equal(t, c.prefixes, fsys.prefixes, "%v", c.includes)
+	}
+}
+
+// prefixFS implements ReadDirPrefixFS and records the prefixes with which it is read.
+type prefixFS struct {
+	fstest.MapFS
+	prefixes []string
+}
+
+func (fsys *prefixFS) ReadDirPrefix(name, prefix string) ([]fs.DirEntry, error) {
+	fsys.prefixes = append(fsys.prefixes, name+" "+prefix)
+	entries, err := fsys.MapFS.ReadDir(name)
+	return slices.DeleteFunc(entries, func(e fs.DirEntry) bool { return !strings.HasPrefix(e.Name(), prefix) }), err
+}
+
+func TestGlobSkipSpecialFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"var/log/app.log":  {},
+		"var/run/app.sock": {Mode: fs.ModeSocket},
+		"var/run/app.fifo": {Mode: fs.ModeNamedPipe},
+		"var/dev/sda":      {Mode: fs.ModeDevice},
+		"var/dev/tty":      {Mode: fs.ModeDevice | fs.ModeCharDevice},
+		"var/lib/data":     {},
+	}
+	cases := []struct {
+		includes []string
+		expected []string
+	}{
+		{[]string{"var/**"}, []string{"var/lib/data", "var/log/app.log"}},
+		{[]string{"var/run/app.fifo", "var/log/app.log"}, []string{"var/log/app.log"}},
+	}
+	for _, c := range cases {
+		g, err := New(c.includes, nil)
+		require.NoError(t, err)
+
+		sniffed := 0
+		matches, err := CollectSorted(MatchWith(g, fsys, ".", MatchOptions{
+			SkipSpecialFiles: true,
+			Sniff:            func(string, []byte) bool { sniffed++; return true },
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v", c.includes)
+		assert.Equal(t, len(c.expected), sniffed, "%v", c.includes)
+	}
+}
+
+func TestGlobSkipMarkers(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"home/a.txt":               {},
+			"home/.cache/CACHEDIR.TAG": {},
+			"home/.cache/x/blob":       {},
+			"home/tmp/.nobackup":       {},
+			"home/tmp/scratch.txt":     {},
+			"home/docs/notes.txt":      {},
+			"home/docs/old/.nobackup":  {},
+			"home/docs/old/draft.txt":  {},
+			"srv/data/CACHEDIR.TAG":    {},
+			"srv/data/db/table.txt":    {},
+			"srv/www/index.txt":        {},
+			"marked/CACHEDIR.TAG":      {},
+			"marked/x.txt":             {},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+	options := MatchOptions{Dirs: DirsPre, SkipMarkers: []string{"CACHEDIR.TAG", ".nobackup"}}
+
+	cases := []struct {
+		includes []string
+		dir      string
+		expected []string
+	}{
+		{[]string{"**"}, "home", []string{"home/.cache", "home/a.txt", "home/docs", "home/docs/notes.txt", "home/docs/old", "home/tmp"}},
+		{[]string{"**/*.txt"}, ".", []string{"home/a.txt", "home/docs/notes.txt", "srv/www/index.txt"}},
+		{[]string{"srv/data/db/table.txt", "srv/www/*.txt"}, ".", []string{"srv/www/index.txt"}},
+		{[]string{"home/docs/old/draft.txt"}, ".", nil},
+		{[]string{"x.txt"}, "marked", nil},
+	}
+	for _, c := range cases {
+		glob, err := New(c.includes, nil)
+		require.NoError(t, err)
+
+		matches, err := CollectSorted(MatchWith(glob, fsys, c.dir, options))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v", c.includes)
+	}
+
+	// The contents of marked directories are not read.
+	clear(fsys.reads)
+	glob, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+	_, err = Collect(MatchWith(glob, fsys, ".", options))
+	require.NoError(t, err)
+	assert.NotContains(t, fsys.reads, "home/.cache/x")
+	assert.NotContains(t, fsys.reads, "srv/data/db")
+}
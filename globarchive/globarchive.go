@@ -0,0 +1,163 @@
+// Package globarchive writes the files matched by a glob into tar and zip archives. Archives are reproducible: their
+// members are written in sorted order with fixed ownership, and their modification times may be fixed as well, so
+// archiving the same tree twice produces the same bytes.
+//
+//	tw := tar.NewWriter(out)
+//	err := globarchive.WriteTar(tw, os.DirFS("build"), ".", g, globarchive.Options{ModTime: time.Unix(0, 0)})
+package globarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pgavlin/glob"
+)
+
+// Options controls the members written by WriteTar and WriteZip.
+type Options struct {
+	// Prefix is prepended to the name of each member, as in 'release-1.0/'. If it does not end with a '/', it is
+	// prepended as is.
+	Prefix string
+
+	// ModTime, if non-zero, is the modification time of every member. Otherwise, each member has the modification
+	// time of its file.
+	ModTime time.Time
+}
+
+// A member is a file or directory to be written to an archive.
+type member struct {
+	path string
+	name string
+	info fs.FileInfo
+}
+
+// members returns the members for the paths under dir in fsys that match g, sorted by name. Each matching path is
+// preceded by its parent directories beneath dir, so that each directory in the archive has its own member.
+func members(fsys fs.FS, dir string, g glob.Glob, options Options) ([]member, error) {
+	dir = path.Clean(dir)
+
+	var ms []member
+	for r := range glob.MatchResults(g, fsys, dir, glob.MatchOptions{Dirs: glob.DirsPre, Parents: true}) {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+
+		// Symbolic links are archived as the files they refer to.
+		var info fs.FileInfo
+		var err error
+		if r.DirEntry.Type()&fs.ModeSymlink != 0 {
+			info, err = fs.Stat(fsys, r.Path)
+		} else {
+			info, err = r.DirEntry.Info()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := r.Path
+		if dir != "." {
+			name = r.Path[len(dir)+1:]
+		}
+		ms = append(ms, member{path: r.Path, name: options.Prefix + name, info: info})
+	}
+
+	// Sorting by name places each directory before its contents.
+	slices.SortFunc(ms, func(a, b member) int { return strings.Compare(a.name, b.name) })
+	return ms, nil
+}
+
+// modTime returns the modification time of the given member.
+func (o Options) modTime(m member) time.Time {
+	if !o.ModTime.IsZero() {
+		return o.ModTime
+	}
+	return m.info.ModTime()
+}
+
+// WriteTar writes a member to tw for each path under dir in fsys that matches g and for each directory beneath dir that
+// contains a match, as a match with MatchOptions.Parents set would yield them. Each member is named by Options.Prefix
+// and its path relative to dir; directories are written as directory members with a trailing '/', and symbolic links
+// as the files they refer to. Members are written in sorted order, with the permissions of their files and no owner.
+// WriteTar does not close tw.
+//
+// The matches are collected before any member is written, so if a directory cannot be read, WriteTar returns the error
+// without writing anything.
+func WriteTar(tw *tar.Writer, fsys fs.FS, dir string, g glob.Glob, options Options) error {
+	ms, err := members(fsys, dir, g, options)
+	if err != nil {
+		return err
+	}
+	for _, m := range ms {
+		hdr := &tar.Header{
+			Name:    m.name,
+			Mode:    int64(m.info.Mode().Perm()),
+			ModTime: options.modTime(m),
+			Format:  tar.FormatPAX,
+		}
+		if m.info.IsDir() {
+			hdr.Typeflag, hdr.Name = tar.TypeDir, hdr.Name+"/"
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		hdr.Typeflag, hdr.Size = tar.TypeReg, m.info.Size()
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if err := copyFile(tw, fsys, m.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteZip writes the paths under dir in fsys that match g to zw, as WriteTar does. Files are compressed with the
+// Deflate method. WriteZip does not close zw.
+func WriteZip(zw *zip.Writer, fsys fs.FS, dir string, g glob.Glob, options Options) error {
+	ms, err := members(fsys, dir, g, options)
+	if err != nil {
+		return err
+	}
+	for _, m := range ms {
+		hdr := &zip.FileHeader{
+			Name:     m.name,
+			Method:   zip.Deflate,
+			Modified: options.modTime(m).UTC(),
+		}
+		hdr.SetMode(m.info.Mode() & (fs.ModeDir | fs.ModePerm))
+		if m.info.IsDir() {
+			hdr.Name, hdr.Method = hdr.Name+"/", zip.Store
+		}
+
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if !m.info.IsDir() {
+			if err := copyFile(w, fsys, m.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies the contents of the named file to w.
+func copyFile(w io.Writer, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,124 @@
+package globarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pgavlin/glob"
+	"github.com/pgavlin/glob/globtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFS returns the tree archived by the tests.
+func testFS() fstest.MapFS {
+	now := time.Now()
+	return fstest.MapFS{
+		"build/bin/tool":      {Data: []byte("#!/bin/sh"), Mode: 0o755, ModTime: now},
+		"build/lib/a.so":      {Data: []byte("a"), Mode: 0o644, ModTime: now},
+		"build/lib/b.so":      {Data: []byte("bb"), Mode: 0o644, ModTime: now},
+		"build/lib/b.so.link": {Data: []byte("b.so"), Mode: fs.ModeSymlink},
+		"build/obj/a.o":       {Data: []byte("obj"), Mode: 0o644, ModTime: now},
+		"build/share/doc":     {Mode: fs.ModeDir | 0o755, ModTime: now},
+	}
+}
+
+type archived struct {
+	Name string
+	Mode fs.FileMode
+	Data string
+}
+
+func TestWriteTar(t *testing.T) {
+	g, err := glob.New([]string{"**"}, []string{"obj", "**/*.link"})
+	require.NoError(t, err)
+
+	write := func(fsys fs.FS) []byte {
+		var b bytes.Buffer
+		tw := tar.NewWriter(&b)
+		err := WriteTar(tw, fsys, "build", g, Options{Prefix: "release/", ModTime: time.Unix(1, 0)})
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		return b.Bytes()
+	}
+
+	archive := write(testFS())
+	var members []archived
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		assert.Equal(t, time.Unix(1, 0), hdr.ModTime)
+		assert.Equal(t, 0, hdr.Uid)
+		members = append(members, archived{Name: hdr.Name, Mode: hdr.FileInfo().Mode(), Data: string(data)})
+	}
+	assert.Equal(t, []archived{
+		{Name: "release/bin/", Mode: fs.ModeDir | 0o555},
+		{Name: "release/bin/tool", Mode: 0o755, Data: "#!/bin/sh"},
+		{Name: "release/lib/", Mode: fs.ModeDir | 0o555},
+		{Name: "release/lib/a.so", Mode: 0o644, Data: "a"},
+		{Name: "release/lib/b.so", Mode: 0o644, Data: "bb"},
+		{Name: "release/share/", Mode: fs.ModeDir | 0o555},
+		{Name: "release/share/doc/", Mode: fs.ModeDir | 0o755},
+	}, members)
+
+	// The archive does not depend on the order in which directory entries are read.
+	unsorted := &globtest.FaultFS{FS: testFS(), Unsorted: true}
+	assert.Equal(t, archive, write(unsorted))
+}
+
+func TestWriteZip(t *testing.T) {
+	g, err := glob.New([]string{"**/*.so", "**/*.link", "bin/tool"}, nil)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	require.NoError(t, WriteZip(zw, testFS(), "build", g, Options{}))
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	require.NoError(t, err)
+	var members []archived
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		members = append(members, archived{Name: f.Name, Mode: f.Mode(), Data: string(data)})
+	}
+	assert.Equal(t, []archived{
+		{Name: "bin/", Mode: fs.ModeDir | 0o555},
+		{Name: "bin/tool", Mode: 0o755, Data: "#!/bin/sh"},
+		{Name: "lib/", Mode: fs.ModeDir | 0o555},
+		{Name: "lib/a.so", Mode: 0o644, Data: "a"},
+		{Name: "lib/b.so", Mode: 0o644, Data: "bb"},
+		{Name: "lib/b.so.link", Mode: 0o644, Data: "bb"},
+	}, members)
+}
+
+func TestWriteErrors(t *testing.T) {
+	g, err := glob.New([]string{"**"}, nil)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	err = WriteTar(tw, testFS(), "missing", g, Options{})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Zero(t, b.Len())
+
+	zw := zip.NewWriter(&b)
+	fsys := &globtest.FaultFS{FS: testFS(), ReadDirErrors: map[string]error{"build/lib": fs.ErrPermission}}
+	err = WriteZip(zw, fsys, "build", g, Options{})
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
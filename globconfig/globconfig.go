@@ -0,0 +1,110 @@
+// Package globconfig decodes globs from configuration data using github.com/go-viper/mapstructure/v2, the decoder
+// used by viper and other configuration libraries.
+//
+// A glob may be written in a configuration file as a single include pattern, as a list of include patterns, or as a
+// map with "include" and "exclude" keys, each of which holds a pattern or a list of patterns:
+//
+//	sources: "src/**/*.go"
+//	docs: ["**/*.md", "**/*.txt"]
+//	assets:
+//	  include: "web/**"
+//	  exclude: ["**/node_modules", "**/*.map"]
+package globconfig
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/pgavlin/glob"
+)
+
+var globType = reflect.TypeFor[glob.Glob]()
+
+// DecodeHook returns a decode hook that compiles configuration values into glob.Glob fields using the given options.
+// Values that are decoded into other types are passed through unchanged. If a value has the wrong shape or contains
+// malformed patterns, the hook fails the decode with an error that describes the problem; syntax errors are reported
+// as *glob.SyntaxError values.
+//
+// To use the hook with viper, pass it to Unmarshal:
+//
+//	err := v.Unmarshal(&config, viper.DecodeHook(globconfig.DecodeHook(glob.Options{})))
+func DecodeHook(options glob.Options) mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data any) (any, error) {
+		if to != globType || from.Implements(globType) {
+			return data, nil
+		}
+		return Decode(data, options)
+	}
+}
+
+// Decode compiles a single configuration value into a glob. The value must be a string, a list of strings, or a map
+// with "include" and "exclude" keys as described in the package documentation.
+func Decode(data any, options glob.Options) (glob.Glob, error) {
+	var includes, excludes []string
+	switch data := data.(type) {
+	case map[string]any:
+		for k, v := range data {
+			if err := decodeKey(k, v, &includes, &excludes); err != nil {
+				return nil, err
+			}
+		}
+	case map[any]any:
+		for k, v := range data {
+			s, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("glob key %v is a %T, not a string", k, k)
+			}
+			if err := decodeKey(s, v, &includes, &excludes); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		patterns, err := decodePatterns(data)
+		if err != nil {
+			return nil, err
+		}
+		includes = patterns
+	}
+	return glob.NewWithOptions(includes, excludes, options)
+}
+
+func decodeKey(key string, value any, includes, excludes *[]string) error {
+	patterns, err := decodePatterns(value)
+	if err != nil {
+		return fmt.Errorf("%v: %w", key, err)
+	}
+	switch key {
+	case "include":
+		*includes = patterns
+	case "exclude":
+		*excludes = patterns
+	default:
+		return fmt.Errorf("unknown glob key %q; expected \"include\" or \"exclude\"", key)
+	}
+	return nil
+}
+
+func decodePatterns(data any) ([]string, error) {
+	switch data := data.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{data}, nil
+	case []string:
+		return data, nil
+	case []any:
+		patterns := make([]string, len(data))
+		for i, p := range data {
+			s, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("pattern %v is a %T, not a string", i, p)
+			}
+			patterns[i] = s
+		}
+		return patterns, nil
+	default:
+		return nil, fmt.Errorf("cannot decode a glob from a %T; expected a pattern, a list of patterns, "+
+			"or a map with \"include\" and \"exclude\" keys", data)
+	}
+}
@@ -0,0 +1,78 @@
+package globconfig
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/pgavlin/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Sources glob.Glob
+	Docs    glob.Glob
+	Assets  glob.Glob
+	Name    string
+}
+
+func decode(t *testing.T, input map[string]any) (config, error) {
+	var c config
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: DecodeHook(glob.Options{}),
+		Result:     &c,
+	})
+	require.NoError(t, err)
+	return c, dec.Decode(input)
+}
+
+func TestDecodeHook(t *testing.T) {
+	c, err := decode(t, map[string]any{
+		"sources": "src/**/*.go",
+		"docs":    []any{"**/*.md", "**/*.txt"},
+		"assets": map[string]any{
+			"include": "web/**",
+			"exclude": []string{"**/node_modules", "**/*.map"},
+		},
+		"name": "site",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "site", c.Name)
+	assert.True(t, c.Sources.MatchPath("src/cmd/main.go"))
+	assert.False(t, c.Sources.MatchPath("main.go"))
+	assert.True(t, c.Docs.MatchPath("README.md"))
+	assert.True(t, c.Docs.MatchPath("docs/notes.txt"))
+	assert.True(t, c.Assets.MatchPath("web/app.js"))
+	assert.False(t, c.Assets.MatchPath("web/app.js.map"))
+	assert.False(t, c.Assets.MatchPath("web/node_modules/x/index.js"))
+}
+
+func TestDecodeHookErrors(t *testing.T) {
+	_, err := decode(t, map[string]any{"sources": []any{"src/**", "[a-"}})
+	var syntaxErr *glob.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr), "%v", err)
+	assert.Equal(t, "[a-", syntaxErr.Pattern)
+
+	_, err = decode(t, map[string]any{"sources": []any{"src/**", 42}})
+	assert.ErrorContains(t, err, "pattern 1 is a int, not a string")
+
+	_, err = decode(t, map[string]any{"assets": map[string]any{"includes": "web/**"}})
+	assert.ErrorContains(t, err, `unknown glob key "includes"`)
+
+	_, err = decode(t, map[string]any{"assets": map[any]any{"exclude": true}})
+	assert.ErrorContains(t, err, "exclude: cannot decode a glob from a bool")
+}
+
+func TestDecode(t *testing.T) {
+	g, err := Decode("(?i)*.MD", glob.Options{})
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("readme.md"))
+
+	g, err = Decode(map[string]any{"include": "**", "exclude": "**/testdata"}, glob.Options{ExplicitDot: true})
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("a/b.go"))
+	assert.False(t, g.MatchPath(".git/config"))
+	assert.False(t, g.MatchPath("a/testdata/x"))
+}
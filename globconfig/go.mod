@@ -0,0 +1,18 @@
+module github.com/pgavlin/glob/globconfig
+
+go 1.24.0
+
+require (
+	github.com/go-viper/mapstructure/v2 v2.5.0
+	github.com/pgavlin/glob v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pgavlin/fx/v2 v2.0.11 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/pgavlin/glob => ../
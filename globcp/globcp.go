@@ -0,0 +1,204 @@
+// Package globcp copies the files matched by a glob from one filesystem to another, preserving the structure of the
+// tree beneath the starting directory:
+//
+//	err := globcp.CopyMatches(ctx, globcp.DirFS("out"), os.DirFS("src"), ".", g, globcp.Options{Concurrency: 8})
+package globcp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/pgavlin/glob"
+)
+
+// A WriteFS is a filesystem that files can be copied into. Names are slash-separated paths relative to the root of the
+// filesystem, as they are for fs.FS.
+type WriteFS interface {
+	// MkdirAll creates the named directory and any parents that do not exist, as os.MkdirAll does.
+	MkdirAll(name string, perm fs.FileMode) error
+	// OpenFile opens the named file for writing with the given os.OpenFile flags and permissions.
+	OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error)
+	// Stat returns information about the named file.
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// DirFS returns a WriteFS for the tree of files rooted at the directory dir in the operating system's filesystem.
+func DirFS(dir string) WriteFS {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+// join returns the operating system path of the named file.
+func (dir dirFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(string(dir), filepath.FromSlash(name)), nil
+}
+
+func (dir dirFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := dir.join("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, perm)
+}
+
+func (dir dirFS) OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error) {
+	p, err := dir.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, flag, perm)
+}
+
+func (dir dirFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := dir.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+// An Overwrite determines what CopyMatches does when a file it copies already exists in the destination.
+type Overwrite int
+
+const (
+	// OverwriteAlways replaces existing files.
+	OverwriteAlways Overwrite = iota
+	// OverwriteNever leaves existing files as they are.
+	OverwriteNever
+	// OverwriteOlder replaces existing files that were last modified before their sources.
+	OverwriteOlder
+	// OverwriteError fails the copy with an error that wraps fs.ErrExist.
+	OverwriteError
+)
+
+// Options controls the behavior of CopyMatches.
+type Options struct {
+	// Overwrite determines what happens when a file already exists in the destination.
+	Overwrite Overwrite
+
+	// Concurrency is the number of files to copy at once. If it is less than one, files are copied one at a time. src
+	// and dst must be safe for concurrent use if Concurrency is greater than one.
+	Concurrency int
+}
+
+// CopyMatches copies the files under dir in src that match g into dst. Each match is copied to its path relative to
+// dir, and the directories that contain the matches are created first, as are matching directories, even if they are
+// empty. Directories are created with permissions 0777 and files with the permissions of their sources, before the
+// umask. Symbolic links are copied as the files they refer to.
+//
+// The first error encountered while matching or copying cancels the context and stops the copy, and CopyMatches
+// returns that error once every file that is being copied has been copied; files copied before the error are left in
+// place. If ctx is canceled, CopyMatches stops and returns the context's error.
+func CopyMatches(ctx context.Context, dst WriteFS, src fs.FS, dir string, g glob.Glob, options Options) error {
+	dir = path.Clean(dir)
+	n := max(options.Concurrency, 1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	if err := dst.MkdirAll(".", 0o777); err != nil {
+		return err
+	}
+
+	type job struct{ from, to string }
+	jobs := make(chan job, n)
+
+	var workers sync.WaitGroup
+	workers.Add(n)
+	for range n {
+		go func() {
+			defer workers.Done()
+
+			for j := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := copyFile(dst, src, j.from, j.to, options.Overwrite); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+	matchOptions := glob.MatchOptions{Dirs: glob.DirsPre, Parents: true}
+	for r := range glob.MatchResults(g, src, dir, matchOptions) {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.Err != nil {
+			cancel(r.Err)
+			break
+		}
+
+		to := r.Path
+		if dir != "." {
+			to = r.Path[len(dir)+1:]
+		}
+		if r.DirEntry.IsDir() {
+			if err := dst.MkdirAll(to, 0o777); err != nil {
+				cancel(err)
+				break
+			}
+			continue
+		}
+		select {
+		case jobs <- job{from: r.Path, to: to}:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	workers.Wait()
+
+	return context.Cause(ctx)
+}
+
+// copyFile copies the file named from in src to the file named to in dst according to the given overwrite policy.
+func copyFile(dst WriteFS, src fs.FS, from, to string, overwrite Overwrite) error {
+	r, err := src.Open(from)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	info, err := r.Stat()
+	if err != nil {
+		return err
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch overwrite {
+	case OverwriteNever, OverwriteError:
+		flag |= os.O_EXCL
+	case OverwriteOlder:
+		existing, err := dst.Stat(to)
+		switch {
+		case err == nil && !existing.ModTime().Before(info.ModTime()):
+			return nil
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+	}
+
+	w, err := dst.OpenFile(to, flag, info.Mode().Perm())
+	if err != nil {
+		if overwrite == OverwriteNever && errors.Is(err, fs.ErrExist) {
+			return nil
+		}
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,133 @@
+package globcp
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pgavlin/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readTree returns the contents of the files beneath dir by slash-separated path, with directories mapped to "/".
+func readTree(t *testing.T, dir string) map[string]string {
+	tree := map[string]string{}
+	err := fs.WalkDir(os.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case p == ".":
+			return nil
+		case d.IsDir():
+			tree[p] = "/"
+			return nil
+		}
+		data, err := fs.ReadFile(os.DirFS(dir), p)
+		tree[p] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+	return tree
+}
+
+func TestCopyMatches(t *testing.T) {
+	src := fstest.MapFS{
+		"src/main.go":         {Data: []byte("package main"), Mode: 0o644},
+		"src/lib/lib.go":      {Data: []byte("package lib"), Mode: 0o600},
+		"src/lib/lib.txt":     {Data: []byte("notes"), Mode: 0o644},
+		"src/testdata/x.go":   {Data: []byte("x"), Mode: 0o644},
+		"src/empty":           {Mode: fs.ModeDir | 0o755},
+		"docs/guide/intro.md": {Data: []byte("# intro"), Mode: 0o644},
+	}
+
+	g, err := glob.New([]string{"**/*.go", "empty"}, []string{"**/testdata"})
+	require.NoError(t, err)
+
+	for _, concurrency := range []int{0, 4} {
+		dst := t.TempDir()
+		err = CopyMatches(context.Background(), DirFS(dst), src, "src", g, Options{Concurrency: concurrency})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"empty":      "/",
+			"lib":        "/",
+			"lib/lib.go": "package lib",
+			"main.go":    "package main",
+		}, readTree(t, dst))
+
+		info, err := os.Stat(filepath.Join(dst, "lib", "lib.go"))
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+	}
+
+	dst := t.TempDir()
+	g, err = glob.New([]string{"**/*.md"}, nil)
+	require.NoError(t, err)
+	err = CopyMatches(context.Background(), DirFS(filepath.Join(dst, "out")), src, ".", g, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"out":                     "/",
+		"out/docs":                "/",
+		"out/docs/guide":          "/",
+		"out/docs/guide/intro.md": "# intro",
+	}, readTree(t, dst))
+}
+
+func TestCopyMatchesOverwrite(t *testing.T) {
+	now := time.Now()
+	src := fstest.MapFS{
+		"new.txt": {Data: []byte("new"), Mode: 0o644, ModTime: now},
+		"old.txt": {Data: []byte("new"), Mode: 0o644, ModTime: now.Add(-time.Hour)},
+	}
+	g, err := glob.New([]string{"*.txt"}, nil)
+	require.NoError(t, err)
+
+	cases := []struct {
+		overwrite Overwrite
+		expected  map[string]string
+	}{
+		{OverwriteAlways, map[string]string{"new.txt": "new", "old.txt": "new"}},
+		{OverwriteNever, map[string]string{"new.txt": "old", "old.txt": "old"}},
+		{OverwriteOlder, map[string]string{"new.txt": "new", "old.txt": "old"}},
+	}
+	for _, c := range cases {
+		dst := t.TempDir()
+		for _, name := range []string{"new.txt", "old.txt"} {
+			p := filepath.Join(dst, name)
+			require.NoError(t, os.WriteFile(p, []byte("old"), 0o644))
+			require.NoError(t, os.Chtimes(p, now.Add(-time.Minute), now.Add(-time.Minute)))
+		}
+
+		err := CopyMatches(context.Background(), DirFS(dst), src, ".", g, Options{Overwrite: c.overwrite})
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, readTree(t, dst), "%v", c.overwrite)
+	}
+
+	dst := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "old.txt"), []byte("old"), 0o644))
+	err = CopyMatches(context.Background(), DirFS(dst), src, ".", g, Options{Overwrite: OverwriteError})
+	assert.ErrorIs(t, err, fs.ErrExist)
+}
+
+func TestCopyMatchesErrors(t *testing.T) {
+	g, err := glob.New([]string{"**"}, nil)
+	require.NoError(t, err)
+
+	// Errors reading the source stop the copy.
+	err = CopyMatches(context.Background(), DirFS(t.TempDir()), fstest.MapFS{}, "missing", g, Options{})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// A canceled context stops the copy.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+	err = CopyMatches(ctx, DirFS(t.TempDir()), src, ".", g, Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = DirFS(t.TempDir()).Stat("../x")
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+}
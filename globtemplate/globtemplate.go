@@ -0,0 +1,46 @@
+// Package globtemplate provides template functions that match globs against a filesystem, for use with text/template
+// and html/template. Static site generators and similar tools can use them to enumerate their inputs from within a
+// template:
+//
+//	{{ range glob "posts/**/*.md" }}
+//	  <li>{{ . }}</li>
+//	{{ end }}
+package globtemplate
+
+import (
+	"io/fs"
+	"text/template"
+
+	"github.com/pgavlin/glob"
+)
+
+// Funcs returns the glob template functions for fsys. Use it with template.Template.Funcs; for an html/template
+// Template, convert the result to an html/template FuncMap.
+//
+// The functions are:
+//
+//	glob pattern...
+//		Returns the sorted paths of the files in fsys that match any of the given patterns.
+//	globMatch pattern path
+//		Reports whether path matches pattern. The filesystem is not consulted.
+//
+// Patterns are compiled with the given options. If a pattern is malformed or a directory cannot be read, the function
+// returns an error, which stops the execution of the template.
+func Funcs(fsys fs.FS, options glob.Options) template.FuncMap {
+	return template.FuncMap{
+		"glob": func(patterns ...string) ([]string, error) {
+			g, err := glob.NewWithOptions(patterns, nil, options)
+			if err != nil {
+				return nil, err
+			}
+			return glob.CollectSorted(g.Match(fsys, ".", false))
+		},
+		"globMatch": func(pattern, path string) (bool, error) {
+			g, err := glob.NewWithOptions([]string{pattern}, nil, options)
+			if err != nil {
+				return false, err
+			}
+			return g.MatchPath(path), nil
+		},
+	}
+}
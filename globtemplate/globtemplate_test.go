@@ -0,0 +1,70 @@
+package globtemplate
+
+import (
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/pgavlin/glob"
+	"github.com/pgavlin/glob/globtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var site = fstest.MapFS{
+	"posts/2024/b.md":      {},
+	"posts/2024/a.md":      {},
+	"posts/2025/c.md":      {},
+	"posts/2025/c.png":     {},
+	"pages/about.md":       {},
+	"pages/about.html":     {},
+	"drafts/unfinished.md": {},
+}
+
+func execute(t *testing.T, fsys fs.FS, text string) (string, error) {
+	tmpl, err := template.New("test").Funcs(Funcs(fsys, glob.Options{})).Parse(text)
+	require.NoError(t, err)
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, nil)
+	return b.String(), err
+}
+
+func TestGlob(t *testing.T) {
+	out, err := execute(t, site, `{{ range glob "posts/**/*.md" "pages/*.md" }}{{ . }};{{ end }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "pages/about.md;posts/2024/a.md;posts/2024/b.md;posts/2025/c.md;", out)
+}
+
+func TestGlobMatch(t *testing.T) {
+	out, err := execute(t, site, `{{ globMatch "posts/**/*.md" "posts/2025/c.md" }} {{ globMatch "*.md" "posts/2025/c.md" }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "true false", out)
+}
+
+func TestHTMLTemplate(t *testing.T) {
+	tmpl, err := htmltemplate.New("test").Funcs(htmltemplate.FuncMap(Funcs(site, glob.Options{}))).
+		Parse(`{{ range glob "pages/*" }}<li>{{ . }}</li>{{ end }}`)
+	require.NoError(t, err)
+
+	var b strings.Builder
+	require.NoError(t, tmpl.Execute(&b, nil))
+	assert.Equal(t, "<li>pages/about.html</li><li>pages/about.md</li>", b.String())
+}
+
+func TestErrors(t *testing.T) {
+	_, err := execute(t, site, `{{ range glob "posts/[" }}{{ . }}{{ end }}`)
+	var syntaxErr *glob.SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr), "%v", err)
+
+	_, err = execute(t, site, `{{ globMatch "[" "posts" }}`)
+	assert.True(t, errors.As(err, &syntaxErr), "%v", err)
+
+	fsys := &globtest.FaultFS{FS: site, ReadDirErrors: map[string]error{"posts/2025": fs.ErrPermission}}
+	_, err = execute(t, fsys, `{{ range glob "posts/**/*.md" }}{{ . }}{{ end }}`)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
@@ -0,0 +1,69 @@
+// Package globtest provides filesystems and matchers for testing code that uses package glob.
+package globtest
+
+import (
+	"io/fs"
+	"slices"
+	"time"
+)
+
+// A FaultFS wraps a filesystem and injects faults into its ReadDir and Stat methods, which are the methods that a glob
+// match calls. Faults are chosen by name: the names used as keys are io/fs names such as "." or "src/lib".
+//
+// The zero value of each field injects no faults, so a FaultFS with only FS set behaves like FS.
+type FaultFS struct {
+	// FS is the underlying filesystem.
+	FS fs.FS
+
+	// ReadDirErrors maps the names of directories to errors returned by ReadDir. Each error is wrapped in an
+	// *fs.PathError.
+	ReadDirErrors map[string]error
+	// StatErrors maps names to errors returned by Stat. Each error is wrapped in an *fs.PathError.
+	StatErrors map[string]error
+
+	// Unsorted causes ReadDir to return entries in reverse order rather than sorted by name, as an fs.ReadDirFS that
+	// does not honor its contract might.
+	Unsorted bool
+
+	// Delay, if non-nil, is called before each call to ReadDir or Stat with the operation ("readdir" or "stat") and
+	// the name. The call is delayed by the returned duration.
+	Delay func(op, name string) time.Duration
+}
+
+// Open opens the named file in the underlying filesystem. No faults are injected.
+func (f *FaultFS) Open(name string) (fs.File, error) {
+	return f.FS.Open(name)
+}
+
+// ReadDir reads the named directory, injecting any faults configured for it.
+func (f *FaultFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.fault("readdir", name, f.ReadDirErrors); err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.FS, name)
+	if f.Unsorted {
+		slices.Reverse(entries)
+	}
+	return entries, err
+}
+
+// Stat returns information about the named file, injecting any faults configured for it.
+func (f *FaultFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.fault("stat", name, f.StatErrors); err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.FS, name)
+}
+
+// fault delays the given operation and returns its configured error, if any.
+func (f *FaultFS) fault(op, name string, errs map[string]error) error {
+	if f.Delay != nil {
+		if d := f.Delay(op, name); d > 0 {
+			time.Sleep(d)
+		}
+	}
+	if err, ok := errs[name]; ok {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
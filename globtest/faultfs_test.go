@@ -0,0 +1,92 @@
+package globtest
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pgavlin/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var files = fstest.MapFS{
+	"a.go":        {},
+	"b.go":        {},
+	"lib/c.go":    {},
+	"lib/d.go":    {},
+	"vendor/e.go": {},
+}
+
+func TestFaultFSReadDir(t *testing.T) {
+	errBroken := errors.New("broken")
+	fsys := &FaultFS{FS: files, ReadDirErrors: map[string]error{"lib": errBroken}}
+
+	g, err := glob.New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	var paths []string
+	var errs []error
+	for p, err := range g.Match(fsys, ".", false) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			paths = append(paths, p)
+		}
+	}
+	assert.Equal(t, []string{"a.go", "b.go", "vendor/e.go"}, paths)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errBroken)
+
+	var pathErr *fs.PathError
+	require.ErrorAs(t, errs[0], &pathErr)
+	assert.Equal(t, "readdir", pathErr.Op)
+	assert.Equal(t, "lib", pathErr.Path)
+}
+
+func TestFaultFSStat(t *testing.T) {
+	fsys := &FaultFS{FS: files, StatErrors: map[string]error{"lib/c.go": fs.ErrPermission}}
+
+	g, err := glob.New([]string{"lib/c.go"}, nil)
+	require.NoError(t, err)
+
+	paths, err := glob.Collect(g.Match(fsys, ".", false))
+	assert.Empty(t, paths)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
+
+func TestFaultFSUnsorted(t *testing.T) {
+	fsys := &FaultFS{FS: files, Unsorted: true}
+
+	entries, err := fsys.ReadDir(".")
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"vendor", "lib", "b.go", "a.go"}, names)
+}
+
+func TestFaultFSDelay(t *testing.T) {
+	var mu sync.Mutex
+	var ops []string
+	fsys := &FaultFS{FS: files, Delay: func(op, name string) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		ops = append(ops, op+" "+name)
+		return time.Millisecond
+	}}
+
+	g, err := glob.New([]string{"lib/*.go"}, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	paths, err := glob.Collect(g.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lib/c.go", "lib/d.go"}, paths)
+	assert.Equal(t, []string{"readdir lib"}, ops)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond)
+}
@@ -0,0 +1,89 @@
+package globtest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pgavlin/glob"
+)
+
+// ReferenceMatch is a slow reference implementation of g.Match. Rather than pruning its traversal using g's patterns,
+// ReferenceMatch walks every directory under dir with fs.WalkDir and yields each path whose name relative to dir is
+// matched by g.MatchPath. Errors reading directories are yielded as they are by g.Match.
+//
+// ReferenceMatch is intended for differential testing: for any glob and filesystem, g.Match and ReferenceMatch should
+// yield the same set of paths. See Compare.
+func ReferenceMatch(g glob.Glob, fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	dir = path.Clean(dir)
+	return func(yield func(string, error) bool) {
+		err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if !yield(p, err) {
+					return fs.SkipAll
+				}
+				return nil
+			}
+			if p == dir || d.IsDir() && !includeDirs {
+				return nil
+			}
+			if g.MatchPath(relativePath(dir, p)) && !yield(p, nil) {
+				return fs.SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			yield(dir, err)
+		}
+	}
+}
+
+// relativePath returns the name of p relative to dir, where p was visited by a walk rooted at dir.
+func relativePath(dir, p string) string {
+	if dir == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, dir+"/")
+}
+
+// Compare matches g against the contents of dir using both g.Match and ReferenceMatch and returns an error that
+// describes any differences between the paths they yield. The order in which paths are yielded is not compared. Errors
+// reading the filesystem are returned as-is.
+func Compare(g glob.Glob, fsys fs.FS, dir string, includeDirs bool) error {
+	actual, err := glob.CollectSorted(g.Match(fsys, dir, includeDirs))
+	if err != nil {
+		return err
+	}
+	expected, err := glob.CollectSorted(ReferenceMatch(g, fsys, dir, includeDirs))
+	if err != nil {
+		return err
+	}
+
+	var missing, extra []string
+	for _, p := range expected {
+		if _, ok := slices.BinarySearch(actual, p); !ok {
+			missing = append(missing, p)
+		}
+	}
+	for _, p := range actual {
+		if _, ok := slices.BinarySearch(expected, p); !ok {
+			extra = append(extra, p)
+		}
+	}
+
+	var errs []error
+	if len(missing) != 0 {
+		errs = append(errs, fmt.Errorf("Match did not yield %q", missing))
+	}
+	if len(extra) != 0 {
+		errs = append(errs, fmt.Errorf("Match unexpectedly yielded %q", extra))
+	}
+	if dups := len(actual) - len(slices.Compact(slices.Clone(actual))); dups != 0 {
+		errs = append(errs, fmt.Errorf("Match yielded %d duplicate paths", dups))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package globtest
+
+import (
+	"io/fs"
+	"iter"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pgavlin/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceMatch(t *testing.T) {
+	g, err := glob.New([]string{"**/*.go"}, []string{"vendor"})
+	require.NoError(t, err)
+
+	paths, err := glob.Collect(ReferenceMatch(g, files, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "b.go", "lib/c.go", "lib/d.go"}, paths)
+
+	paths, err = glob.Collect(ReferenceMatch(g, files, "lib", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lib/c.go", "lib/d.go"}, paths)
+}
+
+func TestCompare(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":             {},
+		"b.txt":            {},
+		".hidden/x.go":     {},
+		"src/.x.go":        {},
+		"src/a.go":         {},
+		"src/lib/b.go":     {},
+		"src/lib/c.txt":    {},
+		"src/x.c/y.go":     {},
+		"vendor/v.go":      {},
+		"vendor/keep/k.go": {},
+	}
+
+	cases := []struct {
+		includes []string
+		excludes []string
+	}{
+		{[]string{"**"}, nil},
+		{[]string{"*"}, nil},
+		{[]string{"src"}, nil},
+		{[]string{"src/**"}, nil},
+		{[]string{"**/*.go"}, []string{"vendor"}},
+		{[]string{"**/*.go"}, []string{"vendor", "!vendor/keep"}},
+		{[]string{"src/**/*.go"}, []string{"**/lib"}},
+		{[]string{"**/*.c"}, nil},
+		{[]string{".*"}, nil},
+		{[]string{"src/{a,lib}*"}, nil},
+		{[]string{"**/*"}, []string{"*.txt"}},
+	}
+	for _, c := range cases {
+		g, err := glob.New(c.includes, c.excludes)
+		require.NoError(t, err)
+		for _, dir := range []string{".", "src"} {
+			for _, includeDirs := range []bool{false, true} {
+				assert.NoError(t, Compare(g, fsys, dir, includeDirs), "%q %q %v %v", c.includes, c.excludes, dir, includeDirs)
+			}
+		}
+	}
+}
+
+// dropGlob is a glob whose Match omits a path.
+type dropGlob struct {
+	glob.Glob
+	drop string
+}
+
+func (g dropGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for p, err := range g.Glob.Match(fsys, dir, includeDirs) {
+			if p != g.drop && !yield(p, err) {
+				return
+			}
+		}
+	}
+}
+
+func TestCompareDifferences(t *testing.T) {
+	g, err := glob.New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	err = Compare(dropGlob{Glob: g, drop: "lib/c.go"}, files, ".", false)
+	assert.EqualError(t, err, `Match did not yield ["lib/c.go"]`)
+
+	err = Compare(g, &FaultFS{FS: files, ReadDirErrors: map[string]error{"lib": fs.ErrPermission}}, ".", false)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
@@ -0,0 +1,353 @@
+package glob
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"container/heap"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+	"unsafe"
+)
+
+// Count returns the number of files under dir that match g. Any errors encountered while reading directories are
+// joined and returned alongside the count.
+func Count(g Glob, fsys fs.FS, dir string) (int, error) {
+	count := 0
+	var errs []error
+	for _, err := range g.Match(fsys, dir, false) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			count++
+		}
+	}
+	return count, errors.Join(errs...)
+}
+
+// Exists reports whether any file under dir matches g. Exists stops reading directories as soon as it finds a match.
+// If no match is found, any errors encountered while reading directories are joined and returned.
+func Exists(g Glob, fsys fs.FS, dir string) (bool, error) {
+	_, ok, err := First(g, fsys, dir)
+	return ok, err
+}
+
+// First returns the first file under dir that matches g. First stops reading directories as soon as it finds a match.
+// If no match is found, First returns false and any errors encountered while reading directories, joined.
+func First(g Glob, fsys fs.FS, dir string) (string, bool, error) {
+	var errs []error
+	for p, err := range g.Match(fsys, dir, false) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return p, true, nil
+	}
+	return "", false, errors.Join(errs...)
+}
+
+// Collect gathers the paths in the given sequence of matches. Any errors in the sequence are joined and returned
+// alongside the paths.
+func Collect(matches iter.Seq2[string, error]) ([]string, error) {
+	var paths []string
+	var errs []error
+	for p, err := range matches {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			paths = append(paths, p)
+		}
+	}
+	return paths, errors.Join(errs...)
+}
+
+// CollectSorted is like Collect, but sorts the paths it returns.
+func CollectSorted(matches iter.Seq2[string, error]) ([]string, error) {
+	paths, err := Collect(matches)
+	slices.Sort(paths)
+	return paths, err
+}
+
+// CollectSortedFunc is like Collect, but gathers the paths of the given results, such as those yielded by MatchResults,
+// and sorts them by their file information using the given comparison function, such as ByModTime or BySize. The
+// information for each path is fetched with its directory entry's Info method, so for results yielded by MatchResults
+// no additional filesystem operations are performed, and symbolic links are described as they were read. Paths that
+// compare equal are sorted by name. Paths whose information cannot be fetched are omitted, and the errors are returned
+// alongside the paths.
+func CollectSortedFunc(results iter.Seq[Result], compare func(a, b fs.FileInfo) int) ([]string, error) {
+	type entry struct {
+		path string
+		info fs.FileInfo
+	}
+
+	var entries []entry
+	var errs []error
+	for r := range results {
+		info, err := r.info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, entry{path: r.Path, info: info})
+	}
+
+	slices.SortFunc(entries, func(a, b entry) int {
+		if c := compare(a.info, b.info); c != 0 {
+			return c
+		}
+		return strings.Compare(a.path, b.path)
+	})
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.path
+	}
+	return paths, errors.Join(errs...)
+}
+
+// Top returns the paths of the n results in the given sequence that sort last under the given comparison function, from
+// last to first. For example, Top(MatchResults(g, fsys, ".", MatchOptions{}), 10, BySize) returns the 10 largest files,
+// largest first. Paths that compare equal are ordered by name as they are by CollectSortedFunc, so Top returns a prefix
+// of the reverse of CollectSortedFunc's result. Top holds at most n paths in memory at a time. File information and
+// errors are handled as they are by CollectSortedFunc.
+func Top(results iter.Seq[Result], n int, compare func(a, b fs.FileInfo) int) ([]string, error) {
+	h := topHeap{compare: compare}
+	var errs []error
+	for r := range results {
+		info, err := r.info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		h.add(topEntry{path: r.Path, info: info}, n)
+	}
+
+	paths := make([]string, len(h.entries))
+	for i := len(paths) - 1; i >= 0; i-- {
+		paths[i] = heap.Pop(&h).(topEntry).path
+	}
+	return paths, errors.Join(errs...)
+}
+
+type topEntry struct {
+	path string
+	info fs.FileInfo
+}
+
+// A topHeap is a min-heap of entries. The least entry is evicted when the heap is full.
+type topHeap struct {
+	entries []topEntry
+	compare func(a, b fs.FileInfo) int
+}
+
+// add adds an entry to a heap that holds at most n entries.
+func (h *topHeap) add(e topEntry, n int) {
+	switch {
+	case len(h.entries) < n:
+		heap.Push(h, e)
+	case n > 0 && h.less(h.entries[0], e):
+		h.entries[0] = e
+		heap.Fix(h, 0)
+	}
+}
+
+func (h *topHeap) less(a, b topEntry) bool {
+	if c := h.compare(a.info, b.info); c != 0 {
+		return c < 0
+	}
+	return a.path < b.path
+}
+
+func (h *topHeap) Len() int           { return len(h.entries) }
+func (h *topHeap) Less(i, j int) bool { return h.less(h.entries[i], h.entries[j]) }
+func (h *topHeap) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *topHeap) Push(x any)         { h.entries = append(h.entries, x.(topEntry)) }
+
+func (h *topHeap) Pop() any {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return e
+}
+
+// ByModTime orders files from least to most recently modified.
+func ByModTime(a, b fs.FileInfo) int {
+	return a.ModTime().Compare(b.ModTime())
+}
+
+// BySize orders files from smallest to largest.
+func BySize(a, b fs.FileInfo) int {
+	return cmp.Compare(a.Size(), b.Size())
+}
+
+// Paths separates the errors in the given sequence of matches from its paths. The returned sequence yields only the
+// paths in matches; any errors are accumulated instead. After the sequence has been iterated, the returned function
+// returns the accumulated errors, joined. Each iteration of the returned sequence starts a fresh accumulation.
+func Paths(matches iter.Seq2[string, error]) (iter.Seq[string], func() error) {
+	var errs []error
+	paths := func(yield func(string) bool) {
+		errs = nil
+		for p, err := range matches {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !yield(p) {
+				return
+			}
+		}
+	}
+	return paths, func() error { return errors.Join(errs...) }
+}
+
+// FilterPaths reads a list of paths separated by delim from r and yields those that match g, in order. A delim of '\n'
+// reads a newline-delimited list, as printed by most tools; a trailing '\r' is removed from each path. A delim of 0 reads
+// a NUL-delimited list, as printed by `git ls-files -z` or `find -print0`. Empty paths are skipped. Paths are matched
+// with g.MatchPath, so they must be relative to the directory that g's patterns are matched against. If reading r
+// fails, the error is yielded with an empty path and the sequence ends.
+func FilterPaths(g Glob, r io.Reader, delim byte) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		s := bufio.NewScanner(r)
+		s.Buffer(nil, maxPathSize)
+		s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if i := bytes.IndexByte(data, delim); i >= 0 {
+				return i + 1, data[:i], nil
+			}
+			if atEOF && len(data) != 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+
+		// Globs that are known not to retain the paths they match are given each path in place in the scanner's buffer,
+		// and only the paths that are yielded are copied.
+		transient := retainsNoPaths(g)
+		for s.Scan() {
+			b := s.Bytes()
+			if delim == '\n' {
+				b = bytes.TrimSuffix(b, []byte("\r"))
+			}
+			if len(b) == 0 {
+				continue
+			}
+
+			var p string
+			if transient {
+				p = unsafe.String(unsafe.SliceData(b), len(b))
+			} else {
+				p = string(b)
+			}
+			if !g.MatchPath(p) {
+				continue
+			}
+			if transient {
+				p = string(b)
+			}
+			if !yield(p, nil) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			yield("", err)
+		}
+	}
+}
+
+// retainsNoPaths reports whether g is known not to retain the paths passed to its MatchPath method: that is, whether it
+// was created by New or NewWithOptions without a Transform or custom segment matchers, which are passed each name.
+func retainsNoPaths(g Glob) bool {
+	switch g := g.(type) {
+	case *matchGlob:
+		return g.options.Transform == nil && len(g.options.Segments) == 0
+	case allGlob, noneGlob:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxPathSize is the maximum length of a path read by FilterPaths.
+const maxPathSize = 1 << 20
+
+// GroupByDir groups the paths in the given sequence of matches by their parent directories. The returned sequence
+// yields each directory together with the paths beneath it that the matches yielded directly, in the order in which
+// they were yielded. A directory's group is yielded once the matches have moved on from the directory and its
+// subdirectories, so the group for a directory follows the groups for its subdirectories. Because a match yields the
+// contents of each directory together, each directory is yielded at most once; for sequences that interleave the
+// contents of different directories, a directory may be yielded more than once. Errors are accumulated as they are by
+// Paths, and the returned function returns them, joined.
+func GroupByDir(matches iter.Seq2[string, error]) (iter.Seq2[string, []string], func() error) {
+	type group struct {
+		dir   string
+		paths []string
+	}
+
+	var errs []error
+	groups := func(yield func(string, []string) bool) {
+		errs = nil
+
+		// open holds the groups for a chain of directories, each of which is beneath the one before it.
+		var open []group
+		for p, err := range matches {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			dir := path.Dir(p)
+			for len(open) != 0 && !within(dir, open[len(open)-1].dir) {
+				g := open[len(open)-1]
+				open = open[:len(open)-1]
+				if !yield(g.dir, g.paths) {
+					return
+				}
+			}
+			if len(open) == 0 || open[len(open)-1].dir != dir {
+				open = append(open, group{dir: dir})
+			}
+			open[len(open)-1].paths = append(open[len(open)-1].paths, p)
+		}
+		for _, g := range slices.Backward(open) {
+			if !yield(g.dir, g.paths) {
+				return
+			}
+		}
+	}
+	return groups, func() error { return errors.Join(errs...) }
+}
+
+// within reports whether p is dir or a path beneath it.
+func within(p, dir string) bool {
+	switch {
+	case p == dir:
+		return true
+	case dir == ".":
+		return !strings.HasPrefix(p, "/") && p != ".." && !strings.HasPrefix(p, "../")
+	case dir == "/":
+		return strings.HasPrefix(p, "/")
+	default:
+		return strings.HasPrefix(p, dir+"/")
+	}
+}
+
+// Depth returns the depth of a path yielded by a match of the directory dir: 0 for dir itself, 1 for its entries, 2
+// for the entries of its subdirectories, and so on. Only the portion of p below dir is examined. If p is not dir or a
+// path beneath it, Depth returns -1.
+func Depth(dir, p string) int {
+	dir, p = path.Clean(dir), path.Clean(p)
+	switch {
+	case !within(p, dir):
+		return -1
+	case p == dir:
+		return 0
+	case dir == ".":
+		return strings.Count(p, "/") + 1
+	case dir == "/":
+		return strings.Count(p[1:], "/") + 1
+	default:
+		return strings.Count(p[len(dir)+1:], "/") + 1
+	}
+}
@@ -0,0 +1,319 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountExistsFirst(t *testing.T) {
+	fsys := newReadDirFS("a/0/aa", "a/1/aa", "b", "c", "d/0", "d/1/aa")
+
+	glob, err := New([]string{"**/aa"}, nil)
+	require.NoError(t, err)
+
+	count, err := Count(glob, fsys, ".")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	fsys.reads = map[string]int{}
+	first, ok, err := First(glob, fsys, ".")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a/0/aa", first)
+	assert.Equal(t, map[string]int{".": 1, "a": 1, "a/0": 1}, fsys.reads)
+
+	ok, err = Exists(glob, fsys, "d")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Exists(glob, fsys, "x")
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	none, err := New([]string{"*.proto"}, nil)
+	require.NoError(t, err)
+	ok, err = Exists(none, fsys, ".")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCollect(t *testing.T) {
+	fsys := newReadDirFS("b/aa", "a/aa", "c")
+
+	glob, err := New([]string{"**/aa", "x/**"}, nil)
+	require.NoError(t, err)
+
+	paths, err := Collect(glob.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/aa", "b/aa"}, paths)
+
+	seq := func(yield func(string, error) bool) {
+		_ = yield("z", nil) && yield("x", fs.ErrPermission) && yield("y", nil) && yield("w", fs.ErrNotExist)
+	}
+	paths, err = CollectSorted(seq)
+	assert.Equal(t, []string{"y", "z"}, paths)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestPaths(t *testing.T) {
+	seq := func(yield func(string, error) bool) {
+		_ = yield("a", nil) && yield("b", fs.ErrPermission) && yield("c", nil)
+	}
+
+	paths, errs := Paths(seq)
+	assert.Equal(t, []string{"a", "c"}, slices.Collect(paths))
+	assert.ErrorIs(t, errs(), fs.ErrPermission)
+
+	for p := range paths {
+		assert.Equal(t, "a", p)
+		break
+	}
+	assert.NoError(t, errs())
+}
+
+func TestCollectSortedFunc(t *testing.T) {
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"logs/a.log": {Data: []byte("aaa"), ModTime: now.Add(-1 * time.Hour)},
+		"logs/b.log": {Data: []byte("bbbb"), ModTime: now.Add(-3 * time.Hour)},
+		"logs/c.log": {Data: []byte("cc"), ModTime: now.Add(-2 * time.Hour)},
+		"logs/d.log": {Data: []byte("dd"), ModTime: now.Add(-2 * time.Hour)},
+	}
+
+	glob, err := New([]string{"logs/*.log"}, nil)
+	require.NoError(t, err)
+
+	paths, err := CollectSortedFunc(MatchResults(glob, fsys, ".", MatchOptions{}), ByModTime)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/b.log", "logs/c.log", "logs/d.log", "logs/a.log"}, paths)
+
+	paths, err = CollectSortedFunc(MatchResults(glob, fsys, ".", MatchOptions{}), BySize)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/c.log", "logs/d.log", "logs/a.log", "logs/b.log"}, paths)
+
+	entry := func(p string) fs.DirEntry {
+		info, err := fs.Stat(fsys, p)
+		require.NoError(t, err)
+		return fs.FileInfoToDirEntry(info)
+	}
+	results := func(yield func(Result) bool) {
+		_ = yield(Result{Path: "logs/a.log", DirEntry: entry("logs/a.log")}) &&
+			yield(Result{Path: "logs/missing.log", DirEntry: missingEntry{}}) &&
+			yield(Result{Path: "logs", Err: fs.ErrPermission})
+	}
+	paths, err = CollectSortedFunc(results, BySize)
+	assert.Equal(t, []string{"logs/a.log"}, paths)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
+
+// A missingEntry is the directory entry of a file that was removed after its directory was read.
+type missingEntry struct {
+	fs.DirEntry
+}
+
+func (missingEntry) Info() (fs.FileInfo, error) {
+	return nil, fs.ErrNotExist
+}
+
+func TestCollectSortedFuncStats(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"logs/a.log": {Data: []byte("aaa")},
+			"logs/b.log": {Data: []byte("b")},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+	glob, err := New([]string{"logs/*.log"}, nil)
+	require.NoError(t, err)
+
+	paths, err := CollectSortedFunc(MatchResults(glob, fsys, ".", MatchOptions{}), BySize)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/b.log", "logs/a.log"}, paths)
+	top, err := Top(MatchResults(glob, fsys, ".", MatchOptions{}), 1, BySize)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/a.log"}, top)
+
+	// The information for each path comes from the entry read from its directory.
+	assert.Empty(t, fsys.stats)
+}
+
+func TestTop(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := range 100 {
+		fsys[fmt.Sprintf("logs/%02d.log", i)] = &fstest.MapFile{Data: make([]byte, (i*37)%50)}
+	}
+
+	glob, err := New([]string{"**/*.log"}, nil)
+	require.NoError(t, err)
+
+	all, err := CollectSortedFunc(MatchResults(glob, fsys, ".", MatchOptions{}), BySize)
+	require.NoError(t, err)
+	slices.Reverse(all)
+
+	for _, n := range []int{0, 1, 10, 100, 200} {
+		top, err := Top(MatchResults(glob, fsys, ".", MatchOptions{}), n, BySize)
+		require.NoError(t, err)
+		assert.Equal(t, all[:min(n, len(all))], top, "n=%d", n)
+	}
+
+	smallest, err := Top(MatchResults(glob, fsys, ".", MatchOptions{}), 3, func(a, b fs.FileInfo) int { return BySize(b, a) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs/50.log", "logs/00.log", "logs/73.log"}, smallest)
+}
+
+func TestFilterPathsAllocs(t *testing.T) {
+	var input strings.Builder
+	for i := range 1000 {
+		fmt.Fprintf(&input, "src/dir%d/file%d.txt\n", i%10, i)
+	}
+	input.WriteString("src/main.go\n")
+
+	filter := func(g Glob) float64 {
+		return testing.AllocsPerRun(10, func() {
+			for _, err := range FilterPaths(g, strings.NewReader(input.String()), '\n') {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// Paths are matched in place in the scanner's buffer, so a glob created by New that rejects paths without
+	// allocating costs a fixed number of allocations. Paths given to other globs are copied first.
+	none, err := New(nil, nil)
+	require.NoError(t, err)
+	assert.Less(t, filter(none), 20.0)
+	assert.GreaterOrEqual(t, filter(foreignGlob{none}), 1000.0)
+}
+
+func BenchmarkFilterPaths(b *testing.B) {
+	var input strings.Builder
+	for i := range 10000 {
+		fmt.Fprintf(&input, "src/dir%d/file%d.txt\n", i%10, i)
+	}
+	g, err := New([]string{"src/**/*.go"}, nil)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, err := range FilterPaths(g, strings.NewReader(input.String()), '\n') {
+			require.NoError(b, err)
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/b.txt":     {},
+		"a/c/d.txt":   {},
+		"a/c/e/f.txt": {},
+	}
+
+	glob, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+
+	expected := map[string]map[string]int{
+		".":   {".": 0, "a": 1, "a/b.txt": 2, "a/c": 2, "a/c/d.txt": 3, "a/c/e": 3, "a/c/e/f.txt": 4},
+		"a":   {"a": 0, "a/b.txt": 1, "a/c": 1, "a/c/d.txt": 2, "a/c/e": 2, "a/c/e/f.txt": 3},
+		"a/c": {"a/c": 0, "a/c/d.txt": 1, "a/c/e": 1, "a/c/e/f.txt": 2},
+	}
+	for dir, want := range expected {
+		depths := map[string]int{}
+		for p, err := range MatchWith(glob, fsys, dir, MatchOptions{Dirs: DirsPre, IncludeRoot: true}) {
+			require.NoError(t, err)
+			depths[p] = Depth(dir, p)
+		}
+		assert.Equal(t, want, depths, "dir=%v", dir)
+	}
+
+	assert.Equal(t, 2, Depth("/", "/a/b"))
+	assert.Equal(t, 1, Depth("a/", "a/b"))
+
+	// Paths outside of the directory have no depth.
+	assert.Equal(t, -1, Depth("a/b", "c/d"))
+	assert.Equal(t, -1, Depth("foo", "foobar/x"))
+	assert.Equal(t, -1, Depth(".", "../x"))
+	assert.Equal(t, -1, Depth("/", "a"))
+}
+
+func TestGroupByDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":         {},
+		"a/x.go":       {},
+		"a/b/y.go":     {},
+		"a/b/c/z.go":   {},
+		"a/b2/w.go":    {},
+		"a/z.go":       {},
+		"d/e.go":       {},
+		"d/readme.txt": {},
+	}
+	g, err := New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	groups, errs := GroupByDir(g.Match(fsys, ".", false))
+	var actual []string
+	for dir, paths := range groups {
+		actual = append(actual, dir+": "+strings.Join(paths, " "))
+	}
+	require.NoError(t, errs())
+	assert.Equal(t, []string{
+		"a/b/c: a/b/c/z.go",
+		"a/b: a/b/y.go",
+		"a/b2: a/b2/w.go",
+		"a: a/x.go a/z.go",
+		"d: d/e.go",
+		".: a.go",
+	}, actual)
+
+	seq := func(yield func(string, error) bool) {
+		_ = yield("x/a", nil) && yield("x", fs.ErrPermission) && yield("x/b", nil) && yield("y/c", nil)
+	}
+	groups, errs = GroupByDir(seq)
+	for dir, paths := range groups {
+		assert.Equal(t, "x", dir)
+		assert.Equal(t, []string{"x/a", "x/b"}, paths)
+		break
+	}
+	assert.ErrorIs(t, errs(), fs.ErrPermission)
+}
+
+func TestFilterPaths(t *testing.T) {
+	g, err := New([]string{"**/*.go"}, []string{"vendor"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		input string
+		delim byte
+	}{
+		{"a.go\nb.txt\nvendor/c.go\n\nd/e.go", '\n'},
+		{"a.go\r\nb.txt\r\nvendor/c.go\r\nd/e.go\r\n", '\n'},
+		{"a.go\x00b.txt\x00vendor/c.go\x00d/e.go\x00", 0},
+	}
+	for _, c := range cases {
+		paths, err := Collect(FilterPaths(g, strings.NewReader(c.input), c.delim))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go", "d/e.go"}, paths, "%q", c.input)
+	}
+
+	// A path may contain newlines if the list is NUL-delimited.
+	paths, err := Collect(FilterPaths(g, strings.NewReader("a\nb.go\x00c.txt"), 0))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a\nb.go"}, paths)
+
+	errRead := errors.New("read failed")
+	paths, err = Collect(FilterPaths(g, io.MultiReader(strings.NewReader("a.go\n"), iotest.ErrReader(errRead)), '\n'))
+	assert.Equal(t, []string{"a.go"}, paths)
+	assert.ErrorIs(t, err, errRead)
+}
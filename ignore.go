@@ -0,0 +1,104 @@
+package glob
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// A Rule is a single pattern read from an ignore file.
+type Rule struct {
+	// Pattern is the pattern text.
+	Pattern string
+	// Negate is true if the pattern was prefixed with '!'.
+	Negate bool
+	// Line is the 1-based line number of the pattern in its file.
+	Line int
+}
+
+// ParseIgnoreFile reads an ordered list of rules from r. Each line of the input holds a single pattern:
+//
+//   - Blank lines and lines that begin with '#' are ignored. A pattern that begins with '#' must be written as '\#'.
+//   - A line that begins with '!' is a negated rule. A pattern that begins with '!' must be written as '\!'.
+//   - Trailing spaces are removed unless they are escaped with a backslash.
+//
+// Escapes are preserved in the returned patterns, which use the syntax accepted by New.
+func ParseIgnoreFile(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := trimTrailingSpace(strings.TrimSuffix(scanner.Text(), "\r"))
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		rule := Rule{Pattern: text, Line: line}
+		if text[0] == '!' {
+			rule.Pattern, rule.Negate = text[1:], true
+		}
+		if rule.Pattern != "" {
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ParseIgnoreFileFS reads an ordered list of rules from the named file in fsys. See ParseIgnoreFile for the syntax.
+func ParseIgnoreFileFS(fsys fs.FS, name string) ([]Rule, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rules, err := ParseIgnoreFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	return rules, nil
+}
+
+// RulePatterns converts a list of rules into the include and exclude patterns accepted by New. Rules that are not
+// negated are includes; negated rules are excludes. As in an ignore file, the last rule that matches a path decides
+// whether it is matched, so a rule that is not negated and follows a negated rule is also added to the excludes as a
+// '!'-prefixed pattern that re-includes the paths that earlier excludes remove. For example, the rules "*.log",
+// "!keep.log", and "keep.log" match keep.log.
+func RulePatterns(rules []Rule) (includes, excludes []string) {
+	for _, r := range rules {
+		switch {
+		case r.Negate && strings.HasPrefix(r.Pattern, "!"):
+			// A leading '!' in an exclude pattern negates it, so a literal '!' must be escaped.
+			excludes = append(excludes, `\`+r.Pattern)
+		case r.Negate:
+			excludes = append(excludes, r.Pattern)
+		default:
+			includes = append(includes, r.Pattern)
+			if len(excludes) != 0 {
+				excludes = append(excludes, "!"+r.Pattern)
+			}
+		}
+	}
+	return includes, excludes
+}
+
+// trimTrailingSpace removes unescaped trailing spaces from s.
+func trimTrailingSpace(s string) string {
+	end := len(s)
+	for end > 0 && s[end-1] == ' ' {
+		// Count the backslashes that precede the space. If there are an odd number, the space is escaped.
+		n := 0
+		for i := end - 2; i >= 0 && s[i] == '\\'; i-- {
+			n++
+		}
+		if n%2 == 1 {
+			break
+		}
+		end--
+	}
+	return s[:end]
+}
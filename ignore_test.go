@@ -0,0 +1,75 @@
+package glob
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIgnoreFile(t *testing.T) {
+	const input = "# comment\n" +
+		"\n" +
+		"*.go\n" +
+		"!vendor/**\r\n" +
+		"trailing   \n" +
+		"escaped\\ \n" +
+		"escaped\\\\  \n" +
+		"\\#hash\n" +
+		"\\!bang\n" +
+		"!\n" +
+		"  indented\n"
+
+	rules, err := ParseIgnoreFile(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Pattern: "*.go", Line: 3},
+		{Pattern: "vendor/**", Negate: true, Line: 4},
+		{Pattern: "trailing", Line: 5},
+		{Pattern: "escaped\\ ", Line: 6},
+		{Pattern: "escaped\\\\", Line: 7},
+		{Pattern: "\\#hash", Line: 8},
+		{Pattern: "\\!bang", Line: 9},
+		{Pattern: "  indented", Line: 11},
+	}, rules)
+
+	includes, excludes := RulePatterns(rules)
+	assert.Equal(t, []string{"*.go", "trailing", "escaped\\ ", "escaped\\\\", "\\#hash", "\\!bang", "  indented"}, includes)
+	assert.Equal(t, []string{"vendor/**", "!trailing", "!escaped\\ ", "!escaped\\\\", "!\\#hash", "!\\!bang", "!  indented"}, excludes)
+
+	g, err := New(includes, excludes)
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("#hash"))
+	assert.True(t, g.MatchPath("!bang"))
+	assert.True(t, g.MatchPath("escaped "))
+}
+
+func TestRulePatternsOrder(t *testing.T) {
+	rules, err := ParseIgnoreFile(strings.NewReader("*.log\n!keep.log\nkeep.log\n!tmp/**\n!!bang.log\n"))
+	require.NoError(t, err)
+
+	includes, excludes := RulePatterns(rules)
+	assert.Equal(t, []string{"*.log", "keep.log"}, includes)
+	assert.Equal(t, []string{"keep.log", "!keep.log", "tmp/**", "\\!bang.log"}, excludes)
+
+	// The last rule that matches a path decides whether it is matched.
+	g, err := New(includes, excludes)
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("a.log"))
+	assert.True(t, g.MatchPath("keep.log"))
+	assert.False(t, g.MatchPath("tmp/keep.log"))
+	assert.False(t, g.MatchPath("!bang.log"))
+}
+
+func TestParseIgnoreFileFS(t *testing.T) {
+	fsys := fstest.MapFS{".globignore": {Data: []byte("*.go\n!*_test.go\n")}}
+
+	rules, err := ParseIgnoreFileFS(fsys, ".globignore")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Pattern: "*.go", Line: 1}, {Pattern: "*_test.go", Negate: true, Line: 2}}, rules)
+
+	_, err = ParseIgnoreFileFS(fsys, "missing")
+	assert.Error(t, err)
+}
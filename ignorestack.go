@@ -0,0 +1,119 @@
+package glob
+
+import "errors"
+
+// An IgnoreStack evaluates the ignore rules that apply to the entries of a directory as a walker descends a tree. The
+// walker pushes a directory's rules, read from whatever source it likes, when it enters the directory and pops them
+// when it leaves; the stack carries the rules of each ancestor forward, so a query costs no more than matching a name
+// against the rules that may still match beneath the current directory.
+//
+// Rules are exclude patterns, as passed to New, that are matched relative to the directory that pushed them. A rule
+// that matches a directory matches the directory's contents. Negated rules re-include the paths they match, and the
+// last rule that matches a path or one of its ancestors decides whether the path is ignored, where the rules of a
+// directory follow the rules of its ancestors. An IgnoreStack is not safe for concurrent use.
+type IgnoreStack struct {
+	options Options
+	frames  []ignoreFrame
+	scratch []pattern
+}
+
+// An ignoreFrame holds the state of a directory on an IgnoreStack.
+type ignoreFrame struct {
+	// patterns are the rules that may match the directory's entries, and index indexes them by name.
+	patterns []pattern
+	index    patternIndex
+	// e records the rule that last matched the directory or one of its ancestors.
+	e exclusion
+	// rules is the number of rules pushed by the directory and its ancestors.
+	rules int
+}
+
+// NewIgnoreStack creates an IgnoreStack for a walk of a directory tree. The given rules apply to the starting
+// directory, which is the current directory of the stack, and are compiled with the given options. If any rule is
+// malformed, the errors are returned as a list of *SyntaxError errors alongside a stack that holds the remaining rules.
+func NewIgnoreStack(rules []Rule, options Options) (*IgnoreStack, error) {
+	s := &IgnoreStack{options: options}
+	s.frames = []ignoreFrame{{}}
+	err := s.addRules(&s.frames[0], rules)
+	return s, err
+}
+
+// addRules compiles the given rules and adds them to f.
+func (s *IgnoreStack) addRules(f *ignoreFrame, rules []Rule) error {
+	var errs []error
+	for i, r := range rules {
+		start := len(f.patterns)
+		if err := newPattern(r.Pattern, s.options, &f.patterns); err != nil {
+			errs = append(errs, locateError(err, r.Pattern, false, i))
+			continue
+		}
+		f.rules++
+		for _, p := range f.patterns[start:] {
+			for j := range p {
+				p[j].rule, p[j].negate = f.rules, r.Negate
+			}
+		}
+	}
+	f.index = newPatternIndex(f.patterns)
+	return errors.Join(errs...)
+}
+
+// Push enters the named subdirectory of the current directory and adds the given rules, which apply to the
+// subdirectory. Each call to Push must be paired with a call to Pop. If any rule is malformed, the subdirectory is still
+// entered, and the errors are returned as a list of *SyntaxError errors.
+func (s *IgnoreStack) Push(name string, rules []Rule) error {
+	top := &s.frames[len(s.frames)-1]
+	next := ignoreFrame{e: top.e, rules: top.rules}
+	for p := range top.index.candidates(name) {
+		if p.matchDir(name, &next.patterns) {
+			next.e = next.e.update(p)
+		}
+	}
+	err := s.addRules(&next, rules)
+	s.frames = append(s.frames, next)
+	return err
+}
+
+// Pop leaves the current directory, returning to its parent. Pop panics if the current directory is the starting
+// directory.
+func (s *IgnoreStack) Pop() {
+	if len(s.frames) == 1 {
+		panic("glob: Pop of the starting directory of an IgnoreStack")
+	}
+	s.frames[len(s.frames)-1] = ignoreFrame{}
+	s.frames = s.frames[:len(s.frames)-1]
+}
+
+// Depth returns the number of directories that have been pushed and not popped.
+func (s *IgnoreStack) Depth() int {
+	return len(s.frames) - 1
+}
+
+// MatchFile reports whether the named file in the current directory is ignored.
+func (s *IgnoreStack) MatchFile(name string) bool {
+	top := &s.frames[len(s.frames)-1]
+	e := top.e
+	for p := range top.index.candidates(name) {
+		if p.matchFile(name) {
+			e = e.update(p)
+		}
+	}
+	return e.excluded()
+}
+
+// MatchDir reports whether the named subdirectory of the current directory is ignored. If prune is true, every path
+// beneath the subdirectory is ignored by the rules pushed so far, so a walker that does not read rules from ignored
+// directories need not descend into it; otherwise, a negated rule may re-include some of its contents.
+func (s *IgnoreStack) MatchDir(name string) (ignored, prune bool) {
+	top := &s.frames[len(s.frames)-1]
+	e, next := top.e, s.scratch[:0]
+	for p := range top.index.candidates(name) {
+		if p.matchDir(name, &next) {
+			e = e.update(p)
+		}
+	}
+	prune = e.final(next) || excludesAll(e, next)
+	clear(next)
+	s.scratch = next[:0]
+	return e.excluded(), prune
+}
@@ -0,0 +1,112 @@
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walkIgnoring walks fsys, reading rules from a file named ".rules" in each directory, and returns the paths that are
+// not ignored.
+func walkIgnoring(t *testing.T, fsys fs.FS, root []Rule, options Options) []string {
+	s, err := NewIgnoreStack(root, options)
+	require.NoError(t, err)
+
+	var paths []string
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := fs.ReadDir(fsys, dir)
+		require.NoError(t, err)
+		for _, e := range entries {
+			p := path.Join(dir, e.Name())
+			if !e.IsDir() {
+				if e.Name() != ".rules" && !s.MatchFile(e.Name()) {
+					paths = append(paths, p)
+				}
+				continue
+			}
+
+			ignored, prune := s.MatchDir(e.Name())
+			if prune {
+				continue
+			}
+			if !ignored {
+				paths = append(paths, p+"/")
+			}
+
+			rules, err := ParseIgnoreFileFS(fsys, path.Join(p, ".rules"))
+			if err != nil {
+				require.ErrorIs(t, err, fs.ErrNotExist)
+			}
+			require.NoError(t, s.Push(e.Name(), rules))
+			walk(p)
+			s.Pop()
+		}
+	}
+	walk(".")
+	assert.Equal(t, 0, s.Depth())
+	return paths
+}
+
+func TestIgnoreStack(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.log":              {},
+		"main.go":            {},
+		"build/out.bin":      {},
+		"src/.rules":         {Data: []byte("*.tmp\n!keep.log\n")},
+		"src/keep.log":       {},
+		"src/x.tmp":          {},
+		"src/lib/y.tmp":      {},
+		"src/lib/lib.go":     {},
+		"docs/.rules":        {Data: []byte("drafts\n")},
+		"docs/drafts/a.md":   {},
+		"docs/guide.md":      {},
+		"dist/app.js":        {},
+		"dist/manifest.json": {},
+	}
+	root := []Rule{{Pattern: "*.log"}, {Pattern: "build"}, {Pattern: "dist/**"}, {Pattern: "dist/manifest.json", Negate: true}, {Pattern: "**/*.tmp"}}
+
+	assert.Equal(t, []string{
+		"dist/",
+		"dist/manifest.json",
+		"docs/",
+		"docs/guide.md",
+		"main.go",
+		"src/",
+		"src/keep.log",
+		"src/lib/",
+		"src/lib/lib.go",
+	}, walkIgnoring(t, fsys, root, Options{}))
+}
+
+func TestIgnoreStackOptions(t *testing.T) {
+	s, err := NewIgnoreStack([]Rule{{Pattern: "*.LOG"}}, Options{CaseInsensitive: true, Basename: true})
+	require.NoError(t, err)
+	require.NoError(t, s.Push("a", nil))
+	require.NoError(t, s.Push("b", []Rule{{Pattern: "x.log", Negate: true}}))
+	assert.True(t, s.MatchFile("y.log"))
+	assert.False(t, s.MatchFile("X.LOG"))
+	s.Pop()
+	assert.True(t, s.MatchFile("x.log"))
+	s.Pop()
+	assert.Panics(t, s.Pop)
+}
+
+func TestIgnoreStackErrors(t *testing.T) {
+	s, err := NewIgnoreStack([]Rule{{Pattern: "["}, {Pattern: "*.log"}}, Options{})
+	var syntaxErr *SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+	assert.True(t, s.MatchFile("a.log"))
+
+	err = s.Push("dir", []Rule{{Pattern: "(?x)"}, {Pattern: "*.txt"}})
+	require.True(t, errors.As(err, &syntaxErr))
+	assert.True(t, strings.Contains(err.Error(), "unknown flag"))
+	assert.Equal(t, 1, s.Depth())
+	assert.True(t, s.MatchFile("a.txt"))
+}
@@ -0,0 +1,66 @@
+package glob
+
+import "iter"
+
+// indexThreshold is the number of patterns above which a list of patterns is indexed by the literal text of their
+// first steps.
+const indexThreshold = 8
+
+// A patternIndex organizes a list of patterns so that the patterns whose first steps may match a particular name can
+// be found without testing every pattern.
+//
+// Patterns whose first steps are literals are grouped by their literal text; the remaining patterns must be tested
+// against every name. Small lists are not indexed. The literal text of a case-insensitive step is case-folded, so
+// such patterns are kept separately and looked up by folded name.
+type patternIndex struct {
+	literals map[string][]pattern
+	folded   map[string][]pattern
+	others   []pattern
+}
+
+// newPatternIndex creates an index for the given patterns.
+func newPatternIndex(patterns []pattern) patternIndex {
+	if len(patterns) <= indexThreshold {
+		return patternIndex{others: patterns}
+	}
+
+	index := patternIndex{literals: map[string][]pattern{}, folded: map[string][]pattern{}}
+	for _, p := range patterns {
+		m := p[0].matcher
+		switch {
+		case m == nil || m.kind != segmentLiteral || m.fold && m.bytes || m.transform != nil:
+			index.others = append(index.others, p)
+		case m.fold:
+			index.folded[m.literal] = append(index.folded[m.literal], p)
+		default:
+			index.literals[m.literal] = append(index.literals[m.literal], p)
+		}
+	}
+	return index
+}
+
+// candidates returns the patterns whose first steps may match name. The sequence is a single function literal so that
+// it can be inlined into the loops that range over it, which keeps matching free of allocations.
+func (x patternIndex) candidates(name string) iter.Seq[pattern] {
+	return func(yield func(pattern) bool) {
+		if x.literals != nil {
+			for _, p := range x.literals[name] {
+				if !yield(p) {
+					return
+				}
+			}
+			if len(x.folded) != 0 {
+				for _, p := range x.folded[foldString(name)] {
+					if !yield(p) {
+						return
+					}
+				}
+			}
+		}
+		for _, p := range x.others {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package glob
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Infer proposes patterns that generalize a set of example paths. Each proposed pattern matches every path in
+// positive and none of the paths in negative. The patterns are listed from the most specific to the most general, and
+// each is a valid pattern for New.
+//
+// The proposals are formed by generalizing the names at each depth of the examples, by replacing the directories
+// beneath their common ancestor with '**', and by matching names by their common prefix, suffix, or extension. They are
+// suggestions rather than a minimal description of the examples: if the examples share no structure, Infer may propose
+// nothing. Paths are slash-separated and cleaned before they are generalized.
+func Infer(positive, negative []string) []string {
+	var paths [][]string
+	for _, p := range positive {
+		if names := splitPath(p); len(names) != 0 {
+			paths = append(paths, names)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	var candidates []string
+	add := func(parts ...string) {
+		candidates = append(candidates, path.Join(parts...))
+	}
+
+	// Generalize the names at each depth if every example is equally deep.
+	if depth := len(paths[0]); !slices.ContainsFunc(paths, func(names []string) bool { return len(names) != depth }) {
+		columns := make([][]string, depth)
+		for _, names := range paths {
+			for i, name := range names {
+				columns[i] = append(columns[i], name)
+			}
+		}
+		steps := make([]string, depth)
+		for i, column := range columns {
+			steps[i] = inferName(column)[0]
+		}
+		add(steps...)
+	}
+
+	// Replace the directories beneath the examples' common ancestor with '**'.
+	dirs := paths[0][:len(paths[0])-1]
+	for _, names := range paths[1:] {
+		dirs = dirs[:commonNames(dirs, names[:len(names)-1])]
+	}
+	bases := make([]string, len(paths))
+	for i, names := range paths {
+		bases[i] = names[len(names)-1]
+	}
+	baseCandidates := inferName(bases)
+	ancestor := make([]string, len(dirs))
+	for i, name := range dirs {
+		ancestor[i] = escapeName(name)
+	}
+	for _, base := range baseCandidates {
+		add(append(slices.Clone(ancestor), "**", base)...)
+	}
+	if len(ancestor) != 0 {
+		for _, base := range baseCandidates {
+			add("**", base)
+		}
+	}
+
+	var proposals []string
+	for _, c := range candidates {
+		if slices.Contains(proposals, c) {
+			continue
+		}
+		g, err := New([]string{c}, nil)
+		if err != nil {
+			continue
+		}
+		if !slices.ContainsFunc(positive, func(p string) bool { return !g.MatchPath(p) }) &&
+			!slices.ContainsFunc(negative, g.MatchPath) {
+			proposals = append(proposals, c)
+		}
+	}
+	return proposals
+}
+
+// inferName returns pattern elements that match each of the given names, from the most specific to the most general.
+// The last element is always '*'.
+func inferName(names []string) []string {
+	if !slices.ContainsFunc(names, func(name string) bool { return name != names[0] }) {
+		return []string{escapeName(names[0]), "*"}
+	}
+
+	// Find the longest prefix and suffix shared by every name that do not overlap within any name.
+	prefix, suffix := names[0], names[0]
+	shortest := len(names[0])
+	for _, name := range names[1:] {
+		prefix = prefix[:commonPrefixLen(prefix, name)]
+		suffix = suffix[len(suffix)-commonSuffixLen(suffix, name):]
+		shortest = min(shortest, len(name))
+	}
+	if len(prefix)+len(suffix) > shortest {
+		suffix = suffix[len(prefix)+len(suffix)-shortest:]
+	}
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	for !utf8.ValidString(suffix) {
+		suffix = suffix[1:]
+	}
+
+	var elements []string
+	if prefix != "" || suffix != "" {
+		elements = append(elements, escapeName(prefix)+"*"+escapeName(suffix))
+	}
+
+	// Match by extension alone if the names share one.
+	if ext := path.Ext(names[0]); ext != "" && ext != names[0] {
+		if !slices.ContainsFunc(names, func(name string) bool { return path.Ext(name) != ext }) {
+			elements = append(elements, "*"+escapeName(ext))
+		}
+	}
+	return slices.Compact(append(elements, "*"))
+}
+
+// commonNames returns the number of leading names shared by a and b.
+func commonNames(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// commonSuffixLen returns the length of the longest common suffix of a and b.
+func commonSuffixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// escapeName escapes the metacharacters in a name so that it matches itself.
+func escapeName(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		b.WriteString(escapeChar(c))
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfer(t *testing.T) {
+	cases := []struct {
+		name               string
+		positive, negative []string
+		expected           []string
+	}{
+		{
+			name:     "single",
+			positive: []string{"src/main.go"},
+			expected: []string{"src/main.go", "src/**/main.go", "src/**/*", "**/main.go", "**/*"},
+		},
+		{
+			name:     "same directory",
+			positive: []string{"src/a.go", "src/b.go"},
+			expected: []string{"src/*.go", "src/**/*.go", "src/**/*", "**/*.go", "**/*"},
+		},
+		{
+			name:     "different depths",
+			positive: []string{"src/a_test.go", "src/lib/b_test.go"},
+			expected: []string{"src/**/*_test.go", "src/**/*.go", "src/**/*", "**/*_test.go", "**/*.go", "**/*"},
+		},
+		{
+			name:     "negative",
+			positive: []string{"src/a_test.go", "src/lib/b_test.go"},
+			negative: []string{"src/c.go", "docs/x_test.go"},
+			expected: []string{"src/**/*_test.go"},
+		},
+		{
+			name:     "columns",
+			positive: []string{"services/auth/migrations/001.sql", "services/billing/migrations/002.sql"},
+			expected: []string{
+				"services/*/migrations/00*.sql",
+				"services/**/00*.sql",
+				"services/**/*.sql",
+				"services/**/*",
+				"**/00*.sql",
+				"**/*.sql",
+				"**/*",
+			},
+		},
+		{
+			name:     "metacharacters",
+			positive: []string{"a[1].txt", "a[2].txt"},
+			expected: []string{`a\[*].txt`, `**/a\[*].txt`, "**/*.txt", "**/*"},
+		},
+		{
+			name:     "unicode",
+			positive: []string{"é1", "è2"},
+			expected: []string{"*", "**/*"},
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, Infer(c.positive, c.negative))
+		})
+	}
+}
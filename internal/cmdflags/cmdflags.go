@@ -0,0 +1,44 @@
+// Package cmdflags provides the flag types and pattern file handling shared by the commands in this module.
+package cmdflags
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pgavlin/glob"
+)
+
+// Strings is a flag that may be repeated.
+type Strings []string
+
+func (s *Strings) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *Strings) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// ReadPatternFile reads include and exclude patterns from the named file in the syntax accepted by
+// glob.ParseIgnoreFile. A name of "-" reads the patterns from stdin.
+func ReadPatternFile(name string, stdin io.Reader) (includes, excludes []string, err error) {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	rules, err := glob.ParseIgnoreFile(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: %w", name, err)
+	}
+	includes, excludes = glob.RulePatterns(rules)
+	return includes, excludes, nil
+}
@@ -0,0 +1,40 @@
+package cmdflags
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrings(t *testing.T) {
+	var s Strings
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(&s, "x", "")
+	require.NoError(t, flags.Parse([]string{"-x", "a", "-x", "b"}))
+	assert.Equal(t, Strings{"a", "b"}, s)
+	assert.Equal(t, "a,b", s.String())
+}
+
+func TestReadPatternFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "patterns")
+	require.NoError(t, os.WriteFile(name, []byte("# comment\n*.go\n\n!*_test.go\n"), 0o644))
+
+	includes, excludes, err := ReadPatternFile(name, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.go"}, includes)
+	assert.Equal(t, []string{"*_test.go"}, excludes)
+
+	includes, excludes, err = ReadPatternFile("-", strings.NewReader("docs/**\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/**"}, includes)
+	assert.Empty(t, excludes)
+
+	_, _, err = ReadPatternFile(filepath.Join(t.TempDir(), "missing"), nil)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
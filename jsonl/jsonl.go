@@ -0,0 +1,102 @@
+// Package jsonl encodes the results of a glob match as JSON Lines: one JSON object per line, suitable for consumption
+// by other tools in a pipeline.
+//
+// Each record describes either a matching path or an error:
+//
+//	{"path":"src/main.go","type":"file","size":1234}
+//	{"path":"src/lib","type":"dir"}
+//	{"path":"src/private","error":"open src/private: permission denied"}
+package jsonl
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"iter"
+
+	"github.com/pgavlin/glob"
+)
+
+// A Record is the JSON form of a single match result.
+type Record struct {
+	// Path is the matching path, or the path associated with Error.
+	Path string `json:"path"`
+	// Type is the type of the file at Path: "file", "dir", "symlink", or "other". It is empty for error records.
+	Type string `json:"type,omitempty"`
+	// Size is the size of a regular file in bytes. It is only set if the encoder's Size option is set.
+	Size *int64 `json:"size,omitempty"`
+	// Error is the error encountered for Path, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Options controls the contents of the records written by an Encoder.
+type Options struct {
+	// Size adds the size of each regular file to its record. Fetching a file's size may require a filesystem
+	// operation; if it fails, the record describes the error instead.
+	Size bool
+}
+
+// An Encoder writes match results to an output stream as JSON Lines.
+type Encoder struct {
+	enc     *json.Encoder
+	options Options
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer, options Options) *Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Encoder{enc: enc, options: options}
+}
+
+// Encode writes the record for the given result to the stream, followed by a newline.
+func (e *Encoder) Encode(r glob.Result) error {
+	return e.enc.Encode(e.record(r))
+}
+
+// EncodeAll writes the record for each result in the given sequence to the stream. EncodeAll stops at the first error
+// writing to the stream and returns it; errors in the sequence are encoded as records.
+func (e *Encoder) EncodeAll(results iter.Seq[glob.Result]) error {
+	for r := range results {
+		if err := e.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record returns the record for the given result.
+func (e *Encoder) record(r glob.Result) Record {
+	if r.Err != nil {
+		return Record{Path: r.Path, Error: r.Err.Error()}
+	}
+
+	rec := Record{Path: r.Path}
+	if r.DirEntry == nil {
+		return rec
+	}
+	rec.Type = typeName(r.DirEntry.Type())
+	if e.options.Size && r.DirEntry.Type().IsRegular() {
+		info, err := r.DirEntry.Info()
+		if err != nil {
+			return Record{Path: r.Path, Error: err.Error()}
+		}
+		size := info.Size()
+		rec.Size = &size
+	}
+	return rec
+}
+
+// typeName returns the name of the given file type.
+func typeName(t fs.FileMode) string {
+	switch {
+	case t.IsRegular():
+		return "file"
+	case t.IsDir():
+		return "dir"
+	case t&fs.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
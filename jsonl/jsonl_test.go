@@ -0,0 +1,66 @@
+package jsonl
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pgavlin/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":   {Data: []byte("package main")},
+		"src/lib/a.go":  {Data: []byte("package lib")},
+		"src/link":      {Mode: fs.ModeSymlink, Data: []byte("lib")},
+		"docs/<a>.md":   {Data: []byte("# a")},
+		"docs/readme.g": {},
+	}
+	g, err := glob.New([]string{"**"}, nil)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	err = NewEncoder(&b, Options{}).EncodeAll(glob.MatchResults(g, fsys, ".", glob.MatchOptions{Dirs: glob.DirsPre}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"path":"docs","type":"dir"}
+{"path":"docs/<a>.md","type":"file"}
+{"path":"docs/readme.g","type":"file"}
+{"path":"src","type":"dir"}
+{"path":"src/lib","type":"dir"}
+{"path":"src/lib/a.go","type":"file"}
+{"path":"src/link","type":"symlink"}
+{"path":"src/main.go","type":"file"}
+`, b.String())
+
+	b.Reset()
+	err = NewEncoder(&b, Options{Size: true}).EncodeAll(glob.MatchResults(g, fsys, "src", glob.MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"path":"src/lib/a.go","type":"file","size":11}
+{"path":"src/link","type":"symlink"}
+{"path":"src/main.go","type":"file","size":12}
+`, b.String())
+}
+
+func TestEncoderErrors(t *testing.T) {
+	var b bytes.Buffer
+	err := NewEncoder(&b, Options{}).Encode(glob.Result{Path: "private", Err: errors.New("permission denied")})
+	require.NoError(t, err)
+	assert.Equal(t, `{"path":"private","error":"permission denied"}`+"\n", b.String())
+
+	errWrite := errors.New("write failed")
+	err = NewEncoder(failWriter{errWrite}, Options{}).Encode(glob.Result{Path: "a"})
+	assert.ErrorIs(t, err, errWrite)
+}
+
+// failWriter is a writer whose writes fail.
+type failWriter struct {
+	err error
+}
+
+func (w failWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
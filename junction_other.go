@@ -0,0 +1,11 @@
+//go:build !windows
+
+package glob
+
+import "io/fs"
+
+// junctionPolicy returns the filesystem for the operating system directory root and no descent filter. Junctions do
+// not exist on this platform.
+func junctionPolicy(root string, mode JunctionMode) (fs.FS, func(string, fs.DirEntry) bool) {
+	return osFS(root), nil
+}
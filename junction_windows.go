@@ -0,0 +1,170 @@
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"syscall"
+)
+
+// ioReparseTagMountPoint is the reparse tag of junctions and volume mount points.
+const ioReparseTagMountPoint = 0xA0000003
+
+// junctionPolicy returns the filesystem for the operating system directory root, which applies the given junction
+// mode to the entries of the directories it reads, and a descent filter that decides whether the contents of each
+// junction are matched.
+func junctionPolicy(root string, mode JunctionMode) (fs.FS, func(string, fs.DirEntry) bool) {
+	if mode == JunctionDefault {
+		return osFS(root), nil
+	}
+	j := &junctionFS{longPathFS: longPathFS(longPath(root)), root: root, mode: mode}
+	return j, j.descend
+}
+
+// A junctionFS rewrites the junctions in the directories it reads according to a JunctionMode.
+type junctionFS struct {
+	longPathFS
+	root string
+	mode JunctionMode
+
+	// followed holds the targets of the junctions that have been followed.
+	followed []fs.FileInfo
+}
+
+// A junctionEntry is the directory entry of a junction that is treated as a directory.
+type junctionEntry struct {
+	fs.DirEntry
+}
+
+func (junctionEntry) IsDir() bool {
+	return true
+}
+
+func (junctionEntry) Type() fs.FileMode {
+	return fs.ModeDir
+}
+
+// Info returns the information for the junction as a directory, so that its mode agrees with IsDir and Type.
+func (e junctionEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := info.(junctionInfo); ok {
+		return info, nil
+	}
+	return junctionInfo{info}, nil
+}
+
+// A junctionInfo describes a junction that is treated as a directory.
+type junctionInfo struct {
+	fs.FileInfo
+}
+
+func (junctionInfo) IsDir() bool {
+	return true
+}
+
+func (i junctionInfo) Mode() fs.FileMode {
+	return i.FileInfo.Mode()&^fs.ModeType | fs.ModeDir
+}
+
+func (i junctionInfo) dirEntry() fs.DirEntry {
+	return junctionEntry{fs.FileInfoToDirEntry(i)}
+}
+
+// osPath returns the extended-length operating system path of the named file.
+func (j *junctionFS) osPath(name string) string {
+	return longPath(filepath.Join(j.root, filepath.FromSlash(name)))
+}
+
+func (j *junctionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := j.longPathFS.ReadDir(name)
+
+	rewritten := entries[:0]
+	for _, e := range entries {
+		switch {
+		case e.Type()&(fs.ModeSymlink|fs.ModeIrregular) == 0 || !isJunction(j.osPath(path.Join(name, e.Name()))):
+			rewritten = append(rewritten, e)
+		case j.mode != JunctionSkip:
+			rewritten = append(rewritten, junctionEntry{e})
+		}
+	}
+	return rewritten, err
+}
+
+// Stat and Lstat apply the junction mode to the named file as ReadDir does to the entries of its parent directory, so
+// that files looked up by name are treated like files found by reading their directories.
+func (j *junctionFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := j.longPathFS.Stat(name)
+	if err != nil || !info.IsDir() {
+		return info, err
+	}
+	return j.rewrite("stat", name, info)
+}
+
+func (j *junctionFS) Lstat(name string) (fs.FileInfo, error) {
+	info, err := j.longPathFS.Lstat(name)
+	if err != nil || info.Mode()&(fs.ModeSymlink|fs.ModeIrregular) == 0 {
+		return info, err
+	}
+	return j.rewrite("lstat", name, info)
+}
+
+// rewrite returns the information for the named file according to the junction mode.
+func (j *junctionFS) rewrite(op, name string, info fs.FileInfo) (fs.FileInfo, error) {
+	if name == "." || !isJunction(j.osPath(name)) {
+		return info, nil
+	}
+	if j.mode == JunctionSkip {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return junctionInfo{info}, nil
+}
+
+// descend reports whether the contents of the given directory are matched. Only the contents of junctions that are
+// followed without forming a cycle are matched.
+func (j *junctionFS) descend(p string, entry fs.DirEntry) bool {
+	if _, ok := entry.(junctionEntry); !ok {
+		return true
+	}
+	if j.mode != JunctionFollow {
+		return false
+	}
+
+	target, err := os.Stat(j.osPath(p))
+	if err != nil {
+		// Let the traversal report the error when it reads the directory.
+		return true
+	}
+	if slices.ContainsFunc(j.followed, func(info fs.FileInfo) bool { return os.SameFile(info, target) }) {
+		return false
+	}
+	for dir := path.Dir(p); ; dir = path.Dir(dir) {
+		if info, err := os.Stat(j.osPath(dir)); err == nil && os.SameFile(info, target) {
+			return false
+		}
+		if dir == "." {
+			break
+		}
+	}
+	j.followed = append(j.followed, target)
+	return true
+}
+
+// isJunction reports whether the file at the given operating system path is a junction or volume mount point.
+func isJunction(p string) bool {
+	name, err := syscall.UTF16PtrFromString(p)
+	if err != nil {
+		return false
+	}
+	var data syscall.Win32finddata
+	h, err := syscall.FindFirstFile(name, &data)
+	if err != nil {
+		return false
+	}
+	syscall.FindClose(h)
+	return data.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0 && data.Reserved0 == ioReparseTagMountPoint
+}
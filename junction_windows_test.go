@@ -0,0 +1,110 @@
+package glob
+
+import (
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	fxs "github.com/pgavlin/fx/v2/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchOSJunctions(t *testing.T) {
+	root := makeTree(t, "a/x.go", "b/y.go")
+
+	// b/loop refers to its own parent, and c refers to a.
+	for link, target := range map[string]string{`b\loop`: "b", "c": "a"} {
+		out, err := exec.Command("cmd", "/c", "mklink", "/J", filepath.Join(root, link), filepath.Join(root, target)).CombinedOutput()
+		if err != nil {
+			t.Skipf("cannot create junction: %v: %s", err, out)
+		}
+	}
+
+	glob, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+
+	join := func(paths ...string) []string {
+		for i, p := range paths {
+			paths[i] = filepath.Join(root, filepath.FromSlash(p))
+		}
+		return paths
+	}
+	cases := []struct {
+		mode     JunctionMode
+		expected []string
+	}{
+		{JunctionSkip, join("a", "a/x.go", "b", "b/y.go")},
+		{JunctionDir, join("a", "a/x.go", "b", "b/loop", "b/y.go", "c")},
+		{JunctionFollow, join("a", "a/x.go", "b", "b/loop", "b/y.go", "c", "c/x.go")},
+	}
+	for _, c := range cases {
+		options := OSOptions{MatchOptions: MatchOptions{Dirs: DirsPre}, Junctions: c.mode}
+		matches, err := fxs.TryCollect(MatchOS(glob, root, options))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "mode %v", c.mode)
+	}
+}
+
+func TestMatchOSJunctionsLiteral(t *testing.T) {
+	root := makeTree(t, "a/x.go", "b/y.go")
+
+	for link, target := range map[string]string{`b\loop`: "b", "c": "a"} {
+		out, err := exec.Command("cmd", "/c", "mklink", "/J", filepath.Join(root, link), filepath.Join(root, target)).CombinedOutput()
+		if err != nil {
+			t.Skipf("cannot create junction: %v: %s", err, out)
+		}
+	}
+
+	// Literal elements are looked up by name rather than by reading their directories.
+	glob, err := New([]string{"c", "c/x.go", "b/loop/y.go"}, nil)
+	require.NoError(t, err)
+
+	cases := []struct {
+		mode     JunctionMode
+		expected []string
+	}{
+		{JunctionSkip, nil},
+		{JunctionDir, []string{filepath.Join(root, "c")}},
+		{JunctionFollow, []string{filepath.Join(root, "c"), filepath.Join(root, "c", "x.go")}},
+	}
+	for _, c := range cases {
+		options := OSOptions{MatchOptions: MatchOptions{Dirs: DirsPre}, Junctions: c.mode}
+		matches, err := CollectSorted(MatchOS(glob, root, options))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "mode %v", c.mode)
+	}
+}
+
+func TestJunctionEntryInfo(t *testing.T) {
+	root := makeTree(t, "a/x.go")
+	out, err := exec.Command("cmd", "/c", "mklink", "/J", filepath.Join(root, "c"), filepath.Join(root, "a")).CombinedOutput()
+	if err != nil {
+		t.Skipf("cannot create junction: %v: %s", err, out)
+	}
+
+	for _, mode := range []JunctionMode{JunctionDir, JunctionFollow} {
+		fsys, _ := junctionPolicy(root, mode)
+		entries, err := fs.ReadDir(fsys, ".")
+		require.NoError(t, err)
+		i := slices.IndexFunc(entries, func(e fs.DirEntry) bool { return e.Name() == "c" })
+		require.NotEqual(t, -1, i)
+
+		// The information for a junction agrees with its directory entry.
+		info, err := entries[i].Info()
+		require.NoError(t, err)
+		assert.True(t, entries[i].IsDir())
+		assert.True(t, info.IsDir())
+		assert.Equal(t, fs.ModeDir, info.Mode().Type())
+
+		info, err = fs.Stat(fsys, "c")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+		entry := infoToDirEntry(info)
+		entryInfo, err := entry.Info()
+		require.NoError(t, err)
+		assert.True(t, entryInfo.IsDir())
+	}
+}
@@ -0,0 +1,240 @@
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pgavlin/fx/v2"
+)
+
+// A Layer is a named set of include and exclude patterns that forms one level of a Layered glob.
+type Layer struct {
+	// Name identifies the layer in decisions, e.g. "global", "repo", or "flags".
+	Name string
+	// Includes lists the layer's include patterns.
+	Includes []string
+	// Excludes lists the layer's exclude patterns. Unlike those passed to New, these patterns may not be negated;
+	// re-include paths using a later layer instead. An exclude pattern that begins with '!' is an error; one that
+	// begins with a literal '!' must escape it.
+	Excludes []string
+	// Options controls how the layer's patterns are compiled, as it does for NewWithOptions. Limits are not enforced.
+	Options Options
+}
+
+// A Decision describes how a Layered glob decided whether to match a path.
+type Decision struct {
+	// Matched is true if the path matches.
+	Matched bool
+	// Layer is the name of the layer that made the decision. If no layer has a pattern that matches the path, Layer is
+	// empty and Matched is false.
+	Layer string
+	// Pattern is the pattern that made the decision.
+	Pattern string
+	// Exclude is true if Pattern is an exclude pattern.
+	Exclude bool
+}
+
+// A sourcePattern is a compiled pattern along with its source text.
+type sourcePattern struct {
+	text     string
+	patterns []pattern
+}
+
+type layer struct {
+	name    string
+	include []sourcePattern
+	exclude []sourcePattern
+}
+
+// A Layered glob evaluates a stack of layers as a single Glob. Later layers take precedence over earlier layers: a
+// path's fate is decided by the last layer that has a pattern that matches the path. Within a layer, excludes take
+// precedence over includes, and an exclude that matches a directory matches the directory's contents.
+//
+// For example, a layer that excludes "vendor" may be followed by a layer that includes "vendor/modules.txt" in order
+// to match that single file.
+type Layered struct {
+	layers []layer
+	union  *matchGlob
+}
+
+// NewLayered creates a Layered glob from the given layers, in order of increasing precedence. If any error is returned,
+// it will be a list of *SyntaxError errors, each of which wraps path.ErrBadPattern.
+func NewLayered(layers ...Layer) (*Layered, error) {
+	var g Layered
+	var includes []pattern
+	var errs []error
+	for _, l := range layers {
+		include, inclErr := newSourcePatterns(l.Includes, false, l.Options)
+		exclude, exclErr := newSourcePatterns(l.Excludes, true, l.Options)
+		errs = append(errs, inclErr, exclErr)
+		g.layers = append(g.layers, layer{name: l.Name, include: include, exclude: exclude})
+		for _, s := range include {
+			includes = append(includes, s.patterns...)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	// Any matching path must be matched by at least one include, so the union of the includes bounds the traversal.
+	g.union = &matchGlob{include: includes, includeIndex: newPatternIndex(includes)}
+	return &g, nil
+}
+
+// NewPrioritized creates a Layered glob from the given groups of patterns, in order of decreasing priority: a path's
+// fate is decided by the first group that has a pattern that matches the path. NewPrioritized(a, b, c) is equivalent to
+// NewLayered(c, b, a).
+func NewPrioritized(groups ...Layer) (*Layered, error) {
+	layers := slices.Clone(groups)
+	slices.Reverse(layers)
+	return NewLayered(layers...)
+}
+
+// newSourcePatterns compiles each of the given include or exclude patterns with the given options.
+func newSourcePatterns(ps []string, exclude bool, options Options) ([]sourcePattern, error) {
+	var patterns []sourcePattern
+	var errs []error
+	for i, p := range ps {
+		s := sourcePattern{text: p}
+		err := error(nil)
+		if exclude && strings.HasPrefix(p, "!") {
+			err = syntaxError(0, 1, "layer exclude patterns may not be negated")
+		} else {
+			err = newPattern(p, options, &s.patterns)
+		}
+		if err != nil {
+			// Layer patterns are never negated, so the error's range needs no adjustment.
+			se := locateError(err, p, false, i)
+			se.Exclude = exclude
+			errs = append(errs, se)
+			continue
+		}
+		patterns = append(patterns, s)
+	}
+	return patterns, errors.Join(errs...)
+}
+
+func (g *Layered) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	return g.MatchWith(fsys, dir, matchOptions(includeDirs))
+}
+
+func (g *Layered) MatchWith(fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
+	dir = path.Clean(dir)
+	return g.union.MatchWith(fsys, dir, g.matchOptions(dir, options))
+}
+
+// matchOptions returns a copy of the given options that omits the paths under dir that g does not match and does not
+// descend into the directories beneath which g matches nothing. Filtering paths during the traversal rather than
+// afterwards ensures that options such as OmitEmptyDirs and Sniff only consider paths that g matches.
+func (g *Layered) matchOptions(dir string, options MatchOptions) MatchOptions {
+	options.filter = func(p string) bool {
+		return g.decide(splitPath(relativePath(dir, p))).Matched
+	}
+	descend := options.descend
+	options.descend = func(p string, entry fs.DirEntry) bool {
+		return (descend == nil || descend(p, entry)) && !g.excludesAll(splitPath(relativePath(dir, p)))
+	}
+	return options
+}
+
+// excludesAll reports whether every path beneath the directory with the given names is excluded: that is, whether a
+// layer excludes the directory and no include pattern in a later layer may match a path beneath it.
+func (g *Layered) excludesAll(names []string) bool {
+	for _, l := range slices.Backward(g.layers) {
+		for _, s := range l.exclude {
+			if matchNames(s.patterns, names, true) {
+				return true
+			}
+		}
+		for _, s := range l.include {
+			if mayContain(s.patterns, names) {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func (g *Layered) MatchPath(p string) bool {
+	names := splitPath(p)
+	return len(names) != 0 && g.decide(names).Matched
+}
+
+// Decide reports whether the given path matches and which layer and pattern made the decision.
+func (g *Layered) Decide(p string) Decision {
+	names := splitPath(p)
+	if len(names) == 0 {
+		return Decision{}
+	}
+	return g.decide(names)
+}
+
+func (g *Layered) decide(names []string) Decision {
+	for _, l := range slices.Backward(g.layers) {
+		for _, s := range l.exclude {
+			if matchNames(s.patterns, names, true) {
+				return Decision{Layer: l.name, Pattern: s.text, Exclude: true}
+			}
+		}
+		for _, s := range l.include {
+			if matchNames(s.patterns, names, false) {
+				return Decision{Matched: true, Layer: l.name, Pattern: s.text}
+			}
+		}
+	}
+	return Decision{}
+}
+
+// splitPath splits a slash-separated path into its names. Empty and '.' elements are dropped, and '..' elements remove
+// the preceding name. If the path refers to a location above its root, splitPath returns nil.
+func splitPath(p string) []string {
+	var names []string
+	for name := range fx.Filter(strings.SplitSeq(p, "/"), func(s string) bool { return s != "" && s != "." }) {
+		if name != ".." {
+			names = append(names, name)
+		} else if len(names) == 0 {
+			return nil
+		} else {
+			names = names[:len(names)-1]
+		}
+	}
+	return names
+}
+
+// relativePath returns the path of p relative to dir, where p was yielded by a match rooted at dir.
+func relativePath(dir, p string) string {
+	switch {
+	case dir == ".":
+		return p
+	case p == dir:
+		return ""
+	default:
+		return strings.TrimPrefix(p, dir+"/")
+	}
+}
+
+// matchNames reports whether any of the given patterns matches names. If prefix is true, matchNames also reports true
+// if a pattern matches a prefix of names. An empty list of names is matched only by '**'.
+func matchNames(patterns []pattern, names []string, prefix bool) bool {
+	if len(names) == 0 {
+		return always(patterns)
+	}
+
+	for i, name := range names {
+		var next []pattern
+		for _, p := range patterns {
+			if p.matchDir(name, &next) && (prefix || i == len(names)-1) {
+				return true
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		patterns = next
+	}
+	return false
+}
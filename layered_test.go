@@ -0,0 +1,119 @@
+package glob
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayered(t *testing.T) {
+	g, err := NewLayered(
+		Layer{Name: "global", Includes: []string{"**/*.go"}, Excludes: []string{"vendor"}},
+		Layer{Name: "repo", Includes: []string{"vendor/modules.txt"}, Excludes: []string{"**/*_test.go"}},
+		Layer{Name: "flags", Includes: []string{"a/a_test.go"}},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, Decision{Matched: true, Layer: "global", Pattern: "**/*.go"}, g.Decide("a/main.go"))
+	assert.Equal(t, Decision{Layer: "global", Pattern: "vendor", Exclude: true}, g.Decide("vendor/x/x.go"))
+	assert.Equal(t, Decision{Matched: true, Layer: "repo", Pattern: "vendor/modules.txt"}, g.Decide("vendor/modules.txt"))
+	assert.Equal(t, Decision{Layer: "repo", Pattern: "**/*_test.go", Exclude: true}, g.Decide("b/b_test.go"))
+	assert.Equal(t, Decision{Matched: true, Layer: "flags", Pattern: "a/a_test.go"}, g.Decide("a/a_test.go"))
+	assert.Equal(t, Decision{}, g.Decide("README.md"))
+	assert.Equal(t, Decision{}, g.Decide(""))
+
+	fsys := fstest.MapFS{
+		"README.md":          {},
+		"a/main.go":          {},
+		"a/a_test.go":        {},
+		"b/b.go":             {},
+		"b/b_test.go":        {},
+		"vendor/modules.txt": {},
+		"vendor/x/x.go":      {},
+	}
+	matches, err := CollectSorted(g.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/a_test.go", "a/main.go", "b/b.go", "vendor/modules.txt"}, matches)
+
+	for _, p := range matches {
+		assert.True(t, g.MatchPath(p), p)
+	}
+	assert.False(t, g.MatchPath("vendor/x/x.go"))
+
+	sub := fstest.MapFS{"src/a/a.go": {}, "src/vendor/v.go": {}}
+	matches, err = CollectSorted(g.Match(sub, "src", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a/a.go"}, matches)
+}
+
+func TestLayeredErrors(t *testing.T) {
+	_, err := NewLayered(Layer{Includes: []string{"["}}, Layer{Excludes: []string{"a/["}})
+	assert.Error(t, err)
+
+	// Layer excludes may not be negated, but may begin with an escaped '!'.
+	_, err = NewLayered(Layer{Includes: []string{"**"}, Excludes: []string{"dist/**", "!dist/keep"}})
+	var se *SyntaxError
+	require.ErrorAs(t, err, &se)
+	assert.True(t, se.Exclude)
+	assert.Equal(t, 1, se.Index)
+	_, err = NewLayered(Layer{Includes: []string{"**"}, Excludes: []string{`\!bang`}})
+	assert.NoError(t, err)
+}
+
+func TestLayeredPruning(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"src/main.go":                     {},
+			"node_modules/a/index.js":         {},
+			"node_modules/b/lib/x.js":         {},
+			"vendor/modules.txt":              {},
+			"vendor/golang.org/x/sys/unix.go": {},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+	g, err := NewLayered(
+		Layer{Includes: []string{"**"}, Excludes: []string{"node_modules", "vendor/**"}},
+		Layer{Includes: []string{"vendor/modules.txt"}},
+	)
+	require.NoError(t, err)
+
+	matches, err := CollectSorted(g.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/main.go", "vendor/modules.txt"}, matches)
+
+	// Excluded subtrees are not read unless a later layer may include a path beneath them.
+	assert.Equal(t, []string{".", "src", "vendor"}, slices.Sorted(maps.Keys(fsys.reads)))
+}
+
+func TestLayeredOptions(t *testing.T) {
+	g, err := NewLayered(
+		Layer{Includes: []string{"**/*.md"}, Options: Options{CaseInsensitive: true}},
+		Layer{Excludes: []string{"*"}, Options: Options{ExplicitDot: true}},
+	)
+	require.NoError(t, err)
+
+	// The second layer's exclude does not match names that begin with '.'.
+	assert.False(t, g.MatchPath("README.MD"))
+	assert.False(t, g.MatchPath("docs/guide.Md"))
+	assert.True(t, g.MatchPath(".github/README.MD"))
+	assert.True(t, g.MatchPath(".docs/guide.Md"))
+	assert.False(t, g.MatchPath(".docs/guide.txt"))
+}
+
+func TestPrioritized(t *testing.T) {
+	g, err := NewPrioritized(
+		Layer{Name: "directory", Excludes: []string{"generated/**"}},
+		Layer{Name: "repo", Includes: []string{"generated/keep.go"}},
+		Layer{Name: "org", Includes: []string{"**/*.go"}},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, Decision{Layer: "directory", Pattern: "generated/**", Exclude: true}, g.Decide("generated/keep.go"))
+	assert.Equal(t, Decision{Matched: true, Layer: "org", Pattern: "**/*.go"}, g.Decide("src/main.go"))
+	assert.Equal(t, Decision{}, g.Decide("README.md"))
+}
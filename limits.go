@@ -0,0 +1,92 @@
+package glob
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Limits bounds the complexity of the patterns accepted by NewWithOptions, so that patterns submitted by untrusted
+// users can be rejected before they are compiled or matched. A limit of zero is not enforced.
+type Limits struct {
+	// MaxPatterns is the maximum number of include and exclude patterns, combined.
+	MaxPatterns int
+	// MaxSegments is the maximum number of '/'-separated elements in a single pattern. Empty elements are not counted.
+	MaxSegments int
+	// MaxSegmentLength is the maximum length of a single pattern element, in bytes.
+	MaxSegmentLength int
+}
+
+// A Limit identifies one of the limits in Limits.
+type Limit int
+
+const (
+	// LimitPatterns is Limits.MaxPatterns.
+	LimitPatterns Limit = iota
+	// LimitSegments is Limits.MaxSegments.
+	LimitSegments
+	// LimitSegmentLength is Limits.MaxSegmentLength.
+	LimitSegmentLength
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LimitPatterns:
+		return "pattern count"
+	case LimitSegments:
+		return "segment count"
+	case LimitSegmentLength:
+		return "segment length"
+	default:
+		return fmt.Sprintf("Limit(%d)", int(l))
+	}
+}
+
+// A LimitError is returned by NewWithOptions when its patterns exceed one of the limits set by Options.Limits.
+type LimitError struct {
+	// Limit is the limit that was exceeded.
+	Limit Limit
+	// Max is the value of the limit, and Actual is the value that exceeded it.
+	Max, Actual int
+	// Pattern is the pattern that exceeded the limit, if the limit applies to a single pattern, and Index is its
+	// position in its list. Exclude is true if the pattern is an exclude pattern.
+	Pattern string
+	Index   int
+	Exclude bool
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == LimitPatterns {
+		return fmt.Sprintf("glob: %v %d exceeds limit of %d", e.Limit, e.Actual, e.Max)
+	}
+	kind := "include"
+	if e.Exclude {
+		kind = "exclude"
+	}
+	return fmt.Sprintf("glob: %v %d of %s pattern %d %q exceeds limit of %d", e.Limit, e.Actual, kind, e.Index, e.Pattern, e.Max)
+}
+
+// check returns a *LimitError for the first limit exceeded by the given patterns, or nil if none is exceeded. The
+// patterns are examined as text, before they are compiled.
+func (l Limits) check(includes, excludes []string) error {
+	if n := len(includes) + len(excludes); l.MaxPatterns != 0 && n > l.MaxPatterns {
+		return &LimitError{Limit: LimitPatterns, Max: l.MaxPatterns, Actual: n}
+	}
+	if l.MaxSegments == 0 && l.MaxSegmentLength == 0 {
+		return nil
+	}
+	for list, patterns := range [][]string{includes, excludes} {
+		for i, p := range patterns {
+			segments := slices.DeleteFunc(strings.Split(p, "/"), func(s string) bool { return s == "" })
+			if l.MaxSegments != 0 && len(segments) > l.MaxSegments {
+				return &LimitError{Limit: LimitSegments, Max: l.MaxSegments, Actual: len(segments), Pattern: p, Index: i, Exclude: list == 1}
+			}
+			for _, text := range segments {
+				if l.MaxSegmentLength != 0 && len(text) > l.MaxSegmentLength {
+					return &LimitError{Limit: LimitSegmentLength, Max: l.MaxSegmentLength, Actual: len(text), Pattern: p, Index: i, Exclude: list == 1}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package glob
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	limits := Limits{MaxPatterns: 3, MaxSegments: 4, MaxSegmentLength: 16}
+
+	cases := []struct {
+		includes, excludes []string
+		expected           *LimitError
+	}{
+		{[]string{"src/**/*.go"}, []string{"**/testdata", "vendor"}, nil},
+		{[]string{"//a//b/c/d//"}, nil, nil},
+		{[]string{"a", "b"}, []string{"c", "d"}, &LimitError{Limit: LimitPatterns, Max: 3, Actual: 4}},
+		{[]string{"a/b/c/d/e"}, nil, &LimitError{Limit: LimitSegments, Max: 4, Actual: 5, Pattern: "a/b/c/d/e"}},
+		{[]string{"a"}, []string{"x", "**/" + strings.Repeat("?", 17)}, &LimitError{Limit: LimitSegmentLength, Max: 16, Actual: 17, Pattern: "**/" + strings.Repeat("?", 17), Index: 1, Exclude: true}},
+		{[]string{"**"}, []string{"a/b/c/d/e"}, &LimitError{Limit: LimitSegments, Max: 4, Actual: 5, Pattern: "a/b/c/d/e", Exclude: true}},
+	}
+	for _, c := range cases {
+		g, err := NewWithOptions(c.includes, c.excludes, Options{Limits: limits})
+		if c.expected == nil {
+			require.NoError(t, err)
+			assert.NotNil(t, g)
+			continue
+		}
+
+		var limitErr *LimitError
+		require.True(t, errors.As(err, &limitErr), "%v", err)
+		assert.Equal(t, c.expected, limitErr)
+	}
+
+	// Limits are checked before patterns are compiled.
+	_, err := NewWithOptions([]string{"[", "]", "a", "b"}, nil, Options{Limits: limits})
+	var limitErr *LimitError
+	assert.True(t, errors.As(err, &limitErr))
+
+	// Limits of zero are not enforced.
+	_, err = New([]string{strings.Repeat("a/", 100) + strings.Repeat("b", 1000)}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `glob: pattern count 4 exceeds limit of 3`, (&LimitError{Limit: LimitPatterns, Max: 3, Actual: 4}).Error())
+	assert.Equal(t, `glob: segment count 5 of exclude pattern 0 "a/b/c/d/e" exceeds limit of 4`,
+		(&LimitError{Limit: LimitSegments, Max: 4, Actual: 5, Pattern: "a/b/c/d/e", Exclude: true}).Error())
+}
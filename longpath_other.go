@@ -0,0 +1,18 @@
+//go:build !windows
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+)
+
+// longPath returns the given path. Paths are not limited in length on this platform.
+func longPath(p string) string {
+	return p
+}
+
+// osFS returns a filesystem for the operating system directory root.
+func osFS(root string) fs.FS {
+	return os.DirFS(root)
+}
@@ -0,0 +1,94 @@
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// longPath returns the extended-length form of the given path, which begins with `\\?\` and is not subject to the
+// MAX_PATH limit of 260 characters. The path is made absolute and cleaned, since extended-length paths are not
+// normalized by the system. If the path is already in extended-length form or cannot be made absolute, longPath
+// returns it unchanged.
+func longPath(p string) string {
+	if strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if unc, ok := strings.CutPrefix(abs, `\\`); ok {
+		return `\\?\UNC\` + unc
+	}
+	return `\\?\` + abs
+}
+
+// osFS returns a filesystem for the operating system directory root. Paths within the filesystem are opened in
+// extended-length form, so trees whose paths exceed MAX_PATH can be matched. Errors refer to paths relative to root,
+// as they do for os.DirFS.
+func osFS(root string) fs.FS {
+	return longPathFS(longPath(root))
+}
+
+// A longPathFS is like os.DirFS, but its root is an extended-length path.
+type longPathFS string
+
+// join returns the extended-length path of the named file.
+func (dir longPathFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) || strings.ContainsAny(name, `\:`) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return strings.TrimSuffix(string(dir), `\`), nil
+	}
+	return strings.TrimSuffix(string(dir), `\`) + `\` + filepath.FromSlash(name), nil
+}
+
+// relative rewrites the path of a *fs.PathError to the given name.
+func relative(err error, name string) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		pe.Path = name
+	}
+	return err
+}
+
+func (dir longPathFS) Open(name string) (fs.File, error) {
+	p, err := dir.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, relative(err, name)
+	}
+	return f, nil
+}
+
+func (dir longPathFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := dir.join("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(p)
+	return entries, relative(err, name)
+}
+
+func (dir longPathFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := dir.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	return info, relative(err, name)
+}
+
+func (dir longPathFS) Lstat(name string) (fs.FileInfo, error) {
+	p, err := dir.join("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(p)
+	return info, relative(err, name)
+}
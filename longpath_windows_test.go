@@ -0,0 +1,40 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fxs "github.com/pgavlin/fx/v2/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongPath(t *testing.T) {
+	assert.Equal(t, `\\?\C:\a\b`, longPath(`C:\a\.\b\`))
+	assert.Equal(t, `\\?\UNC\server\share\a`, longPath(`\\server\share\a`))
+	assert.Equal(t, `\\?\C:\a\..\b`, longPath(`\\?\C:\a\..\b`))
+}
+
+func TestMatchOSLongPaths(t *testing.T) {
+	root := t.TempDir()
+
+	// Create a directory whose path exceeds MAX_PATH.
+	deep := `\\?\` + root
+	for range 30 {
+		deep += `\` + strings.Repeat("d", 10)
+	}
+	require.NoError(t, os.MkdirAll(deep, 0o755))
+	require.NoError(t, os.WriteFile(deep+`\x.go`, nil, 0o644))
+
+	glob, err := New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	matches, err := fxs.TryCollect(MatchOS(glob, root, OSOptions{}))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.True(t, strings.HasPrefix(matches[0], root))
+	assert.Greater(t, len(matches[0]), 260)
+	assert.Equal(t, "x.go", filepath.Base(matches[0]))
+}
@@ -0,0 +1,423 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pgavlin/fx/v2"
+)
+
+// A MappingRule maps paths that match Pattern to destinations formed from Template.
+//
+// Pattern uses the same syntax as the patterns accepted by New, including flag groups and bounded '**' elements. Each
+// wildcard in Pattern captures the text it matches: '*', '?', and character classes capture the characters they match
+// within a name, and '**' and bounded '**' elements capture the sequence of directory names they match, joined by '/'.
+// Captures are numbered from 1 in the order their wildcards appear in Pattern. An element compiled by a custom
+// SegmentSyntax captures nothing, and a '**' added by the Basename option is not a capture.
+//
+// Template is a path in which $n or ${n} is replaced by the text of the n'th capture and $0 is replaced by the entire
+// matched path. $$ is replaced by a literal '$'. Empty path elements in the result are removed, so a '**' that
+// matches no directories does not introduce a leading or doubled '/'.
+type MappingRule struct {
+	Pattern  string
+	Template string
+}
+
+// A Mapping is an ordered list of rules that map source paths to destination paths.
+type Mapping struct {
+	rules []mappingRule
+}
+
+type mappingRule struct {
+	pattern  []captureSegment
+	template []templatePart
+}
+
+// NewMapping creates a new Mapping from the given rules. Rules are tried in order; the first rule whose pattern matches
+// a path determines its destination.
+func NewMapping(rules []MappingRule) (*Mapping, error) {
+	return NewMappingWithOptions(rules, Options{})
+}
+
+// NewMappingWithOptions creates a new Mapping from the given rules, compiling their patterns with the given options
+// as NewWithOptions does. If the options have a Transform, captures hold the transformed text of the names they
+// match.
+func NewMappingWithOptions(rules []MappingRule, options Options) (*Mapping, error) {
+	var errs []error
+	compiled := make([]mappingRule, 0, len(rules))
+	for _, r := range rules {
+		rule, err := newMappingRule(r, options)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &Mapping{rules: compiled}, nil
+}
+
+func newMappingRule(r MappingRule, options Options) (mappingRule, error) {
+	// Compiling the pattern as New would validates it, so the elements below are well-formed.
+	if _, err := compilePattern(r.Pattern, options); err != nil {
+		return mappingRule{}, fmt.Errorf("pattern %q: %w", r.Pattern, err)
+	}
+	p, options, _ := parseFlags(r.Pattern, options)
+
+	var segments []captureSegment
+	captures := 0
+	for text := range fx.Filter(strings.SplitSeq(p, "/"), func(s string) bool { return s != "" }) {
+		segment := newCaptureSegment(text, options)
+		captures += segment.captures()
+		segments = append(segments, segment)
+	}
+	if options.Basename && p != "" && !strings.Contains(p, "/") && !segments[0].globstar {
+		all := newCaptureSegment("**", options)
+		all.capture = false
+		segments = append([]captureSegment{all}, segments...)
+	}
+
+	template, err := parseTemplate(r.Template, captures)
+	if err != nil {
+		return mappingRule{}, fmt.Errorf("template %q: %w", r.Template, err)
+	}
+	return mappingRule{pattern: segments, template: template}, nil
+}
+
+// Map returns the destination for the given path according to the first rule that matches it. If no rule matches,
+// Map returns false.
+func (m *Mapping) Map(p string) (string, bool) {
+	names := slices.Collect(fx.Filter(strings.SplitSeq(p, "/"), func(s string) bool { return s != "" }))
+	for _, r := range m.rules {
+		if captures, ok := matchCaptures(r.pattern, names); ok {
+			return expandTemplate(r.template, strings.Join(names, "/"), captures), true
+		}
+	}
+	return "", false
+}
+
+// Apply maps each of the given paths, yielding (source, destination) pairs for each path matched by a rule. Paths that
+// no rule matches are skipped.
+func (m *Mapping) Apply(paths iter.Seq[string]) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for p := range paths {
+			if dest, ok := m.Map(p); ok && !yield(p, dest) {
+				return
+			}
+		}
+	}
+}
+
+// A captureSegment is a single path element of a mapping pattern. A globstar segment matches between min and max
+// names, where a max of -1 means that there is no limit; any other segment matches a single name with its compiled
+// step, and its tokens extract the captures from the name.
+type captureSegment struct {
+	step      step
+	globstar  bool
+	min, max  int
+	capture   bool
+	tokens    []captureToken
+	bytes     bool
+	transform func(string) string
+}
+
+func (s captureSegment) captures() int {
+	switch {
+	case s.globstar && s.capture:
+		return 1
+	case s.globstar:
+		return 0
+	}
+	n := 0
+	for _, t := range s.tokens {
+		if t.kind != tokenLiteral {
+			n++
+		}
+	}
+	return n
+}
+
+type tokenKind int
+
+const (
+	tokenLiteral tokenKind = iota
+	tokenStar
+	tokenChar
+)
+
+// A captureToken is a literal, a '*', or a single-character wildcard ('?' or a character class). The text of a literal
+// is unescaped, and the text of a wildcard is its source. Literals and single-character wildcards are matched by their
+// compiled forms; a literal matches as many characters as it contains.
+type captureToken struct {
+	kind    tokenKind
+	text    string
+	matcher *segmentMatcher
+	chars   int
+}
+
+// newCaptureSegment compiles a (valid) pattern element and splits it into tokens.
+func newCaptureSegment(text string, options Options) captureSegment {
+	if min, max, bounded, _ := parseBounds(text); bounded {
+		star, _ := newStep("*", options)
+		return captureSegment{step: star, globstar: true, min: min, max: max, capture: true}
+	}
+	s, _ := newStep(text, options)
+	if s.isGlobstar() {
+		return captureSegment{step: s, globstar: true, max: -1, capture: true}
+	}
+
+	segment := captureSegment{step: s, bytes: options.Bytes, transform: options.Transform}
+	if s.matcher.kind == segmentCustom {
+		return segment
+	}
+
+	// Tokens match names that have already been transformed, so they are compiled from the transformed text of the
+	// element without a transform.
+	if options.Transform != nil {
+		text = options.Transform(text)
+	}
+	tokenOptions := Options{CaseInsensitive: options.CaseInsensitive, Bytes: options.Bytes}
+	compile := func(kind tokenKind, source, text string, chars int) {
+		m, _ := compileText(source, tokenOptions)
+		segment.tokens = append(segment.tokens, captureToken{kind: kind, text: text, matcher: m, chars: chars})
+	}
+
+	var source, literal strings.Builder
+	chars := 0
+	flush := func() {
+		if literal.Len() != 0 {
+			compile(tokenLiteral, source.String(), literal.String(), chars)
+			source.Reset()
+			literal.Reset()
+			chars = 0
+		}
+	}
+	for len(text) > 0 {
+		switch text[0] {
+		case '*':
+			flush()
+			text = strings.TrimLeft(text, "*")
+			segment.tokens = append(segment.tokens, captureToken{kind: tokenStar})
+		case '?':
+			flush()
+			compile(tokenChar, "?", "?", 1)
+			text = text[1:]
+		case '[':
+			flush()
+			end, _ := classEnd(text)
+			compile(tokenChar, text[:end], text[:end], 1)
+			text = text[end:]
+		default:
+			escaped := text[0] == '\\'
+			if escaped {
+				source.WriteByte('\\')
+				text = text[1:]
+			}
+			_, n := decodeChar(text, options.Bytes)
+			source.WriteString(text[:n])
+			literal.WriteString(text[:n])
+			text, chars = text[n:], chars+1
+		}
+	}
+	flush()
+	return segment
+}
+
+// matchCaptures matches the given segments against names and returns the text captured by each wildcard. '**'
+// elements capture as few names as possible and '*' wildcards as many characters as possible. The pairs of segments
+// and names that fail to match are remembered, so matching takes polynomial time even for patterns with many
+// wildcards.
+func matchCaptures(segments []captureSegment, names []string) ([]string, bool) {
+	m := captureMatch{segments: segments, names: names, failed: make([]bool, (len(segments)+1)*(len(names)+1))}
+	return m.match(0, 0, nil)
+}
+
+// A captureMatch is the state of a match of a list of segments against a list of names.
+type captureMatch struct {
+	segments []captureSegment
+	names    []string
+	failed   []bool
+}
+
+// match matches the segments from the i'th onward against the names from the j'th onward, appending captures to
+// captures.
+func (m *captureMatch) match(i, j int, captures []string) ([]string, bool) {
+	if i == len(m.segments) {
+		return captures, j == len(m.names)
+	}
+	failed := &m.failed[i*(len(m.names)+1)+j]
+	if *failed {
+		return nil, false
+	}
+
+	segment := m.segments[i]
+	if segment.globstar {
+		for n := 0; j+n <= len(m.names) && (segment.max == -1 || n <= segment.max); n++ {
+			if n != 0 && !segment.step.match(m.names[j+n-1]) {
+				break
+			}
+			if n < segment.min {
+				continue
+			}
+			c := captures
+			if segment.capture {
+				c = append(c, strings.Join(m.names[j:j+n], "/"))
+			}
+			if c, ok := m.match(i+1, j+n, c); ok {
+				return c, true
+			}
+		}
+	} else if j < len(m.names) && segment.step.match(m.names[j]) {
+		if c, ok := segment.captureName(m.names[j], captures); ok {
+			if c, ok := m.match(i+1, j+1, c); ok {
+				return c, true
+			}
+		}
+	}
+	*failed = true
+	return nil, false
+}
+
+// captureName appends the text captured by each of the segment's wildcards from the given name, which the segment's
+// step matches.
+func (s captureSegment) captureName(name string, captures []string) ([]string, bool) {
+	if s.transform != nil {
+		name = s.transform(name)
+	}
+	m := tokenMatch{tokens: s.tokens, name: name, bytes: s.bytes, failed: make([]bool, (len(s.tokens)+1)*(len(name)+1))}
+	return m.match(0, 0, captures)
+}
+
+// A tokenMatch is the state of a match of a list of tokens against a single name.
+type tokenMatch struct {
+	tokens []captureToken
+	name   string
+	bytes  bool
+	failed []bool
+}
+
+// match matches the tokens from the k'th onward against the name from byte offset i onward, appending captures to
+// captures.
+func (m *tokenMatch) match(k, i int, captures []string) ([]string, bool) {
+	if k == len(m.tokens) {
+		return captures, i == len(m.name)
+	}
+	failed := &m.failed[k*(len(m.name)+1)+i]
+	if *failed {
+		return nil, false
+	}
+
+	token := m.tokens[k]
+	if token.kind == tokenStar {
+		for n := len(m.name); n >= i; n-- {
+			if !m.bytes && n < len(m.name) && !utf8.RuneStart(m.name[n]) {
+				continue
+			}
+			if c, ok := m.match(k+1, n, append(captures, m.name[i:n])); ok {
+				return c, true
+			}
+		}
+	} else {
+		end := i
+		for range token.chars {
+			if end == len(m.name) {
+				break
+			}
+			_, size := decodeChar(m.name[end:], m.bytes)
+			end += size
+		}
+		if end != i && token.matcher.match(m.name[i:end]) {
+			c := captures
+			if token.kind == tokenChar {
+				c = append(c, m.name[i:end])
+			}
+			if c, ok := m.match(k+1, end, c); ok {
+				return c, true
+			}
+		}
+	}
+	*failed = true
+	return nil, false
+}
+
+// A templatePart is either literal text or a reference to a capture. Capture 0 is the entire matched path.
+type templatePart struct {
+	literal string
+	capture int
+}
+
+func parseTemplate(t string, captures int) ([]templatePart, error) {
+	var parts []templatePart
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() != 0 {
+			parts = append(parts, templatePart{literal: literal.String(), capture: -1})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(t); i++ {
+		if t[i] != '$' {
+			literal.WriteByte(t[i])
+			continue
+		}
+		i++
+		if i == len(t) {
+			return nil, errors.New("trailing '$'")
+		}
+
+		var digits string
+		switch {
+		case t[i] == '$':
+			literal.WriteByte('$')
+			continue
+		case t[i] == '{':
+			end := strings.IndexByte(t[i:], '}')
+			if end == -1 {
+				return nil, errors.New("unterminated '${'")
+			}
+			digits, i = t[i+1:i+end], i+end
+		case t[i] >= '0' && t[i] <= '9':
+			start := i
+			for i+1 < len(t) && t[i+1] >= '0' && t[i+1] <= '9' {
+				i++
+			}
+			digits = t[start : i+1]
+		default:
+			return nil, fmt.Errorf("unexpected character %q after '$'", t[i])
+		}
+
+		n, err := strconv.Atoi(digits)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid capture reference %q", digits)
+		}
+		if n > captures {
+			return nil, fmt.Errorf("capture reference $%d out of range (pattern has %d captures)", n, captures)
+		}
+		flush()
+		parts = append(parts, templatePart{capture: n})
+	}
+	flush()
+	return parts, nil
+}
+
+func expandTemplate(template []templatePart, p string, captures []string) string {
+	var b strings.Builder
+	for _, part := range template {
+		switch part.capture {
+		case -1:
+			b.WriteString(part.literal)
+		case 0:
+			b.WriteString(p)
+		default:
+			b.WriteString(captures[part.capture-1])
+		}
+	}
+	return strings.Join(slices.Collect(fx.Filter(strings.SplitSeq(b.String(), "/"), func(s string) bool { return s != "" })), "/")
+}
@@ -0,0 +1,134 @@
+package glob
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapping(t *testing.T) {
+	m, err := NewMapping([]MappingRule{
+		{Pattern: "content/**/*.md", Template: "public/$1/$2.html"},
+		{Pattern: "static/**", Template: "public/$1"},
+		{Pattern: "img/[a-c]?.png", Template: "public/img/${1}_${2}.png"},
+		{Pattern: "*.txt", Template: "$$/$0"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		path string
+		dest string
+		ok   bool
+	}{
+		{"content/index.md", "public/index.html", true},
+		{"content/posts/2024/hello.md", "public/posts/2024/hello.html", true},
+		{"static/css/site.css", "public/css/site.css", true},
+		{"img/b1.png", "public/img/b_1.png", true},
+		{"img/d1.png", "", false},
+		{"notes.txt", "$/notes.txt", true},
+		{"dir/notes.txt", "", false},
+		{"content/index.html", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			dest, ok := m.Map(c.path)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.dest, dest)
+		})
+	}
+
+	pairs := maps.Collect(m.Apply(slices.Values([]string{"content/a.md", "other/b.md", "static/x"})))
+	assert.Equal(t, map[string]string{"content/a.md": "public/a.html", "static/x": "public/x"}, pairs)
+}
+
+func TestMappingErrors(t *testing.T) {
+	for _, rule := range []MappingRule{
+		{Pattern: "[", Template: "x"},
+		{Pattern: "*", Template: "$2"},
+		{Pattern: "*", Template: "${1"},
+		{Pattern: "*", Template: "$x"},
+		{Pattern: "*", Template: "a$"},
+	} {
+		_, err := NewMapping([]MappingRule{rule})
+		assert.Error(t, err, "%v", rule)
+	}
+}
+
+func TestMappingOptions(t *testing.T) {
+	m, err := NewMapping([]MappingRule{
+		{Pattern: "(?i)docs/*.MD", Template: "site/$1.html"},
+		{Pattern: "(?b)*.bak", Template: "trash/$1"},
+	})
+	require.NoError(t, err)
+	dest, ok := m.Map("DOCS/Guide.md")
+	assert.True(t, ok)
+	assert.Equal(t, "site/Guide.html", dest)
+	dest, ok = m.Map("a/b/c.bak")
+	assert.True(t, ok)
+	assert.Equal(t, "trash/c", dest)
+
+	m, err = NewMappingWithOptions([]MappingRule{{Pattern: "**/*.[ch]", Template: "$1/$2.o"}}, Options{ExplicitDot: true, CaseInsensitive: true})
+	require.NoError(t, err)
+	dest, ok = m.Map("src/Lib/main.C")
+	assert.True(t, ok)
+	assert.Equal(t, "src/Lib/main.o", dest)
+	_, ok = m.Map(".git/hooks/x.c")
+	assert.False(t, ok)
+	_, ok = m.Map("src/.x.c")
+	assert.False(t, ok)
+}
+
+func TestMappingBoundedGlobstar(t *testing.T) {
+	m, err := NewMapping([]MappingRule{
+		{Pattern: "src/**{1,2}/*.go", Template: "pkg/$1/$2"},
+		{Pattern: "**{0,1}/*.md", Template: "docs/$1/$2"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		path string
+		dest string
+		ok   bool
+	}{
+		{"src/a/main.go", "pkg/a/main", true},
+		{"src/a/b/main.go", "pkg/a/b/main", true},
+		{"src/main.go", "", false},
+		{"src/a/b/c/main.go", "", false},
+		{"README.md", "docs/README", true},
+		{"guide/intro.md", "docs/guide/intro", true},
+		{"a/b/intro.md", "", false},
+	}
+	for _, c := range cases {
+		dest, ok := m.Map(c.path)
+		assert.Equal(t, c.ok, ok, c.path)
+		assert.Equal(t, c.dest, dest, c.path)
+	}
+
+	_, err = NewMapping([]MappingRule{{Pattern: "a/**{2,1}", Template: "$1"}})
+	assert.Error(t, err)
+}
+
+func TestMappingPathological(t *testing.T) {
+	// Patterns with many wildcards are matched in polynomial time.
+	m, err := NewMapping([]MappingRule{{Pattern: "*a*a*a*a*a*a*a*a*b", Template: "$1"}})
+	require.NoError(t, err)
+	name := strings.Repeat("a", 1000)
+	_, ok := m.Map(name)
+	assert.False(t, ok)
+	dest, ok := m.Map(name + "b")
+	assert.True(t, ok)
+	assert.Equal(t, strings.Repeat("a", 992), dest)
+
+	m, err = NewMapping([]MappingRule{{Pattern: "**/a/**/a/**/a/**/a/**/b/*", Template: "$1"}})
+	require.NoError(t, err)
+	names := strings.Repeat("a/", 200)
+	_, ok = m.Map(names + "c/x")
+	assert.False(t, ok)
+	dest, ok = m.Map(names + "b/x")
+	assert.True(t, ok)
+	assert.Equal(t, "", dest)
+}
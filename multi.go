@@ -0,0 +1,196 @@
+package glob
+
+import (
+	"io/fs"
+	"iter"
+	"path"
+	"slices"
+)
+
+// A MultiMatch is a path that is matched by one or more of the globs passed to MatchAll.
+type MultiMatch struct {
+	// Path is the matching path.
+	Path string
+	// Globs holds the indices of the globs that match the path, in increasing order.
+	Globs []int
+}
+
+// An includer is a Glob that can report the include patterns that bound its matches.
+type includer interface {
+	includePatterns() []pattern
+}
+
+func (g *matchGlob) includePatterns() []pattern {
+	return g.include
+}
+
+func (allGlob) includePatterns() []pattern {
+	return allPatterns
+}
+
+func (noneGlob) includePatterns() []pattern {
+	return nil
+}
+
+func (g *Layered) includePatterns() []pattern {
+	return g.union.include
+}
+
+// MatchAll matches each of the given globs against the contents of dir in a single traversal. Each file that is matched
+// by at least one glob is yielded once, along with the indices of the globs that match it. As with Match, the error
+// portion of a pair is only non-nil when the path portion is a directory and MatchAll fails to read the directory's
+// entries.
+//
+// The traversal visits the directories that may contain matches for any of the globs. As it enters each directory, it
+// advances the patterns of each glob created by New past the directory's name, so each file is matched against the
+// remaining patterns of the globs that may match it by its name alone. Other globs are matched against each file's
+// path with MatchPath. Globs that were not created by this package cannot bound the traversal, so if any such glob is
+// present, the entire tree is visited.
+func MatchAll(fsys fs.FS, dir string, globs []Glob) iter.Seq2[MultiMatch, error] {
+	var union []pattern
+	for _, g := range globs {
+		i, ok := g.(includer)
+		if !ok {
+			union = allPatterns
+			break
+		}
+		union = append(union, i.includePatterns()...)
+	}
+
+	dir = path.Clean(dir)
+	return func(yield func(MultiMatch, error) bool) {
+		if len(union) == 0 {
+			return
+		}
+
+		tracker := newGlobTracker(globs)
+		w := newWalker(fsys, MatchOptions{}, func(p string, err error) bool {
+			if err != nil {
+				return yield(MultiMatch{Path: p}, err)
+			}
+			matched := tracker.match(relativePath(dir, p))
+			return len(matched) == 0 || yield(MultiMatch{Path: p, Globs: matched}, nil)
+		})
+		w.walk(dir, union, nil)
+	}
+}
+
+// A globState holds the patterns of a glob created by New that remain to be matched against the contents of a
+// directory, along with the exclusion that applies to them. The patterns are scanned linearly, as they are by MatchPath
+// beneath the first level.
+type globState struct {
+	index   int
+	include []pattern
+	exclude []pattern
+	e       exclusion
+}
+
+// enter advances the state past the given directory name. It returns false if the glob cannot match anything beneath
+// the directory.
+func (s *globState) enter(name string) (globState, bool) {
+	next := globState{index: s.index, e: s.e}
+	for _, p := range s.exclude {
+		if p.matchDir(name, &next.exclude) {
+			next.e = next.e.update(p)
+		}
+	}
+	if next.e.final(next.exclude) {
+		return globState{}, false
+	}
+	for _, p := range s.include {
+		p.matchDir(name, &next.include)
+	}
+	return next, len(next.include) != 0
+}
+
+// match reports whether the glob matches the file with the given name in the state's directory. rest is used as
+// scratch space.
+func (s *globState) match(name string, rest *[]pattern) bool {
+	e := s.e
+	*rest = (*rest)[:0]
+	for _, p := range s.exclude {
+		if p.matchDir(name, rest) {
+			e = e.update(p)
+		}
+	}
+	if e.excluded() {
+		return false
+	}
+	*rest = (*rest)[:0]
+	for _, p := range s.include {
+		if p.matchDir(name, rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// A globTracker matches paths against a list of globs. The paths of a traversal are matched incrementally: the states
+// of the globs created by New are kept for each directory between the root and the most recently matched path, so
+// only the components of a path that differ from those of the previous path are matched again.
+type globTracker struct {
+	// others holds the globs that are matched with MatchPath.
+	others []Glob
+	// otherIndices holds the index of each glob in others.
+	otherIndices []int
+
+	// dirs holds the names of the directories on the stack, and stack holds the states of the globs for the root and
+	// for each of those directories.
+	dirs  []string
+	stack [][]globState
+
+	// rest is scratch space for matching file names.
+	rest []pattern
+}
+
+func newGlobTracker(globs []Glob) *globTracker {
+	t := &globTracker{stack: [][]globState{nil}}
+	for i, g := range globs {
+		if mg, ok := g.(*matchGlob); ok {
+			t.stack[0] = append(t.stack[0], globState{index: i, include: mg.include, exclude: mg.exclude})
+			continue
+		}
+		t.others, t.otherIndices = append(t.others, g), append(t.otherIndices, i)
+	}
+	return t
+}
+
+// match returns the indices of the globs that match the given relative path, in increasing order.
+func (t *globTracker) match(rel string) []int {
+	names := splitPath(rel)
+	if len(names) == 0 {
+		return nil
+	}
+	dirs, name := names[:len(names)-1], names[len(names)-1]
+
+	// Reuse the states of the directories that the path shares with the previous path.
+	shared := 0
+	for shared < len(dirs) && shared < len(t.dirs) && dirs[shared] == t.dirs[shared] {
+		shared++
+	}
+	t.dirs, t.stack = t.dirs[:shared], t.stack[:shared+1]
+	for _, d := range dirs[shared:] {
+		states := t.stack[len(t.stack)-1]
+		next := make([]globState, 0, len(states))
+		for _, s := range states {
+			if n, ok := s.enter(d); ok {
+				next = append(next, n)
+			}
+		}
+		t.dirs, t.stack = append(t.dirs, d), append(t.stack, next)
+	}
+
+	var matched []int
+	for _, s := range t.stack[len(t.stack)-1] {
+		if s.match(name, &t.rest) {
+			matched = append(matched, s.index)
+		}
+	}
+	for i, g := range t.others {
+		if g.MatchPath(rel) {
+			matched = append(matched, t.otherIndices[i])
+		}
+	}
+	slices.Sort(matched)
+	return matched
+}
@@ -0,0 +1,149 @@
+package glob
+
+import (
+	"fmt"
+	"io/fs"
+	"iter"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pathGlob is a Glob that is not created by this package.
+type pathGlob string
+
+func (g pathGlob) Match(fsys fs.FS, dir string, includeDirs bool) iter.Seq2[string, error] {
+	panic("unimplemented")
+}
+
+func (g pathGlob) MatchWith(fsys fs.FS, dir string, options MatchOptions) iter.Seq2[string, error] {
+	panic("unimplemented")
+}
+
+func (g pathGlob) MatchPath(p string) bool {
+	return p == string(g)
+}
+
+func TestMatchAll(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/a.go":      {},
+		"src/a_test.go": {},
+		"docs/x.md":     {},
+		"other/y.txt":   {},
+	}
+
+	mustNew := func(includes, excludes []string) Glob {
+		g, err := New(includes, excludes)
+		require.NoError(t, err)
+		return g
+	}
+	layered, err := NewLayered(Layer{Includes: []string{"docs/**"}})
+	require.NoError(t, err)
+
+	globs := []Glob{
+		mustNew([]string{"**/*.go"}, []string{"**/*_test.go"}),
+		mustNew([]string{"**/*_test.go"}, nil),
+		mustNew(nil, nil),
+		layered,
+	}
+
+	var matches []MultiMatch
+	for m, err := range MatchAll(fsys, ".", globs) {
+		require.NoError(t, err)
+		matches = append(matches, m)
+	}
+	assert.Equal(t, []MultiMatch{
+		{Path: "docs/x.md", Globs: []int{3}},
+		{Path: "src/a.go", Globs: []int{0}},
+		{Path: "src/a_test.go", Globs: []int{1}},
+	}, matches)
+
+	// A glob that is not created by this package forces a full traversal.
+	matches = nil
+	for m, err := range MatchAll(fsys, "other", append(globs, pathGlob("y.txt"))) {
+		require.NoError(t, err)
+		matches = append(matches, m)
+	}
+	assert.Equal(t, []MultiMatch{{Path: "other/y.txt", Globs: []int{4}}}, matches)
+
+	for range MatchAll(fsys, ".", []Glob{mustNew(nil, nil)}) {
+		t.Fatal("unexpected match")
+	}
+}
+
+func TestMatchAllMatchPath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":             {},
+		"src/a.go":         {},
+		"src/a_test.go":    {},
+		"src/gen/b.go":     {},
+		"src/gen/keep.go":  {},
+		"src/lib/c.go":     {},
+		"src/lib/c.md":     {},
+		"docs/x.md":        {},
+		"docs/api/y.md":    {},
+		"vendor/v/v.go":    {},
+		"vendor/v/README":  {},
+		".hidden/z.go":     {},
+		"other/deep/y.txt": {},
+	}
+
+	specs := []struct{ includes, excludes []string }{
+		{[]string{"**/*.go"}, []string{"**/*_test.go", "vendor"}},
+		{[]string{"src/**"}, []string{"src/gen/**", "!src/gen/keep.go"}},
+		{[]string{"**/*.md", "vendor/*/README"}, []string{"docs/api"}},
+		{[]string{"*.go", "other/*/y.txt"}, nil},
+		{[]string{"**"}, []string{"src", "docs"}},
+	}
+	var globs []Glob
+	for _, s := range specs {
+		g, err := New(s.includes, s.excludes)
+		require.NoError(t, err)
+		globs = append(globs, g)
+	}
+	globs = append(globs, pathGlob("docs/x.md"))
+
+	// Each file is tagged with exactly the globs whose MatchPath accepts it.
+	var expected []MultiMatch
+	for p := range fsys {
+		var matched []int
+		for i, g := range globs {
+			if g.MatchPath(p) {
+				matched = append(matched, i)
+			}
+		}
+		if matched != nil {
+			expected = append(expected, MultiMatch{Path: p, Globs: matched})
+		}
+	}
+	var matches []MultiMatch
+	for m, err := range MatchAll(fsys, ".", globs) {
+		require.NoError(t, err)
+		matches = append(matches, m)
+	}
+	assert.ElementsMatch(t, expected, matches)
+}
+
+func BenchmarkMatchAll(b *testing.B) {
+	fsys := fstest.MapFS{}
+	for i := range 20 {
+		for j := range 20 {
+			fsys[fmt.Sprintf("d%02d/e%02d/f.go", i, j)] = &fstest.MapFile{}
+			fsys[fmt.Sprintf("d%02d/e%02d/f.md", i, j)] = &fstest.MapFile{}
+		}
+	}
+	var globs []Glob
+	for i := range 50 {
+		g, err := New([]string{fmt.Sprintf("d%02d/**/*.go", i%20), "**/*.md"}, []string{fmt.Sprintf("**/e%02d", i%20)})
+		require.NoError(b, err)
+		globs = append(globs, g)
+	}
+
+	for b.Loop() {
+		for _, err := range MatchAll(fsys, ".", globs) {
+			require.NoError(b, err)
+		}
+	}
+}
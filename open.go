@@ -0,0 +1,47 @@
+package glob
+
+import (
+	"io/fs"
+	"iter"
+)
+
+// An OpenedFile is a matching file that has been opened by MatchOpen.
+type OpenedFile struct {
+	// Path is the path of the file.
+	Path string
+	// File is the open file. It is nil if the file could not be opened.
+	File fs.File
+}
+
+// MatchOpen opens each file under dir that matches g and yields it along with any error encountered while opening it.
+// If a file cannot be opened, its pair holds the path and the error, and the traversal continues. Errors encountered
+// while reading directories are yielded as they are by Match.
+//
+// MatchOpen owns each file it yields: the file is closed as soon as the loop body that received it returns, whether or
+// not the loop continues. Consumers must not close the file themselves or retain it beyond the loop body; a consumer
+// that needs a file for longer should open it again.
+func MatchOpen(g Glob, fsys fs.FS, dir string) iter.Seq2[OpenedFile, error] {
+	return func(yield func(OpenedFile, error) bool) {
+		for p, err := range g.Match(fsys, dir, false) {
+			if err != nil {
+				if !yield(OpenedFile{Path: p}, err) {
+					return
+				}
+				continue
+			}
+
+			f, err := fsys.Open(p)
+			if err != nil {
+				if !yield(OpenedFile{Path: p}, err) {
+					return
+				}
+				continue
+			}
+			ok := yield(OpenedFile{Path: p, File: f}, nil)
+			f.Close()
+			if !ok {
+				return
+			}
+		}
+	}
+}
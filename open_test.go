@@ -0,0 +1,95 @@
+package glob
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeTrackingFS records the files opened through it that have not been closed.
+type closeTrackingFS struct {
+	fstest.MapFS
+	open map[string]bool
+}
+
+type trackedFile struct {
+	fs.File
+	name string
+	fsys *closeTrackingFS
+}
+
+func (f *trackedFile) Close() error {
+	delete(f.fsys.open, f.name)
+	return f.File.Close()
+}
+
+func (fsys *closeTrackingFS) Open(name string) (fs.File, error) {
+	f, err := fsys.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fsys.open[name] = true
+	return &trackedFile{File: f, name: name, fsys: fsys}, nil
+}
+
+func TestMatchOpen(t *testing.T) {
+	fsys := &closeTrackingFS{
+		MapFS: fstest.MapFS{
+			"a.txt":   {Data: []byte("a")},
+			"b/c.txt": {Data: []byte("c")},
+			"d.go":    {},
+		},
+		open: map[string]bool{},
+	}
+
+	g, err := New([]string{"**/*.txt"}, nil)
+	require.NoError(t, err)
+
+	contents := map[string]string{}
+	for f, err := range MatchOpen(g, fsys, ".") {
+		require.NoError(t, err)
+		data, err := io.ReadAll(f.File)
+		require.NoError(t, err)
+		contents[f.Path] = string(data)
+	}
+	assert.Equal(t, map[string]string{"a.txt": "a", "b/c.txt": "c"}, contents)
+	assert.Empty(t, fsys.open)
+
+	// Breaking out of the loop closes the current file.
+	for range MatchOpen(g, fsys, ".") {
+		break
+	}
+	assert.Empty(t, fsys.open)
+}
+
+func TestMatchOpenError(t *testing.T) {
+	g, err := New([]string{"*.txt"}, nil)
+	require.NoError(t, err)
+
+	fsys := openFailFS{fstest.MapFS{"locked.txt": {}}}
+	count := 0
+	for f, err := range MatchOpen(g, fsys, ".") {
+		count++
+		assert.Equal(t, "locked.txt", f.Path)
+		assert.Nil(t, f.File)
+		assert.ErrorIs(t, err, fs.ErrPermission)
+	}
+	assert.Equal(t, 1, count)
+}
+
+// openFailFS is a filesystem whose files cannot be opened, though its directories can be read.
+type openFailFS struct {
+	fstest.MapFS
+}
+
+func (fsys openFailFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+func (fsys openFailFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fsys.MapFS.ReadDir(name)
+}
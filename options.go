@@ -0,0 +1,377 @@
+package glob
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Options controls how NewWithOptions compiles a Glob's patterns.
+type Options struct {
+	// CaseInsensitive causes names to match pattern elements without regard to case. Names and patterns are compared
+	// using Unicode simple case folding.
+	CaseInsensitive bool
+
+	// ExplicitDot prevents wildcards and '**' from matching names that begin with '.', as in a shell without the
+	// dotglob option. Such names are only matched by pattern elements that begin with '.'.
+	ExplicitDot bool
+
+	// Bytes causes names and patterns to be matched as raw bytes rather than as UTF-8 text: '?' matches a single
+	// byte, and each byte in a character class is a separate character. This allows names that are not valid UTF-8,
+	// such as Latin-1 names, to be matched deterministically. If CaseInsensitive is also set, only ASCII letters are
+	// folded.
+	Bytes bool
+
+	// Basename causes a pattern that does not contain a '/' to match names at any depth, as if it began with '**/',
+	// so that '*.bak' matches backup files anywhere in the tree, as it would with find -name or in an ignore file. A
+	// pattern that contains a '/' is matched from the starting directory as usual; write '/*.bak' to match only the
+	// backup files in the starting directory itself.
+	Basename bool
+
+	// Transform, if non-nil, is applied to each name before it is compared with a pattern element and to the text of
+	// each pattern element before it is compiled, so that names are matched by their transformed forms: for example,
+	// a Transform might normalize names to Unicode NFC or strip a version suffix. Transform is applied to pattern
+	// elements as text, so it should map the metacharacters '*', '?', '[', ']', and '\\' to themselves. Because the
+	// transformed text of a literal element need not be the name of a file, the literal elements of patterns are
+	// matched by reading their parent directories rather than by looking them up directly.
+	Transform func(name string) string
+
+	// Segments lists custom syntaxes for pattern elements. Each element other than '**' is offered to each syntax in
+	// turn, and the first syntax that accepts the element compiles it; elements that no syntax accepts use the standard
+	// syntax. Elements are separated by '/' before they are offered, so a custom element cannot contain a slash. A
+	// custom element may match any name, so it is matched by reading its directory.
+	Segments []SegmentSyntax
+
+	// Limits bounds the number and size of the patterns. If the patterns exceed a limit, NewWithOptions returns a
+	// *LimitError without compiling them.
+	Limits Limits
+}
+
+// A SegmentSyntax compiles pattern elements written in a custom syntax, such as 're:^v[0-9]+$' for a regular
+// expression, so that user code can decide which names an element matches while the traversal remains in this
+// package.
+type SegmentSyntax interface {
+	// CompileSegment compiles the given pattern element. If the element is not written in the syntax, CompileSegment
+	// returns false. If the element is written in the syntax but is malformed, CompileSegment returns an error, which
+	// is reported as a *SyntaxError for the element.
+	CompileSegment(element string) (SegmentMatcher, bool, error)
+}
+
+// A SegmentMatcher matches names against a pattern element compiled by a SegmentSyntax.
+type SegmentMatcher interface {
+	// MatchSegment reports whether the given name matches the element. The name is a single path element that has
+	// been transformed by Options.Transform, if it is set.
+	MatchSegment(name string) bool
+}
+
+// equal reports whether o and p are the same options. Transforms are equal if they are the same function. Limits do
+// not affect matching and are not compared.
+func (o Options) equal(p Options) bool {
+	transform := func(o Options) uintptr {
+		if o.Transform == nil {
+			return 0
+		}
+		return reflect.ValueOf(o.Transform).Pointer()
+	}
+	return o.CaseInsensitive == p.CaseInsensitive && o.ExplicitDot == p.ExplicitDot && o.Bytes == p.Bytes &&
+		o.Basename == p.Basename && transform(o) == transform(p) && slices.EqualFunc(o.Segments, p.Segments, sameSyntax)
+}
+
+// sameSyntax reports whether two segment syntaxes are the same value. Syntaxes whose values cannot be compared are
+// the same only if they are the same pointer, func, map, or slice.
+func sameSyntax(a, b SegmentSyntax) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	switch {
+	case ta != tb:
+		return false
+	case ta == nil || ta.Comparable():
+		return a == b
+	default:
+		va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+		switch va.Kind() {
+		case reflect.Func, reflect.Map, reflect.Slice:
+			return va.Pointer() == vb.Pointer()
+		default:
+			return false
+		}
+	}
+}
+
+// A DirMode determines whether and when a match yields matching directories.
+type DirMode int
+
+const (
+	// DirsNone omits directories from the sequence.
+	DirsNone DirMode = 0
+	// DirsPre yields each matching directory before its contents.
+	DirsPre DirMode = 1 << 0
+	// DirsPost yields each matching directory after its contents.
+	DirsPost DirMode = 1 << 1
+	// DirsBoth yields each matching directory both before and after its contents. Consumers can distinguish the two
+	// by noting whether the directory has been seen before.
+	DirsBoth = DirsPre | DirsPost
+)
+
+// MatchOptions controls the behavior of MatchWith.
+type MatchOptions struct {
+	// Dirs determines whether and when matching directories are included in the sequence. Match's includeDirs
+	// parameter corresponds to DirsPre.
+	Dirs DirMode
+
+	// IncludeRoot causes the starting directory to be included in the sequence if Dirs is not DirsNone and the glob
+	// matches the starting directory itself, which is the case when one of its include patterns is '**'.
+	IncludeRoot bool
+
+	// MaxOps limits the number of filesystem operations (directory reads and stats) performed by a single match. When
+	// the limit is reached, the match yields a *BudgetExceededError and stops. A value of zero means no limit.
+	MaxOps int
+
+	// OmitEmptyDirs causes a matching directory to be yielded only if at least one file beneath it is also yielded, so
+	// that a broad include such as '**' does not yield empty directories or directories whose contents are all
+	// excluded. A directory that is yielded before its contents is held back until the first such file is found.
+	// Matching directories whose contents are not matched are omitted.
+	OmitEmptyDirs bool
+
+	// LeafDirs causes a matching directory to be yielded only if no matching directory beneath it is yielded, so that
+	// a pattern such as '**/migrations' or '**' yields the deepest matching directories rather than every directory
+	// along the way. Because a directory is not known to be a leaf until its contents have been matched, each leaf
+	// directory is yielded once, after its contents, whatever the value of Dirs. A match that resumes from a checkpoint
+	// does not know which directories it yielded before the checkpoint, so it may yield an ancestor of the checkpoint
+	// that is not a leaf.
+	LeafDirs bool
+
+	// Parents causes each path in the sequence to be preceded by the directories between the starting directory and
+	// the path that have not already been yielded, outermost first, whatever the value of Dirs. Each such directory is
+	// yielded once, before any of its contents, so a consumer that copies or mirrors the matches can create each
+	// directory as it is yielded. MatchResults sets Result.Parent for these directories; a matching directory that is
+	// yielded before its contents is not also yielded as a parent. The directory entries of parents that the match
+	// did not read, such as the directories named by the literal prefix of a pattern, are fetched by MatchResults with
+	// fs.Stat.
+	Parents bool
+
+	// Scope, if non-nil, restricts the match to the subtrees rooted at the directories that Scope matches. A path is
+	// only yielded if Scope matches one of its ancestors or, if the path is a directory, the path itself, and a
+	// directory's contents are only read if the directory is in scope or Scope may match a directory beneath it. Scope
+	// is matched against paths relative to the starting directory, which is in scope if one of Scope's include patterns
+	// is '**'. For example, a glob that includes '**/*.sql' with a Scope that includes 'services/*/migrations' matches
+	// only the SQL files beneath each service's migrations. If Scope was not created by this package, it cannot bound
+	// the traversal, and every directory is read.
+	Scope Glob
+
+	// Shard, if its Count is non-zero, restricts the match to one of several disjoint shards, so that the work of a
+	// large match can be distributed across processes or machines. The union of the matches of every shard is the full
+	// match.
+	Shard Shard
+
+	// Dedup causes the match to track the paths it yields and skip any path it has already yielded. A single traversal
+	// never yields a path more than once, so this is only necessary for traversals that may reach the same path by
+	// multiple routes.
+	Dedup bool
+
+	// SkipSpecialFiles omits matching files that are not regular files, directories, or symbolic links: named pipes,
+	// sockets, devices, and irregular files. Files are classified by the type bits of their directory entries, so no
+	// additional filesystem operations are performed; symbolic links found by reading a directory are not followed, so
+	// a link to a special file is not omitted. Special files are omitted before they are passed to Sniff, so Sniff never
+	// blocks reading a named pipe.
+	SkipSpecialFiles bool
+
+	// SkipMarkers lists the names of marker files, such as "CACHEDIR.TAG" or ".nobackup", that prevent the match from
+	// matching the contents of the directories that contain them, as backup tools do. Such a directory may itself
+	// match. The entries of a directory are checked for markers when the directory is read; a directory that the match
+	// would otherwise look up by name rather than read, because it is named by a literal pattern element, is checked
+	// by looking up each marker within it. The directory names in a pattern's literal prefix are looked up one at a
+	// time, rather than skipped, so that each is checked.
+	SkipMarkers []string
+
+	// InvalidUTF8 determines how the match handles matching paths that are not valid UTF-8.
+	InvalidUTF8 InvalidUTF8Mode
+
+	// Warn, if non-nil, is called with problems that do not prevent a path from matching, such as the
+	// *InvalidUTF8Error reported for each matching path that is not valid UTF-8 when InvalidUTF8 is InvalidUTF8Warn.
+	// It is called before the path is yielded.
+	Warn func(err error)
+
+	// Sniff, if non-nil, filters matching files by their contents. It is called for each matching file with up to
+	// SniffSize bytes read from the start of the file, and the file is omitted from the sequence if it returns false.
+	// The slice is only valid for the duration of the call. If the file cannot be read, the error is yielded paired
+	// with the file's path. Each read counts toward MaxOps.
+	Sniff func(path string, head []byte) bool
+
+	// SniffSize is the maximum number of bytes passed to Sniff. If zero, 512 bytes are read.
+	SniffSize int
+
+	// Checkpoint, if non-nil, is called each time the match finishes a directory: that is, once it has yielded every
+	// match beneath the directory and, if Dirs includes DirsPost, the directory itself. The directory's path is a
+	// checkpoint that may be saved and later passed as Resume to continue the match if it is interrupted.
+	Checkpoint func(dir string)
+
+	// Resume, if non-empty, continues a match from a checkpoint passed to Checkpoint by an earlier match of the same
+	// glob, directory, and options. The paths that the earlier match yielded before the checkpoint are skipped, and
+	// the directories that it finished are not read again. Because skipped paths are identified by their order, the
+	// filesystem must return directory entries sorted by name, as fs.ReadDir does, and changes made to the tree since
+	// the checkpoint may be missed.
+	Resume string
+
+	// Trace, if non-nil, is called each time the match begins reading a directory with a description of the include
+	// and exclude patterns that the directory's entries are matched against. The description is intended for debugging
+	// and its format is not stable.
+	Trace func(dir string, state string)
+
+	// StatConcurrency, if greater than one, allows the match to look up the paths named entirely by literal pattern
+	// elements concurrently, using up to StatConcurrency goroutines. When a directory is read because its include
+	// patterns begin with different literal names, the lookups for those patterns are started alongside the read, so a
+	// match of many literal paths on a high-latency filesystem costs a few round trips rather than one per path. fsys
+	// must be safe for concurrent use. StatConcurrency is ignored if MaxOps or Lstat is set.
+	StatConcurrency int
+
+	// Lstat prevents the match from following symbolic links when it looks up a path by name, as it does for the
+	// literal elements of a pattern. A symbolic link is then treated as a file, as it is when it is found by reading a
+	// directory. If the filesystem has an Lstat method, as os.DirFS does, it is used; otherwise, the path's entry is
+	// found by reading its parent directory. The starting directory is always followed.
+	Lstat bool
+
+	// descend, if non-nil, is called before the match descends into a directory. If it returns false, the directory's
+	// contents are not matched. This is used by the operating system helpers.
+	descend func(path string, entry fs.DirEntry) bool
+
+	// filter, if non-nil, is called for each matching path before it is yielded. If it returns false, the path is
+	// omitted. This is used by Layered globs.
+	filter func(path string) bool
+}
+
+// A Shard selects one of several disjoint parts of a match. Each path is assigned to a shard by hashing its ancestor at
+// depth Depth below the starting directory, or the path itself if it is no deeper than that, so each shard reads only
+// the subtrees at that depth that are assigned to it; every shard reads the directories above them. The assignment is
+// stable across processes and machines.
+type Shard struct {
+	// Index is the shard to match, from 0 to Count-1.
+	Index int
+	// Count is the number of shards. If it is zero, the match is not sharded.
+	Count int
+	// Depth is the depth of the directories by which paths are assigned to shards: 1 assigns the entries of the
+	// starting directory, 2 assigns the entries of its subdirectories, and so on. A value of zero is treated as 1.
+	Depth int
+}
+
+// Contains reports whether the given slash-separated path, relative to the starting directory, is assigned to the
+// shard. Every path is contained by a shard whose Count is zero.
+func (s Shard) Contains(p string) bool {
+	if s.Count == 0 {
+		return true
+	}
+
+	// Cut the path after its first Depth names.
+	key, depth := strings.Trim(p, "/"), max(s.Depth, 1)
+	for i, n := 0, 0; i < len(key); i++ {
+		if key[i] == '/' {
+			if n++; n == depth {
+				key = key[:i]
+				break
+			}
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index
+}
+
+// An InvalidUTF8Mode determines how a match handles matching paths that are not valid UTF-8.
+type InvalidUTF8Mode int
+
+const (
+	// InvalidUTF8Allow yields paths that are not valid UTF-8 as-is. Unless the glob was created with the Bytes option,
+	// each byte of an invalid UTF-8 sequence is matched as a single character that matches '?', '*', and negated
+	// character classes, but no other character class. Note that fs.FS implementations reject paths that are not valid
+	// UTF-8 (see fs.ValidPath), so the contents of a directory whose name is not valid UTF-8 typically cannot be read.
+	InvalidUTF8Allow InvalidUTF8Mode = iota
+	// InvalidUTF8Skip omits paths that are not valid UTF-8. The contents of directories whose names are not valid UTF-8
+	// are not matched.
+	InvalidUTF8Skip
+	// InvalidUTF8Warn yields paths that are not valid UTF-8 as InvalidUTF8Allow does, and passes an *InvalidUTF8Error
+	// for each to MatchOptions.Warn.
+	InvalidUTF8Warn
+	// InvalidUTF8Fail yields the first path that is not valid UTF-8 paired with an *InvalidUTF8Error and stops.
+	InvalidUTF8Fail
+)
+
+// An InvalidUTF8Error reports a matching path that is not valid UTF-8.
+type InvalidUTF8Error struct {
+	// Path is the path that is not valid UTF-8.
+	Path string
+}
+
+func (e *InvalidUTF8Error) Error() string {
+	return fmt.Sprintf("glob: path %q is not valid UTF-8", e.Path)
+}
+
+// defaultSniffSize is the number of bytes passed to MatchOptions.Sniff if MatchOptions.SniffSize is zero.
+const defaultSniffSize = 512
+
+// A BudgetExceededError is yielded when a match reaches its limit on filesystem operations.
+type BudgetExceededError struct {
+	// Limit is the maximum number of operations allowed.
+	Limit int
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("glob: filesystem operation budget of %d exceeded", e.Limit)
+}
+
+// PathOptions controls how MatchPathWith interprets a path.
+type PathOptions struct {
+	// Absolute determines how a path that begins with a slash is handled.
+	Absolute AbsPathMode
+
+	// DotDot determines how '..' elements are handled.
+	DotDot DotDotMode
+
+	// WindowsSeparators causes '\' to be treated as a path separator, as it is on Windows. The path is converted
+	// before it is checked against the other options, so `\src\main.go` is absolute.
+	WindowsSeparators bool
+}
+
+// An AbsPathMode determines how MatchPathWith handles paths that begin with a slash.
+type AbsPathMode int
+
+const (
+	// AbsPathAnchor treats a path that begins with a slash as relative to the directory being matched. This is the
+	// behavior of Glob.MatchPath.
+	AbsPathAnchor AbsPathMode = iota
+	// AbsPathReject rejects a path that begins with a slash with an *AbsolutePathError.
+	AbsPathReject
+)
+
+// An AbsolutePathError is returned by MatchPathWith for a path that begins with a slash when such paths are rejected.
+type AbsolutePathError struct {
+	// Path is the absolute path.
+	Path string
+}
+
+func (e *AbsolutePathError) Error() string {
+	return fmt.Sprintf("glob: path %q is absolute", e.Path)
+}
+
+// A DotDotMode determines how MatchPathWith handles '..' elements.
+type DotDotMode int
+
+const (
+	// DotDotResolve resolves each '..' element lexically by removing the preceding name. A path that refers to a
+	// location above the directory being matched matches nothing. This is the behavior of Glob.MatchPath.
+	DotDotResolve DotDotMode = iota
+	// DotDotReject rejects a path that contains a '..' element with a *DotDotError.
+	DotDotReject
+)
+
+// A DotDotError is returned by MatchPathWith for a path that contains a '..' element when such paths are rejected.
+type DotDotError struct {
+	// Path is the path that contains a '..' element.
+	Path string
+}
+
+func (e *DotDotError) Error() string {
+	return fmt.Sprintf("glob: path %q contains '..'", e.Path)
+}
@@ -0,0 +1,315 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// A CaseMode determines whether MatchOS matches names without regard to case.
+type CaseMode int
+
+const (
+	// CaseDetect matches without regard to case if root resides on a case-insensitive filesystem, such as a default
+	// macOS or Windows volume.
+	CaseDetect CaseMode = iota
+	// CaseSensitive never enables case-insensitive matching. The glob matches as it was created.
+	CaseSensitive
+	// CaseInsensitive always matches without regard to case.
+	CaseInsensitive
+)
+
+// OSOptions controls the behavior of MatchOS.
+type OSOptions struct {
+	MatchOptions
+
+	// SameDevice prevents the match from descending into directories that reside on a different device than root,
+	// like find's -xdev flag. Directories that are mount points may still be yielded, but their contents are not
+	// matched. Each directory found by reading its parent is examined with an extra lstat, which is shared with the
+	// check made by Permission. SameDevice has no effect on platforms that do not report device IDs.
+	SameDevice bool
+
+	// UniqueFiles yields each file at most once when several matching paths are hard links to the same file, as
+	// identified by its device and inode numbers. Only the first such path is yielded. Each matching path that is not a
+	// directory is examined with an extra lstat, so symbolic links are not followed. UniqueFiles has no effect on
+	// platforms that do not report inode numbers.
+	UniqueFiles bool
+
+	// Case determines whether names are matched without regard to case. Case-insensitive matching is only available for
+	// globs created by New or NewWithOptions; other globs always match as they were created. Detection examines only
+	// the filesystem that contains root.
+	Case CaseMode
+
+	// Permission determines how the match handles directories that it is not permitted to read. On platforms whose
+	// permission bits can be checked in advance, PermissionSkip and PermissionSummarize examine each directory found by
+	// reading its parent with an extra lstat, which is shared with the check made by SameDevice.
+	Permission PermissionMode
+
+	// Junctions determines how the match handles Windows junctions and volume mount points, which are reparse points
+	// that refer to directories. Junctions has no effect on other platforms.
+	Junctions JunctionMode
+}
+
+// A JunctionMode determines how MatchOS handles Windows junctions and volume mount points.
+type JunctionMode int
+
+const (
+	// JunctionDefault handles junctions as the os package reports them. Depending on the version of Go, a junction is
+	// reported either as a symbolic link or as an irregular file; in either case, its contents are not matched.
+	JunctionDefault JunctionMode = iota
+	// JunctionSkip omits junctions from the sequence.
+	JunctionSkip
+	// JunctionDir treats each junction as a directory that may be yielded, but whose contents are not matched.
+	JunctionDir
+	// JunctionFollow treats each junction as a directory and matches its contents. To prevent cycles, a junction that
+	// refers to one of its own ancestors is not followed, and each directory is reached through at most one junction.
+	JunctionFollow
+)
+
+// A PermissionMode determines how MatchOS handles directories that it is not permitted to read.
+type PermissionMode int
+
+const (
+	// PermissionReport yields the error for each directory that cannot be read because permission is denied.
+	PermissionReport PermissionMode = iota
+	// PermissionSkip silently skips the contents of directories that cannot be read because permission is denied.
+	// Before reading a directory, the match checks the directory's permission bits against the process's user and
+	// groups, so the contents of most such directories are skipped without attempting to read them; directories that
+	// are denied for other reasons, such as access control lists, are skipped when the read fails.
+	PermissionSkip
+	// PermissionSummarize skips directories as PermissionSkip does, and then yields a single *PermissionSummaryError
+	// paired with root after the last match if any directories were skipped.
+	PermissionSummarize
+)
+
+// A PermissionSummaryError is yielded by MatchOS with PermissionSummarize if the contents of any directories were
+// skipped because permission to read them was denied. It wraps fs.ErrPermission.
+type PermissionSummaryError struct {
+	// Count is the number of directories whose contents were skipped.
+	Count int
+}
+
+func (e *PermissionSummaryError) Error() string {
+	return fmt.Sprintf("glob: permission denied reading %d directories", e.Count)
+}
+
+func (e *PermissionSummaryError) Unwrap() error {
+	return fs.ErrPermission
+}
+
+// MatchOS matches g against the contents of the operating system directory root. The paths in the returned sequence
+// are operating system paths that begin with root. On Windows, the tree is read using extended-length paths, which
+// begin with `\\?\`, so paths longer than MAX_PATH can be matched; the prefix does not appear in the returned paths.
+func MatchOS(g Glob, root string, options OSOptions) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		if options.Case == CaseInsensitive || options.Case == CaseDetect && caseInsensitive(root) {
+			folded, err := foldGlob(g)
+			if err != nil {
+				yield(root, err)
+				return
+			}
+			g = folded
+		}
+
+		matchOptions := options.MatchOptions
+		var checks []func(fs.FileInfo) bool
+		if options.SameDevice {
+			info, err := os.Stat(longPath(root))
+			if err != nil {
+				yield(root, err)
+				return
+			}
+			if dev, ok := deviceID(info); ok {
+				checks = append(checks, sameDevice(dev))
+			}
+		}
+
+		denied := 0
+		if options.Permission != PermissionReport {
+			if accessible := newAccessCheck(); accessible != nil {
+				checks = append(checks, permitted(accessible, &denied))
+			}
+		}
+		matchOptions.descend = allowDescent(matchOptions.descend, checkInfo(checks))
+
+		fsys, descend := junctionPolicy(root, options.Junctions)
+		matchOptions.descend = allowDescent(matchOptions.descend, descend)
+
+		var seen map[fileID]bool
+		if options.UniqueFiles {
+			seen = map[fileID]bool{}
+		}
+		for p, err := range MatchWith(g, fsys, ".", matchOptions) {
+			p = filepath.Join(root, filepath.FromSlash(p))
+			if seen != nil && err == nil && linkedBefore(p, seen) {
+				continue
+			}
+			if options.Permission != PermissionReport && errors.Is(err, fs.ErrPermission) {
+				denied++
+				continue
+			}
+			if !yield(p, err) {
+				return
+			}
+		}
+		if options.Permission == PermissionSummarize && denied != 0 {
+			yield(root, &PermissionSummaryError{Count: denied})
+		}
+	}
+}
+
+// checkInfo returns a descent filter that allows a directory only if each of the given checks allows its information,
+// or nil if there are no checks. The information is fetched once per directory for all of the checks. It is free for
+// directories named by literal pattern elements, whose entries were made from the information returned by a stat, but
+// costs an lstat for directories found by reading their parents.
+func checkInfo(checks []func(fs.FileInfo) bool) func(string, fs.DirEntry) bool {
+	if len(checks) == 0 {
+		return nil
+	}
+	return func(_ string, entry fs.DirEntry) bool {
+		info, err := entry.Info()
+		if err != nil {
+			// Let the traversal report the error when it reads the directory.
+			return true
+		}
+		for _, check := range checks {
+			if !check(info) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sameDevice returns a check that rejects directories that do not reside on the given device.
+func sameDevice(dev uint64) func(fs.FileInfo) bool {
+	return func(info fs.FileInfo) bool {
+		d, ok := deviceID(info)
+		return !ok || d == dev
+	}
+}
+
+// allowDescent returns a descent filter that allows a directory only if both a and b allow it. Either may be nil.
+func allowDescent(a, b func(string, fs.DirEntry) bool) func(string, fs.DirEntry) bool {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return func(p string, entry fs.DirEntry) bool {
+		return a(p, entry) && b(p, entry)
+	}
+}
+
+// permitted returns a check that rejects directories that the given check reports are not accessible and counts them
+// in denied.
+func permitted(accessible func(fs.FileInfo) bool, denied *int) func(fs.FileInfo) bool {
+	return func(info fs.FileInfo) bool {
+		if accessible(info) {
+			return true
+		}
+		*denied++
+		return false
+	}
+}
+
+// linkedBefore reports whether the file at p is a hard link to a file recorded in seen, and records it otherwise.
+// Directories and files with a single link are not recorded. If the file cannot be examined, linkedBefore reports
+// false.
+func linkedBefore(p string, seen map[fileID]bool) bool {
+	info, err := os.Lstat(longPath(p))
+	if err != nil || info.IsDir() {
+		return false
+	}
+	id, links, ok := fileIdentity(info)
+	if !ok || links < 2 {
+		return false
+	}
+	if seen[id] {
+		return true
+	}
+	seen[id] = true
+	return false
+}
+
+// foldGlob returns a case-insensitive version of g. If g does not retain its patterns, foldGlob returns g.
+func foldGlob(g Glob) (Glob, error) {
+	sg, ok := g.(sourcedGlob)
+	if !ok || sg.compileOptions().CaseInsensitive {
+		return g, nil
+	}
+	includes, excludes := sg.patterns()
+	options := sg.compileOptions()
+	options.CaseInsensitive = true
+	return NewWithOptions(includes, excludes, options)
+}
+
+// caseInsensitive reports whether the filesystem that contains root is case-insensitive. It looks for a name on the
+// path to root--or failing that, an entry in root--whose case can be changed, and checks whether the name with its
+// case changed refers to the same file. If there is no such name, caseInsensitive reports false.
+func caseInsensitive(root string) bool {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+
+	// Try the names on the path to root, starting with root itself.
+	for dir := abs; ; {
+		parent, name := filepath.Split(dir)
+		if same, ok := sameFileOtherCase(parent, name); ok {
+			return same
+		}
+		parent = filepath.Clean(parent)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// Try the entries in root.
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if same, ok := sameFileOtherCase(abs, entry.Name()); ok {
+			return same
+		}
+	}
+	return false
+}
+
+// sameFileOtherCase reports whether the given name in dir and the same name with its case changed refer to the same
+// file. The second result is false if the name's case cannot be changed or the name does not exist.
+func sameFileOtherCase(dir, name string) (same, ok bool) {
+	other := swapCase(name)
+	if other == name {
+		return false, false
+	}
+	info, err := os.Lstat(filepath.Join(dir, name))
+	if err != nil {
+		return false, false
+	}
+	otherInfo, err := os.Lstat(filepath.Join(dir, other))
+	if err != nil {
+		// If the name with its case changed does not exist, the filesystem is case-sensitive.
+		return false, errors.Is(err, fs.ErrNotExist)
+	}
+	return os.SameFile(info, otherInfo), true
+}
+
+// swapCase returns s with the case of each letter inverted.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
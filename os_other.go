@@ -0,0 +1,25 @@
+//go:build !unix
+
+package glob
+
+import "io/fs"
+
+// deviceID returns the ID of the device that contains the given file. Device IDs are not available on this platform.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// A fileID identifies a file. Files cannot be identified on this platform.
+type fileID struct{}
+
+// fileIdentity returns the identity of the given file and its number of hard links. Files cannot be identified on this
+// platform.
+func fileIdentity(info fs.FileInfo) (fileID, uint64, bool) {
+	return fileID{}, 0, false
+}
+
+// newAccessCheck returns a function that reports whether the process may list and traverse a directory. Permissions
+// cannot be checked in advance on this platform, so newAccessCheck returns nil.
+func newAccessCheck() func(fs.FileInfo) bool {
+	return nil
+}
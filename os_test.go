@@ -0,0 +1,171 @@
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	fxs "github.com/pgavlin/fx/v2/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTree creates the given files under a new temporary directory and returns the directory.
+func makeTree(t *testing.T, paths ...string) string {
+	root := t.TempDir()
+	for _, p := range paths {
+		p = filepath.Join(root, filepath.FromSlash(p))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, nil, 0o644))
+	}
+	return root
+}
+
+func TestMatchOS(t *testing.T) {
+	root := makeTree(t, "a/b.go", "a/c/d.go", "e.txt")
+
+	glob, err := New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	for _, sameDevice := range []bool{false, true} {
+		matches, err := fxs.TryCollect(MatchOS(glob, root, OSOptions{SameDevice: sameDevice}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(root, "a", "b.go"), filepath.Join(root, "a", "c", "d.go")}, matches)
+	}
+}
+
+func TestMatchOSDescend(t *testing.T) {
+	root := makeTree(t, "a/b.go", "a/c/d.go", "e/f.go")
+
+	glob, err := New([]string{"**"}, nil)
+	require.NoError(t, err)
+
+	options := OSOptions{MatchOptions: MatchOptions{Dirs: DirsPre}}
+	options.descend = func(p string, _ fs.DirEntry) bool { return p != "a/c" }
+	matches, err := fxs.TryCollect(MatchOS(glob, root, options))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "a"),
+		filepath.Join(root, "a", "b.go"),
+		filepath.Join(root, "a", "c"),
+		filepath.Join(root, "e"),
+		filepath.Join(root, "e", "f.go"),
+	}, matches)
+}
+
+func TestMatchOSCase(t *testing.T) {
+	root := makeTree(t, "photo.jpg", "PHOTO2.Jpg", "a.txt")
+
+	glob, err := New([]string{"*.JPG"}, nil)
+	require.NoError(t, err)
+
+	matches, err := fxs.TryCollect(MatchOS(glob, root, OSOptions{Case: CaseInsensitive}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "PHOTO2.Jpg"), filepath.Join(root, "photo.jpg")}, matches)
+
+	matches, err = fxs.TryCollect(MatchOS(glob, root, OSOptions{Case: CaseSensitive}))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	// Detection must agree with the filesystem.
+	_, err = os.Stat(filepath.Join(root, "PHOTO.JPG"))
+	assert.Equal(t, err == nil, caseInsensitive(root))
+}
+
+func TestMatchOSUniqueFiles(t *testing.T) {
+	root := makeTree(t, "a/x.bin", "b/y.bin")
+	if err := os.Link(filepath.Join(root, "a", "x.bin"), filepath.Join(root, "b", "x.bin")); err != nil {
+		t.Skipf("hard links are not supported: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(root, "a", "x.bin"))
+	require.NoError(t, err)
+	if _, _, ok := fileIdentity(info); !ok {
+		t.Skip("files cannot be identified on this platform")
+	}
+
+	glob, err := New([]string{"**/*.bin"}, nil)
+	require.NoError(t, err)
+
+	matches, err := fxs.TryCollect(MatchOS(glob, root, OSOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "a", "x.bin"),
+		filepath.Join(root, "b", "x.bin"),
+		filepath.Join(root, "b", "y.bin"),
+	}, matches)
+
+	matches, err = fxs.TryCollect(MatchOS(glob, root, OSOptions{UniqueFiles: true}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a", "x.bin"), filepath.Join(root, "b", "y.bin")}, matches)
+}
+
+func TestMatchOSPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for the superuser")
+	}
+
+	root := makeTree(t, "a/x.go", "b/c/y.go", "b/d/z.go")
+	for _, dir := range []string{"a", "b/c"} {
+		p := filepath.Join(root, filepath.FromSlash(dir))
+		require.NoError(t, os.Chmod(p, 0))
+		t.Cleanup(func() { os.Chmod(p, 0o755) })
+	}
+
+	glob, err := New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	var errs []error
+	for _, err := range MatchOS(glob, root, OSOptions{}) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	assert.Len(t, errs, 2)
+
+	matches, err := fxs.TryCollect(MatchOS(glob, root, OSOptions{Permission: PermissionSkip}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "b", "d", "z.go")}, matches)
+
+	var summary *PermissionSummaryError
+	for p, err := range MatchOS(glob, root, OSOptions{Permission: PermissionSummarize}) {
+		if err != nil {
+			assert.Equal(t, root, p)
+			require.ErrorAs(t, err, &summary)
+		}
+	}
+	require.NotNil(t, summary)
+	assert.Equal(t, 2, summary.Count)
+	assert.ErrorIs(t, summary, fs.ErrPermission)
+}
+
+// An infoCountingEntry is a directory entry that counts the calls to its Info method.
+type infoCountingEntry struct {
+	fs.DirEntry
+	calls *int
+}
+
+func (e infoCountingEntry) Info() (fs.FileInfo, error) {
+	*e.calls++
+	return e.DirEntry.Info()
+}
+
+func TestCheckInfo(t *testing.T) {
+	assert.Nil(t, checkInfo(nil))
+
+	info, err := fs.Stat(fstest.MapFS{"d/x": {}}, "d")
+	require.NoError(t, err)
+	calls := 0
+	entry := infoCountingEntry{DirEntry: fs.FileInfoToDirEntry(info), calls: &calls}
+
+	// The information is fetched once for all of the checks.
+	denied := 0
+	allow := func(fs.FileInfo) bool { return true }
+	deny := func(fs.FileInfo) bool { return false }
+	assert.True(t, checkInfo([]func(fs.FileInfo) bool{allow, permitted(allow, &denied)})("d", entry))
+	assert.Equal(t, 1, calls)
+	assert.False(t, checkInfo([]func(fs.FileInfo) bool{allow, permitted(deny, &denied)})("d", entry))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, denied)
+}
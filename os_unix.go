@@ -0,0 +1,62 @@
+//go:build unix
+
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"slices"
+	"syscall"
+)
+
+// deviceID returns the ID of the device that contains the given file.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// A fileID identifies a file by its device and inode numbers.
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileIdentity returns the identity of the given file and its number of hard links.
+func fileIdentity(info fs.FileInfo) (fileID, uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, 0, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, uint64(stat.Nlink), true
+}
+
+// newAccessCheck returns a function that reports whether the process may list and traverse a directory, judging by its
+// permission bits and the process's effective user and groups. Directories whose owners are unknown are accessible.
+func newAccessCheck() func(fs.FileInfo) bool {
+	groups, _ := os.Getgroups()
+	return accessCheck(os.Geteuid(), append(groups, os.Getegid()))
+}
+
+// accessCheck returns a function that reports whether the given user, a member of the given groups, may list and
+// traverse a directory.
+func accessCheck(uid int, gids []int) func(fs.FileInfo) bool {
+	return func(info fs.FileInfo) bool {
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok || uid == 0 {
+			return true
+		}
+
+		// Listing a directory requires read permission, and traversing it requires execute permission.
+		const readExecute = 0o5
+		perm := info.Mode().Perm()
+		switch {
+		case int(stat.Uid) == uid:
+			perm >>= 6
+		case slices.Contains(gids, int(stat.Gid)):
+			perm >>= 3
+		}
+		return perm&readExecute == readExecute
+	}
+}
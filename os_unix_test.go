@@ -0,0 +1,44 @@
+//go:build unix
+
+package glob
+
+import (
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessCheck(t *testing.T) {
+	dir := func(perm fs.FileMode, uid, gid uint32) fs.FileInfo {
+		return fakeInfo{mode: fs.ModeDir | perm, sys: &syscall.Stat_t{Uid: uid, Gid: gid}}
+	}
+
+	check := accessCheck(1000, []int{100, 1000})
+	assert.True(t, check(dir(0o700, 1000, 0)))
+	assert.False(t, check(dir(0o300, 1000, 0)))
+	assert.False(t, check(dir(0o600, 1000, 0)))
+	assert.True(t, check(dir(0o750, 0, 100)))
+	assert.False(t, check(dir(0o705, 0, 100)))
+	assert.True(t, check(dir(0o705, 0, 0)))
+	assert.False(t, check(dir(0o770, 0, 0)))
+	assert.True(t, check(fakeInfo{mode: fs.ModeDir}))
+
+	root := accessCheck(0, nil)
+	assert.True(t, root(dir(0o000, 1000, 1000)))
+}
+
+// fakeInfo is a FileInfo with the given mode and system data.
+type fakeInfo struct {
+	mode fs.FileMode
+	sys  any
+}
+
+func (fi fakeInfo) Name() string       { return "dir" }
+func (fi fakeInfo) Size() int64        { return 0 }
+func (fi fakeInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fakeInfo) ModTime() time.Time { return time.Time{} }
+func (fi fakeInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fakeInfo) Sys() any           { return fi.sys }
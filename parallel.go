@@ -0,0 +1,68 @@
+package glob
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+)
+
+// WalkParallel calls fn for each file under dir that matches g, using up to n goroutines to call fn and up to n
+// goroutines to traverse the tree. The traversal is divided among its goroutines by sharding the match (see Shard) on
+// the entries of dir, so each goroutine reads the subtrees assigned to it; dir itself is read by every goroutine. fsys
+// must be safe for concurrent use, and fn must be safe to call concurrently. If n is less than one, it is treated as one.
+//
+// The first error returned by fn or encountered while reading a directory cancels the context passed to fn and stops
+// the traversal, and WalkParallel returns that error once every call to fn has returned. If ctx is canceled,
+// WalkParallel stops and returns the context's error.
+func WalkParallel(ctx context.Context, fsys fs.FS, dir string, g Glob, n int, fn func(ctx context.Context, path string) error) error {
+	n = max(n, 1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	paths := make(chan string, n)
+
+	var walkers sync.WaitGroup
+	walkers.Add(n)
+	for i := range n {
+		go func() {
+			defer walkers.Done()
+
+			for p, err := range MatchWith(g, fsys, dir, MatchOptions{Shard: Shard{Index: i, Count: n}}) {
+				if err != nil {
+					cancel(err)
+					return
+				}
+				select {
+				case paths <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		walkers.Wait()
+		close(paths)
+	}()
+
+	var workers sync.WaitGroup
+	workers.Add(n)
+	for range n {
+		go func() {
+			defer workers.Done()
+
+			for p := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, p); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+	workers.Wait()
+
+	return context.Cause(ctx)
+}
@@ -0,0 +1,101 @@
+package glob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkParallel(t *testing.T) {
+	fsys := fstest.MapFS{}
+	var expected []string
+	for i := range 10 {
+		for j := range 10 {
+			fsys[fmt.Sprintf("d%d/f%d.go", i, j)] = &fstest.MapFile{}
+			fsys[fmt.Sprintf("d%d/f%d.txt", i, j)] = &fstest.MapFile{}
+			expected = append(expected, fmt.Sprintf("d%d/f%d.go", i, j))
+		}
+	}
+	g, err := New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+
+	t.Run("all", func(t *testing.T) {
+		for _, n := range []int{0, 1, 4, 16} {
+			var mu sync.Mutex
+			var paths []string
+			err := WalkParallel(context.Background(), fsys, ".", g, n, func(ctx context.Context, p string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				paths = append(paths, p)
+				return nil
+			})
+			require.NoError(t, err)
+			slices.Sort(paths)
+			assert.Equal(t, expected, paths)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// Every worker blocks until all of them are running.
+		var running atomic.Int32
+		release := make(chan struct{})
+		err := WalkParallel(context.Background(), fsys, ".", g, 4, func(ctx context.Context, p string) error {
+			if running.Add(1) == 4 {
+				close(release)
+			}
+			<-release
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := WalkParallel(context.Background(), fsys, ".", g, 4, func(ctx context.Context, p string) error {
+			if p == "d3/f3.go" {
+				return boom
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, boom)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		faulty := &errFS{FS: fsys, dir: "d5"}
+		err := WalkParallel(context.Background(), faulty, ".", g, 4, func(ctx context.Context, p string) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, fs.ErrPermission)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := WalkParallel(ctx, fsys, ".", g, 4, func(ctx context.Context, p string) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// errFS fails to read a single directory.
+type errFS struct {
+	fs.FS
+	dir string
+}
+
+func (f *errFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return fs.ReadDir(f.FS, name)
+}
@@ -0,0 +1,57 @@
+package glob
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParsePatternList splits a list of patterns separated by delim, such as the value of an environment variable like
+// 'src/**/*.go:!**/testdata', into the include and exclude patterns accepted by New. Common delimiters are ':', ';',
+// and ',':
+//
+//   - Empty patterns are ignored, so a list may begin or end with a delimiter.
+//   - A pattern that begins with '!' is an exclude. A pattern that begins with '!' must be written as '\!'.
+//   - A delimiter within a pattern must be escaped with a backslash, as in '\:'. The backslash is removed.
+//
+// Other escapes, including '\\', are preserved in the returned patterns, so the backslash that precedes an escaped
+// delimiter cannot itself be escaped. ParsePatternList returns an error if delim is '\\' or '!'.
+func ParsePatternList(s string, delim rune) (includes, excludes []string, err error) {
+	if delim == '\\' || delim == '!' {
+		return nil, nil, fmt.Errorf("glob: pattern list delimiter must not be %q", delim)
+	}
+
+	add := func(p string) {
+		switch {
+		case p == "":
+		case p[0] == '!':
+			if p = p[1:]; p != "" {
+				excludes = append(excludes, p)
+			}
+		default:
+			includes = append(includes, p)
+		}
+	}
+
+	var b strings.Builder
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case r == delim:
+			add(b.String())
+			b.Reset()
+		case r == '\\' && len(s) > 1:
+			next, nextSize := utf8.DecodeRuneInString(s[1:])
+			if next != delim {
+				b.WriteByte('\\')
+			}
+			b.WriteString(s[1 : 1+nextSize])
+			size += nextSize
+		default:
+			b.WriteString(s[:size])
+		}
+		s = s[size:]
+	}
+	add(b.String())
+	return includes, excludes, nil
+}
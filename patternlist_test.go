@@ -0,0 +1,44 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatternList(t *testing.T) {
+	cases := []struct {
+		s                  string
+		delim              rune
+		includes, excludes []string
+	}{
+		{"", ':', nil, nil},
+		{"src/**/*.go:!**/testdata", ':', []string{"src/**/*.go"}, []string{"**/testdata"}},
+		{":a::b:", ':', []string{"a", "b"}, nil},
+		{`a\;b/*.txt;!*.bak;\!x`, ';', []string{"a;b/*.txt", `\!x`}, []string{"*.bak"}},
+		{`{a\,b},c`, ',', []string{"{a,b}", "c"}, nil},
+		{`a\*,b\\,c`, ',', []string{`a\*`, `b\\`, "c"}, nil},
+		{"!,!!x", ',', nil, []string{"!x"}},
+		{"ä→b→c", '→', []string{"ä", "b", "c"}, nil},
+		{`trailing\`, ',', []string{`trailing\`}, nil},
+	}
+	for _, c := range cases {
+		includes, excludes, err := ParsePatternList(c.s, c.delim)
+		require.NoError(t, err, c.s)
+		assert.Equal(t, c.includes, includes, c.s)
+		assert.Equal(t, c.excludes, excludes, c.s)
+	}
+
+	includes, excludes, err := ParsePatternList("**/*.go,!vendor", ',')
+	require.NoError(t, err)
+	g, err := New(includes, excludes)
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("a/b.go"))
+	assert.False(t, g.MatchPath("vendor/b.go"))
+
+	for _, delim := range []rune{'\\', '!'} {
+		_, _, err := ParsePatternList("a", delim)
+		assert.Error(t, err, "%q", delim)
+	}
+}
@@ -0,0 +1,123 @@
+package glob
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteMeta returns a pattern that matches exactly the text s by escaping each metacharacter it contains with a
+// backslash. In addition to the wildcards '*', '?', and '[' and the escape character, '(' and '!' are escaped so that
+// the text cannot begin a flag group or negate an exclude pattern. A '/' cannot be escaped and still separates the
+// pattern's elements.
+func QuoteMeta(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[\(!`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// A PatternTemplate is a pattern with named placeholders, such as 'users/${id}/**', that are replaced by
+// caller-provided values. Each value is escaped with QuoteMeta as it is substituted, so a value that contains
+// metacharacters, such as 'a[1]', matches only itself rather than changing the meaning of the pattern.
+type PatternTemplate struct {
+	text  string
+	parts []patternTemplatePart
+}
+
+// A patternTemplatePart is either literal pattern text or the name of a placeholder.
+type patternTemplatePart struct {
+	literal string
+	name    string
+}
+
+// NewPatternTemplate parses a pattern template. ${name} is a placeholder for the value with the given name, which
+// consists of ASCII letters, digits, and underscores, and $$ is replaced by a literal '$'. The rest of the template is
+// pattern text and is not escaped.
+func NewPatternTemplate(template string) (*PatternTemplate, error) {
+	var parts []patternTemplatePart
+	var literal strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '$' {
+			literal.WriteByte(template[i])
+			continue
+		}
+		i++
+		switch {
+		case i == len(template):
+			return nil, fmt.Errorf("glob: template %q: trailing '$'", template)
+		case template[i] == '$':
+			literal.WriteByte('$')
+			continue
+		case template[i] != '{':
+			return nil, fmt.Errorf("glob: template %q: unexpected character %q after '$'", template, template[i])
+		}
+
+		end := strings.IndexByte(template[i:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("glob: template %q: unterminated '${'", template)
+		}
+		name := template[i+1 : i+end]
+		if !isPlaceholderName(name) {
+			return nil, fmt.Errorf("glob: template %q: invalid placeholder name %q", template, name)
+		}
+		if literal.Len() != 0 {
+			parts = append(parts, patternTemplatePart{literal: literal.String()})
+			literal.Reset()
+		}
+		parts = append(parts, patternTemplatePart{name: name})
+		i += end
+	}
+	if literal.Len() != 0 {
+		parts = append(parts, patternTemplatePart{literal: literal.String()})
+	}
+	return &PatternTemplate{text: template, parts: parts}, nil
+}
+
+// isPlaceholderName reports whether name is a valid placeholder name.
+func isPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the text of the template.
+func (t *PatternTemplate) String() string {
+	return t.text
+}
+
+// Expand returns the pattern formed by replacing each placeholder in the template with its escaped value. It is an
+// error for a placeholder to have no value. Because a '/' cannot be escaped, a value that contains one would add
+// elements to the pattern, and is also an error; a caller that means to substitute a path can split it, quote each
+// name with QuoteMeta, and join the results. The values "", ".", and ".." are errors as well, as they would remove an
+// element from the pattern or refer to the element's parent, so that 'users/${id}/**' would match every user's files.
+func (t *PatternTemplate) Expand(values map[string]string) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.name == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+
+		value, ok := values[part.name]
+		switch {
+		case !ok:
+			return "", fmt.Errorf("glob: template %q: no value for ${%v}", t.text, part.name)
+		case strings.Contains(value, "/"):
+			return "", fmt.Errorf("glob: template %q: value %q for ${%v} contains a '/'", t.text, value, part.name)
+		case value == "" || value == "." || value == "..":
+			return "", fmt.Errorf("glob: template %q: invalid value %q for ${%v}", t.text, value, part.name)
+		}
+		b.WriteString(QuoteMeta(value))
+	}
+	return b.String(), nil
+}
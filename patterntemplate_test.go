@@ -0,0 +1,68 @@
+package glob
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteMeta(t *testing.T) {
+	for _, s := range []string{"plain", "a[1]", "*?", `back\slash`, "(?i)x", "!important", "ünïcode{x}"} {
+		q := QuoteMeta(s)
+		g, err := New([]string{q}, nil)
+		require.NoError(t, err, q)
+		assert.True(t, g.MatchPath(s), q)
+
+		g, err = New([]string{"**"}, []string{q})
+		require.NoError(t, err, q)
+		assert.False(t, g.MatchPath(s), q)
+	}
+	assert.Equal(t, `a\[1]/\*`, QuoteMeta("a[1]/*"))
+	g, err := New([]string{QuoteMeta("a[1]")}, nil)
+	require.NoError(t, err)
+	assert.False(t, g.MatchPath("a1"))
+}
+
+func TestPatternTemplate(t *testing.T) {
+	tmpl, err := NewPatternTemplate("users/${id}/**/${name}-*.$$v")
+	require.NoError(t, err)
+	assert.Equal(t, "users/${id}/**/${name}-*.$$v", tmpl.String())
+
+	p, err := tmpl.Expand(map[string]string{"id": "[admin]", "name": "report*"})
+	require.NoError(t, err)
+	assert.Equal(t, `users/\[admin]/**/report\*-*.$v`, p)
+
+	g, err := New([]string{p}, nil)
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("users/[admin]/2024/report*-1.$v"))
+	assert.False(t, g.MatchPath("users/a/2024/report*-1.$v"))
+	assert.False(t, g.MatchPath("users/[admin]/2024/report-x-1.$v"))
+
+	_, err = tmpl.Expand(map[string]string{"id": "a"})
+	assert.ErrorContains(t, err, "no value for ${name}")
+	_, err = tmpl.Expand(map[string]string{"id": "../b", "name": "x"})
+	assert.ErrorContains(t, err, "contains a '/'")
+
+	// Values that would remove an element or refer to its parent could match every user's files.
+	users, err := NewPatternTemplate("users/${id}/**")
+	require.NoError(t, err)
+	for _, value := range []string{"", ".", ".."} {
+		_, err = users.Expand(map[string]string{"id": value})
+		assert.ErrorContains(t, err, "invalid value", "%q", value)
+	}
+	p, err = users.Expand(map[string]string{"id": "alice"})
+	require.NoError(t, err)
+	g, err = New([]string{p}, nil)
+	require.NoError(t, err)
+	fsys := fstest.MapFS{"users/alice/secret": {}, "users/bob/x": {}}
+	matches, err := CollectSorted(g.Match(fsys, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users/alice/secret"}, matches)
+
+	for _, text := range []string{"a$", "a$b", "${", "${}", "${a-b}"} {
+		_, err := NewPatternTemplate(text)
+		assert.Error(t, err, text)
+	}
+}
@@ -0,0 +1,93 @@
+package glob
+
+import (
+	"path"
+	"slices"
+)
+
+// A PlannedRead is a directory that a match reads.
+type PlannedRead struct {
+	// Dir is the path of the directory.
+	Dir string
+	// MayDescend is true if the match may also read directories beneath Dir that are not listed by Plan. Such
+	// directories match wildcard pattern elements, so they cannot be found without reading Dir.
+	MayDescend bool
+}
+
+// Plan returns the directories that a match of g rooted at dir reads, as far as they can be determined from g's patterns
+// without reading any directories. The directories are listed in the order in which they are read. Directories that
+// are named by literal pattern elements and skipped or looked up directly, as described by Describe, are not read and
+// are not listed. Plan does not access a filesystem, so it may list directories that do not exist.
+//
+// If g was not created by this package, Plan returns a single read of dir that may descend.
+func Plan(g Glob, dir string) []PlannedRead {
+	dir = path.Clean(dir)
+	switch g := g.(type) {
+	case *matchGlob:
+		var reads []PlannedRead
+		planDir(&reads, dir, g.include, g.exclude, exclusion{})
+		return reads
+	case allGlob:
+		return []PlannedRead{{Dir: dir, MayDescend: true}}
+	case noneGlob:
+		return nil
+	case *Layered:
+		return Plan(g.union, dir)
+	default:
+		return []PlannedRead{{Dir: dir, MayDescend: true}}
+	}
+}
+
+// planDir appends the reads for the named directory, which is matched against the given patterns. e records the
+// exclude pattern that last matched the directory or one of its ancestors.
+func planDir(reads *[]PlannedRead, dir string, include, exclude []pattern, e exclusion) {
+	if always(include) {
+		*reads = append(*reads, PlannedRead{Dir: dir, MayDescend: true})
+		return
+	}
+
+	if lit, nextInclude, ok := literal(include); ok {
+		if len(nextInclude) != 0 {
+			planChild(reads, dir, lit, include, exclude, e)
+		}
+		return
+	}
+
+	read := PlannedRead{Dir: dir}
+	var children []string
+	for _, p := range include {
+		if len(p) == 1 {
+			continue
+		}
+		if lit := p[0].text; !hasMeta(lit) && p[0].matcher.exact() {
+			if !slices.Contains(children, lit) {
+				children = append(children, lit)
+			}
+		} else {
+			read.MayDescend = true
+		}
+	}
+	*reads = append(*reads, read)
+
+	slices.Sort(children)
+	for _, child := range children {
+		planChild(reads, dir, child, include, exclude, e)
+	}
+}
+
+// planChild appends the reads for the named subdirectory of dir, which is matched against the given patterns.
+func planChild(reads *[]PlannedRead, dir, name string, include, exclude []pattern, e exclusion) {
+	var nextInclude, nextExclude []pattern
+	for _, p := range exclude {
+		if p.matchDir(name, &nextExclude) {
+			e = e.update(p)
+		}
+	}
+	if e.final(nextExclude) || excludesAll(e, nextExclude) {
+		return
+	}
+	for _, p := range include {
+		p.matchDir(name, &nextInclude)
+	}
+	planDir(reads, path.Join(dir, name), nextInclude, nextExclude, e)
+}
@@ -0,0 +1,73 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	cases := []struct {
+		includes, excludes []string
+		dir                string
+		expected           []PlannedRead
+	}{
+		{
+			includes: []string{"src/**/*.go", "docs/*.md", "cmd/glob/main.go", "cmd/glob/*.txt"},
+			excludes: []string{"**/testdata", "docs/internal"},
+			expected: []PlannedRead{
+				{Dir: "."},
+				{Dir: "cmd/glob"},
+				{Dir: "docs"},
+				{Dir: "src", MayDescend: true},
+			},
+		},
+		{
+			includes: []string{"a/b/c/*.go"},
+			dir:      "root",
+			expected: []PlannedRead{{Dir: "root/a/b/c"}},
+		},
+		{
+			includes: []string{"a/b/c.go"},
+			expected: nil,
+		},
+		{
+			includes: []string{"*/x/*.go"},
+			expected: []PlannedRead{{Dir: ".", MayDescend: true}},
+		},
+		{
+			includes: []string{"a/**", "b/*"},
+			excludes: []string{"a"},
+			expected: []PlannedRead{{Dir: "."}, {Dir: "b"}},
+		},
+		{
+			includes: []string{"**"},
+			expected: []PlannedRead{{Dir: ".", MayDescend: true}},
+		},
+	}
+	for _, c := range cases {
+		g, err := New(c.includes, c.excludes)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, Plan(g, c.dir), "%v %v", c.includes, c.excludes)
+	}
+}
+
+func TestPlanMatchesReads(t *testing.T) {
+	fsys := newReadDirFS("src/a/a.go", "src/b.go", "docs/x.md", "docs/internal/y.md", "cmd/glob/main.go", "cmd/glob/z.txt")
+	g, err := New([]string{"docs/*.md", "cmd/glob/*.txt"}, []string{"docs/internal"})
+	require.NoError(t, err)
+
+	_, err = Collect(g.Match(fsys, ".", false))
+	require.NoError(t, err)
+
+	var planned []string
+	for _, r := range Plan(g, ".") {
+		assert.False(t, r.MayDescend)
+		planned = append(planned, r.Dir)
+	}
+	for dir := range fsys.reads {
+		assert.Contains(t, planned, dir)
+	}
+	assert.Len(t, fsys.reads, len(planned))
+}
@@ -0,0 +1,109 @@
+package glob
+
+import "slices"
+
+// PresetVersion is the version of the preset pattern sets and source sets. It is incremented whenever the patterns of
+// any preset change, so that consumers that persist match results can tell when a preset has been revised.
+const PresetVersion = 1
+
+// The preset pattern sets are curated lists of exclude patterns for directories and files that are rarely meant to
+// be matched. Each preset may be appended to a list of excludes, e.g. append(excludes, glob.PresetVCS...), or combined
+// with others using slices.Concat. The presets are shared and must not be modified.
+var (
+	// PresetVCS excludes the metadata directories of version control systems.
+	PresetVCS = []string{
+		"**/.git",
+		"**/.hg",
+		"**/.svn",
+		"**/.bzr",
+		"**/.jj",
+		"**/_darcs",
+		"**/CVS",
+	}
+
+	// PresetNodeModules excludes JavaScript package installation directories.
+	PresetNodeModules = []string{
+		"**/node_modules",
+		"**/bower_components",
+		"**/jspm_packages",
+		"**/.pnpm-store",
+		"**/.yarn/cache",
+	}
+
+	// PresetBuildArtifacts excludes the output directories and intermediate files of common build tools.
+	PresetBuildArtifacts = []string{
+		"**/target",
+		"**/dist",
+		"**/build",
+		"**/out",
+		"**/obj",
+		"**/.gradle",
+		"**/.next",
+		"**/.nuxt",
+		"**/__pycache__",
+		"**/*.pyc",
+		"**/*.o",
+		"**/*.class",
+	}
+
+	// PresetVirtualEnvs excludes Python virtual environments and the environments of Python test runners.
+	PresetVirtualEnvs = []string{
+		"**/.venv",
+		"**/venv",
+		"**/.tox",
+		"**/.nox",
+	}
+)
+
+// A SourceSet is a named group of include and exclude patterns that selects the source files of a language or
+// ecosystem, such as the preset PresetGoSources. A SourceSet may be extended with additional patterns before it is
+// compiled.
+type SourceSet struct {
+	// Name describes the files that the source set selects.
+	Name string
+	// Includes and Excludes are the source set's patterns, as passed to New.
+	Includes []string
+	Excludes []string
+}
+
+// Extend returns a copy of s with the given include and exclude patterns appended to its own. s is not modified.
+func (s SourceSet) Extend(includes, excludes []string) SourceSet {
+	return SourceSet{Name: s.Name, Includes: slices.Concat(s.Includes, includes), Excludes: slices.Concat(s.Excludes, excludes)}
+}
+
+// Glob compiles the source set's patterns.
+func (s SourceSet) Glob() (Glob, error) {
+	return New(s.Includes, s.Excludes)
+}
+
+// The preset source sets select the source files of common ecosystems and exclude test fixtures, vendored code, and
+// generated output. Like the preset pattern sets, they are shared and must not be modified; use Extend to add patterns.
+var (
+	// PresetGoSources selects Go source files, excluding test fixtures and vendored packages.
+	PresetGoSources = SourceSet{
+		Name:     "Go sources",
+		Includes: []string{"**/*.go"},
+		Excludes: slices.Concat([]string{"**/testdata", "**/vendor"}, PresetVCS),
+	}
+
+	// PresetPythonSources selects Python source and stub files, excluding virtual environments, installed packages,
+	// and build output.
+	PresetPythonSources = SourceSet{
+		Name:     "Python sources",
+		Includes: []string{"**/*.py", "**/*.pyi"},
+		Excludes: slices.Concat([]string{"**/site-packages", "**/__pycache__", "**/build", "**/dist", "**/*.egg-info"},
+			PresetVirtualEnvs, PresetVCS),
+	}
+
+	// PresetWebAssets selects the scripts, stylesheets, and markup of a web application, excluding installed
+	// packages, build output, and minified files.
+	PresetWebAssets = SourceSet{
+		Name: "web assets",
+		Includes: []string{
+			"**/*.js", "**/*.mjs", "**/*.cjs", "**/*.jsx", "**/*.ts", "**/*.mts", "**/*.cts", "**/*.tsx",
+			"**/*.css", "**/*.scss", "**/*.sass", "**/*.less", "**/*.html", "**/*.vue", "**/*.svelte",
+		},
+		Excludes: slices.Concat([]string{"**/dist", "**/build", "**/.next", "**/.nuxt", "**/*.min.js", "**/*.min.css"},
+			PresetNodeModules, PresetVCS),
+	}
+)
@@ -0,0 +1,90 @@
+package glob
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets(t *testing.T) {
+	presets := slices.Concat(PresetVCS, PresetNodeModules, PresetBuildArtifacts, PresetVirtualEnvs)
+	assert.Empty(t, Check([]string{"**"}, presets))
+
+	g, err := New([]string{"**"}, presets)
+	require.NoError(t, err)
+
+	for _, p := range []string{
+		".git/HEAD",
+		"vendor/lib/.hg/store",
+		"web/node_modules/react/index.js",
+		".yarn/cache/x.zip",
+		"crate/target/debug/app",
+		"app/dist/bundle.js",
+		"pkg/__pycache__/mod.cpython-312.pyc",
+		"pkg/mod.pyc",
+		"src/Main.class",
+		".venv/bin/python",
+	} {
+		assert.False(t, g.MatchPath(p), p)
+	}
+	for _, p := range []string{
+		"src/main.go",
+		".github/workflows/ci.yml",
+		".yarn/releases/yarn.cjs",
+		"docs/building.md",
+		"targets/x",
+	} {
+		assert.True(t, g.MatchPath(p), p)
+	}
+}
+
+func TestSourceSets(t *testing.T) {
+	cases := []struct {
+		set       SourceSet
+		match     []string
+		dontMatch []string
+	}{
+		{
+			PresetGoSources,
+			[]string{"main.go", "internal/x/x_test.go"},
+			[]string{"internal/x/testdata/bad.go", "vendor/golang.org/x/y.go", "README.md"},
+		},
+		{
+			PresetPythonSources,
+			[]string{"pkg/mod.py", "pkg/mod.pyi"},
+			[]string{".venv/lib/python3.12/site-packages/x.py", "build/lib/pkg/mod.py", "pkg.egg-info/x.py", "pkg/mod.pyc"},
+		},
+		{
+			PresetWebAssets,
+			[]string{"src/App.tsx", "src/styles/main.scss", "public/index.html"},
+			[]string{"node_modules/react/index.js", "dist/app.js", "src/vendor.min.js", "src/logo.png"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.set.Name, func(t *testing.T) {
+			assert.Empty(t, Check(c.set.Includes, c.set.Excludes))
+
+			g, err := c.set.Glob()
+			require.NoError(t, err)
+			for _, p := range c.match {
+				assert.True(t, g.MatchPath(p), p)
+			}
+			for _, p := range c.dontMatch {
+				assert.False(t, g.MatchPath(p), p)
+			}
+		})
+	}
+
+	// Extending a source set does not modify the preset.
+	excludes := slices.Clone(PresetGoSources.Excludes)
+	extended := PresetGoSources.Extend([]string{"**/*.s"}, []string{"**/*_gen.go"})
+	assert.Equal(t, excludes, PresetGoSources.Excludes)
+	assert.Equal(t, []string{"**/*.go", "**/*.s"}, extended.Includes)
+
+	g, err := extended.Glob()
+	require.NoError(t, err)
+	assert.True(t, g.MatchPath("asm/add_amd64.s"))
+	assert.False(t, g.MatchPath("api/types_gen.go"))
+}
@@ -0,0 +1,97 @@
+package glob
+
+import (
+	"io/fs"
+	"iter"
+	"path"
+)
+
+// A Result is a single result of a match.
+type Result struct {
+	// Path is the path of the matching file or directory, or the path associated with Err.
+	Path string
+	// DirEntry is the directory entry for Path. It is nil if Err is non-nil.
+	DirEntry fs.DirEntry
+	// Depth is the depth of Path below the starting directory, as computed by Depth.
+	Depth int
+	// Err is an error encountered during the match, paired with Path as it would be by MatchWith.
+	Err error
+	// Parent is true if Path is not itself a match, but a directory that contains a match and is yielded because
+	// MatchOptions.Parents is set.
+	Parent bool
+}
+
+// info returns the file information for the result's path.
+func (r Result) info() (fs.FileInfo, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return r.DirEntry.Info()
+}
+
+// A resultMatcher is a Glob that can supply directory entries for its results as it matches.
+type resultMatcher interface {
+	matchResults(fsys fs.FS, dir string, options MatchOptions) iter.Seq[Result]
+}
+
+// MatchResults is like MatchWith, but yields each result as a Result. For globs created by this package, the
+// directory entry for each path is the entry read from its parent directory, so no additional filesystem operations
+// are performed, as is the case for globs that are not OptionsGlobs; the entry for the starting directory and the
+// entries for other globs are fetched with fs.Stat. If an entry cannot be fetched, the error is yielded in its place.
+func MatchResults(g Glob, fsys fs.FS, dir string, options MatchOptions) iter.Seq[Result] {
+	dir = path.Clean(dir)
+	if rm, ok := g.(resultMatcher); ok {
+		return rm.matchResults(fsys, dir, options)
+	}
+	if _, ok := g.(OptionsGlob); !ok {
+		return allGlob{}.matchResults(fsys, dir, filterOptions(g, dir, options))
+	}
+	return func(yield func(Result) bool) {
+		for p, err := range MatchWith(g, fsys, dir, options) {
+			if !yield(newResult(fsys, dir, p, nil, err)) {
+				return
+			}
+		}
+	}
+}
+
+// newResult creates the Result for a path yielded by a match of dir. If entry is nil and err is nil, the entry is
+// fetched from fsys.
+func newResult(fsys fs.FS, dir, p string, entry fs.DirEntry, err error) Result {
+	if entry == nil && err == nil {
+		var info fs.FileInfo
+		if info, err = fs.Stat(fsys, p); err == nil {
+			entry = infoToDirEntry(info)
+		}
+	}
+	return Result{Path: p, DirEntry: entry, Depth: Depth(dir, p), Err: err}
+}
+
+// walkResults matches the given patterns against the contents of dir and yields each result as a Result.
+func walkResults(fsys fs.FS, dir string, options MatchOptions, include, exclude []pattern) iter.Seq[Result] {
+	return func(yield func(Result) bool) {
+		var w *walker
+		w = newWalker(fsys, options, func(p string, err error) bool {
+			r := newResult(fsys, dir, p, w.entry, err)
+			r.Parent = w.parent
+			return yield(r)
+		})
+		w.walk(dir, include, exclude)
+	}
+}
+
+func (g *matchGlob) matchResults(fsys fs.FS, dir string, options MatchOptions) iter.Seq[Result] {
+	return walkResults(fsys, dir, options, g.include, g.exclude)
+}
+
+func (allGlob) matchResults(fsys fs.FS, dir string, options MatchOptions) iter.Seq[Result] {
+	return walkResults(fsys, dir, options, allPatterns, nil)
+}
+
+func (noneGlob) matchResults(fsys fs.FS, dir string, options MatchOptions) iter.Seq[Result] {
+	return func(_ func(Result) bool) {}
+}
+
+func (g *Layered) matchResults(fsys fs.FS, dir string, options MatchOptions) iter.Seq[Result] {
+	return g.union.matchResults(fsys, dir, g.matchOptions(dir, options))
+}
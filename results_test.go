@@ -0,0 +1,152 @@
+package glob
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	fxs "github.com/pgavlin/fx/v2/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wrappedGlob hides the implementation of a Glob created by this package.
+type wrappedGlob struct{ Glob }
+
+func TestMatchResults(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":         {Data: []byte("package main")},
+		"src/lib/lib.go":      {Data: []byte("package lib")},
+		"src/lib/lib.txt":     {},
+		"src/testdata/x.go":   {},
+		"docs/readme.md":      {},
+		"docs/guide/intro.md": {},
+	}
+
+	type result struct {
+		Path  string
+		Dir   bool
+		Size  int64
+		Depth int
+	}
+	collect := func(g Glob, dir string, options MatchOptions) []result {
+		var results []result
+		for r := range MatchResults(g, fsys, dir, options) {
+			require.NoError(t, r.Err)
+			require.NotNil(t, r.DirEntry, r.Path)
+
+			info, err := r.DirEntry.Info()
+			require.NoError(t, err)
+			assert.Equal(t, path.Base(r.Path), r.DirEntry.Name())
+			results = append(results, result{Path: r.Path, Dir: r.DirEntry.IsDir(), Size: info.Size(), Depth: r.Depth})
+		}
+		return results
+	}
+
+	mustNew := func(includes, excludes []string) Glob {
+		g, err := New(includes, excludes)
+		require.NoError(t, err)
+		return g
+	}
+
+	g := mustNew([]string{"src/**/*.go", "src/lib"}, []string{"**/testdata"})
+	expected := []result{
+		{Path: "src/lib", Dir: true, Depth: 2},
+		{Path: "src/lib/lib.go", Size: 11, Depth: 3},
+		{Path: "src/main.go", Size: 12, Depth: 2},
+	}
+	options := MatchOptions{Dirs: DirsPre}
+	assert.Equal(t, expected, collect(g, ".", options))
+	assert.Equal(t, expected, collect(wrappedGlob{g}, ".", options))
+
+	g = mustNew([]string{"main.go"}, nil)
+	assert.Equal(t, []result{{Path: "src/main.go", Size: 12, Depth: 1}}, collect(g, "src/", MatchOptions{}))
+
+	g = mustNew([]string{"**"}, []string{"**/*.go", "src"})
+	expected = []result{
+		{Path: "docs", Dir: true, Depth: 0},
+		{Path: "docs/guide", Dir: true, Depth: 1},
+		{Path: "docs/guide/intro.md", Depth: 2},
+		{Path: "docs/readme.md", Depth: 1},
+	}
+	options = MatchOptions{Dirs: DirsPre, IncludeRoot: true}
+	assert.Equal(t, expected, collect(g, "docs", options))
+	assert.Equal(t, expected[1:], collect(mustNew([]string{"**"}, nil), "docs", MatchOptions{Dirs: DirsPre}))
+
+	layered, err := NewLayered(Layer{Includes: []string{"**/*.md"}}, Layer{Excludes: []string{"docs/guide"}})
+	require.NoError(t, err)
+	assert.Equal(t, []result{{Path: "docs/readme.md", Depth: 2}}, collect(layered, ".", MatchOptions{}))
+
+	var results []Result
+	for r := range MatchResults(mustNew([]string{"*"}, nil), fsys, "missing", MatchOptions{}) {
+		results = append(results, r)
+	}
+	require.Len(t, results, 1)
+	assert.Equal(t, "missing", results[0].Path)
+	assert.Nil(t, results[0].DirEntry)
+	assert.ErrorIs(t, results[0].Err, fs.ErrNotExist)
+}
+
+func TestMatchParents(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":           {},
+		"src/lib/a/a.go":        {},
+		"src/lib/a/b/b.go":      {},
+		"src/lib/c/c.go":        {},
+		"src/lib/c/c.txt":       {},
+		"docs/guide/intro.md":   {},
+		"docs/guide/install.md": {},
+	}
+
+	type result struct {
+		Path   string
+		Dir    bool
+		Parent bool
+	}
+	collect := func(g Glob, options MatchOptions) []result {
+		options.Parents = true
+		var results []result
+		for r := range MatchResults(g, fsys, ".", options) {
+			require.NoError(t, r.Err)
+			require.NotNil(t, r.DirEntry, r.Path)
+			assert.Equal(t, path.Base(r.Path), r.DirEntry.Name())
+			results = append(results, result{Path: r.Path, Dir: r.DirEntry.IsDir(), Parent: r.Parent})
+		}
+		return results
+	}
+
+	g, err := New([]string{"**/*.go"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []result{
+		{Path: "src", Dir: true, Parent: true},
+		{Path: "src/lib", Dir: true, Parent: true},
+		{Path: "src/lib/a", Dir: true, Parent: true},
+		{Path: "src/lib/a/a.go"},
+		{Path: "src/lib/a/b", Dir: true, Parent: true},
+		{Path: "src/lib/a/b/b.go"},
+		{Path: "src/lib/c", Dir: true, Parent: true},
+		{Path: "src/lib/c/c.go"},
+		{Path: "src/main.go"},
+	}, collect(g, MatchOptions{}))
+
+	// Matching directories yielded before their contents are not also yielded as parents, and the parents named by a
+	// literal prefix are found by name.
+	g, err = New([]string{"src/lib/**"}, []string{"**/*.go"})
+	require.NoError(t, err)
+	assert.Equal(t, []result{
+		{Path: "src", Dir: true, Parent: true},
+		{Path: "src/lib", Dir: true, Parent: true},
+		{Path: "src/lib/a", Dir: true},
+		{Path: "src/lib/a/b", Dir: true},
+		{Path: "src/lib/c", Dir: true},
+		{Path: "src/lib/c/c.txt"},
+	}, collect(g, MatchOptions{Dirs: DirsPre}))
+
+	// Plain matches yield parents as paths.
+	g, err = New([]string{"docs/*/*.md"}, nil)
+	require.NoError(t, err)
+	matches, err := fxs.TryCollect(MatchWith(g, fsys, ".", MatchOptions{Parents: true}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "docs/guide", "docs/guide/install.md", "docs/guide/intro.md"}, matches)
+}
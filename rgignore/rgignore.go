@@ -0,0 +1,402 @@
+// Package rgignore implements the ignore-file semantics of ripgrep over an fs.FS, so that a tool that matches globs
+// against a tree skips the same files that ripgrep would.
+//
+// Each directory may contain ignore files named .rgignore, .ignore, and .gitignore, which use the gitignore syntax. A
+// pattern in an ignore file is matched relative to the directory that contains the file: a pattern that contains a '/'
+// other than a trailing one is anchored to that directory, and any other pattern matches names at any depth beneath
+// it. A pattern with a trailing '/' matches only directories. Within a file, the last pattern that matches a path
+// decides whether it is ignored, and a pattern that begins with '!' re-includes the paths that it matches.
+//
+// As in ripgrep, the kind of an ignore file takes precedence over its location: a path matched by a .rgignore file is
+// decided by the deepest such file, regardless of .ignore and .gitignore files; a path matched by no .rgignore file but
+// by a .ignore file is decided by the deepest .ignore file; and so on for .gitignore files, then the repository's
+// .git/info/exclude file and the global gitignore rules, and finally any additional ignore files. The git ignore rules
+// only apply within a repository, and .gitignore files above the root of a repository do not apply to it. A path
+// beneath an ignored directory is ignored. Hidden files and directories, whose names begin with '.', are ignored unless
+// the Hidden option is set; as in ripgrep, this applies only to paths that no ignore or whitelist rule matches, so a
+// rule such as '!.github/' re-includes a hidden directory.
+package rgignore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pgavlin/glob"
+)
+
+// Options controls which files an FS ignores.
+type Options struct {
+	// Hidden includes hidden files and directories, whose names begin with '.', unless they are ignored by an ignore
+	// file.
+	Hidden bool
+
+	// NoRequireGit honors .gitignore files and GitGlobal outside of git repositories. By default, these are only
+	// honored in directories that contain a .git entry or are beneath one.
+	NoRequireGit bool
+
+	// GitGlobal holds the rules of git's global ignore file, as named by its core.excludesFile setting. The rules are
+	// matched relative to the root of the repository, or to the root of the filesystem if it is not in a repository.
+	GitGlobal []glob.Rule
+
+	// IgnoreFiles holds the rules of additional ignore files, as passed to ripgrep's --ignore-file flag. They have the
+	// lowest precedence and are matched relative to the root of the filesystem.
+	IgnoreFiles []glob.Rule
+}
+
+// The kinds of per-directory ignore files, in order of decreasing precedence.
+var ignoreFileNames = [...]string{".rgignore", ".ignore", ".gitignore"}
+
+const gitignoreKind = 2
+
+// An FS is a view of a filesystem that omits the files that ripgrep would ignore. Ignored paths are reported as not
+// existing, and reading a directory returns only the entries that are not ignored, so a glob matched against an FS
+// does not descend into ignored directories. Ignore files are read once, when a path in their directory is first
+// examined.
+//
+// FS implements fs.ReadDirFS and fs.StatFS. An FS is safe for concurrent use if the underlying filesystem is.
+type FS struct {
+	fsys    fs.FS
+	options Options
+
+	gitGlobal   []rule
+	ignoreFiles []rule
+	err         error
+
+	m       sync.Mutex
+	dirs    map[string]*dirState
+	ignored map[string]bool
+}
+
+var (
+	_ = fs.ReadDirFS((*FS)(nil))
+	_ = fs.StatFS((*FS)(nil))
+)
+
+// New returns a view of fsys that omits the files that ripgrep would ignore. If the global rules are malformed, the
+// error is returned by every operation on the FS.
+func New(fsys fs.FS, options Options) *FS {
+	gitGlobal, gitErr := compileRules("core.excludesFile", options.GitGlobal)
+	ignoreFiles, ignoreErr := compileRules("ignore file", options.IgnoreFiles)
+	return &FS{
+		fsys:        fsys,
+		options:     options,
+		gitGlobal:   gitGlobal,
+		ignoreFiles: ignoreFiles,
+		err:         errors.Join(gitErr, ignoreErr),
+		dirs:        map[string]*dirState{},
+		ignored:     map[string]bool{},
+	}
+}
+
+// A rule is a compiled ignore-file pattern.
+type rule struct {
+	g       glob.Glob
+	dirOnly bool
+	negate  bool
+}
+
+// compileRules compiles the given rules, which were read from the named file.
+func compileRules(file string, rules []glob.Rule) ([]rule, error) {
+	compiled := make([]rule, 0, len(rules))
+	var errs []error
+	for _, r := range rules {
+		p, dirOnly := strings.CutSuffix(r.Pattern, "/")
+		g, err := glob.NewWithOptions([]string{p}, nil, glob.Options{Basename: true})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v:%v: %w", file, r.Line, err))
+			continue
+		}
+		compiled = append(compiled, rule{g: g, dirOnly: dirOnly, negate: r.Negate})
+	}
+	return compiled, errors.Join(errs...)
+}
+
+// match reports whether any of the given rules matches the path p, which is relative to the rules' directory, and if
+// so, whether the last rule that matches ignores the path.
+func match(rules []rule, p string, isDir bool) (matched, ignored bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		r := rules[i]
+		if (isDir || !r.dirOnly) && r.g.MatchPath(p) {
+			return true, !r.negate
+		}
+	}
+	return false, false
+}
+
+// A dirState holds the ignore files of a directory.
+type dirState struct {
+	dir    string
+	parent *dirState
+	files  [len(ignoreFileNames)][]rule
+
+	// repo is the state of the directory that contains the repository's .git entry, or nil if the directory is not in
+	// a repository. exclude holds the rules of the repository's .git/info/exclude file.
+	repo    *dirState
+	exclude []rule
+}
+
+// load returns the state of the named directory, reading its ignore files and those of its ancestors if necessary.
+// f.m must be held.
+func (f *FS) load(dir string) (*dirState, error) {
+	if d, ok := f.dirs[dir]; ok {
+		return d, nil
+	}
+
+	d := &dirState{dir: dir}
+	if dir != "." {
+		parent, err := f.load(path.Dir(dir))
+		if err != nil {
+			return nil, err
+		}
+		d.parent, d.repo = parent, parent.repo
+	}
+
+	for i, name := range ignoreFileNames {
+		rules, err := f.readRules(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		d.files[i] = rules
+	}
+	if info, err := fs.Stat(f.fsys, path.Join(dir, ".git")); err == nil {
+		// A .git file, as in a worktree, marks a repository but does not contain its exclude file.
+		d.repo = d
+		if info.IsDir() {
+			if d.exclude, err = f.readRules(path.Join(dir, ".git/info/exclude")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	f.dirs[dir] = d
+	return d, nil
+}
+
+// readRules reads and compiles the rules of the named ignore file. A missing file has no rules.
+func (f *FS) readRules(name string) ([]rule, error) {
+	rules, err := glob.ParseIgnoreFileFS(f.fsys, name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return compileRules(name, rules)
+}
+
+// Ignored reports whether the named path, which is a directory if isDir is true, is ignored: that is, whether it or one
+// of its ancestors is matched by an ignore rule. The root of the filesystem is never ignored. If an ignore file cannot
+// be read or contains a malformed pattern, Ignored returns the error.
+func (f *FS) Ignored(name string, isDir bool) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	name = path.Clean(name)
+	if name == "." {
+		return false, nil
+	}
+	if parent := path.Dir(name); parent != "." {
+		if ignored, err := f.dirIgnored(parent); ignored || err != nil {
+			return ignored, err
+		}
+	}
+	return f.decide(name, isDir)
+}
+
+// dirIgnored reports whether the named directory or one of its ancestors is ignored. f.m must be held.
+func (f *FS) dirIgnored(dir string) (bool, error) {
+	if ignored, ok := f.ignored[dir]; ok {
+		return ignored, nil
+	}
+
+	ignored := false
+	if parent := path.Dir(dir); parent != "." {
+		var err error
+		if ignored, err = f.dirIgnored(parent); err != nil {
+			return false, err
+		}
+	}
+	if !ignored {
+		var err error
+		if ignored, err = f.decide(dir, true); err != nil {
+			return false, err
+		}
+	}
+	f.ignored[dir] = ignored
+	return ignored, nil
+}
+
+// decide reports whether the named path is ignored by the rules that apply to it, without regard to its ancestors.
+// f.m must be held.
+func (f *FS) decide(name string, isDir bool) (bool, error) {
+	matched, ignored, err := f.matchRules(name, isDir)
+	if err != nil || matched {
+		return ignored, err
+	}
+	// Hidden paths are only ignored if no ignore or whitelist rule matches them.
+	return !f.options.Hidden && strings.HasPrefix(path.Base(name), "."), nil
+}
+
+// matchRules matches the named path against the ignore rules that apply to it. It reports whether any rule matched and,
+// if so, whether the path is ignored.
+func (f *FS) matchRules(name string, isDir bool) (matched, ignored bool, err error) {
+	d, err := f.load(path.Dir(name))
+	if err != nil {
+		return false, false, err
+	}
+	git := d.repo != nil || f.options.NoRequireGit
+
+	for kind := range ignoreFileNames {
+		if kind == gitignoreKind && !git {
+			continue
+		}
+		for s := d; s != nil; s = s.parent {
+			if matched, ignored := match(s.files[kind], relative(s.dir, name), isDir); matched {
+				return true, ignored, nil
+			}
+			// .gitignore files above the root of a repository do not apply to it.
+			if kind == gitignoreKind && s == d.repo {
+				break
+			}
+		}
+	}
+	if git {
+		root := "."
+		if d.repo != nil {
+			root = d.repo.dir
+			if matched, ignored := match(d.repo.exclude, relative(root, name), isDir); matched {
+				return true, ignored, nil
+			}
+		}
+		if matched, ignored := match(f.gitGlobal, relative(root, name), isDir); matched {
+			return true, ignored, nil
+		}
+	}
+	matched, ignored = match(f.ignoreFiles, name, isDir)
+	return matched, ignored, nil
+}
+
+// relative returns the path of name relative to dir, which is an ancestor of name.
+func relative(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return strings.TrimPrefix(name, dir+"/")
+}
+
+// check reports an error if the named path does not exist in the view.
+func (f *FS) check(op, name string, isDir bool) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	ignored, err := f.Ignored(name, isDir)
+	switch {
+	case err != nil:
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	case ignored:
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// Open opens the named file. Reading the entries of a directory returns only the entries that are not ignored.
+func (f *FS) Open(name string) (fs.File, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil {
+		err = f.check("open", name, info.IsDir())
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if d, ok := file.(fs.ReadDirFile); ok && info.IsDir() {
+		return &dir{ReadDirFile: d, fsys: f, name: name}, nil
+	}
+	return file, nil
+}
+
+// ReadDir reads the named directory and returns the entries that are not ignored.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.check("readdir", name, true); err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	entries, filterErr := f.filter(name, entries)
+	return entries, errors.Join(err, filterErr)
+}
+
+// filter removes the ignored entries from the given entries of the named directory.
+func (f *FS) filter(dir string, entries []fs.DirEntry) ([]fs.DirEntry, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	kept := entries[:0]
+	for _, e := range entries {
+		ignored, err := f.decide(path.Join(dir, e.Name()), e.IsDir())
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: dir, Err: err}
+		}
+		if !ignored {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+// Stat returns information about the named file.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.check("stat", name, info.IsDir()); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// A dir is an open directory of an FS.
+type dir struct {
+	fs.ReadDirFile
+	fsys *FS
+	name string
+}
+
+// ReadDir reads the entries of the directory that are not ignored.
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		entries, filterErr := d.fsys.filter(d.name, entries)
+		return entries, errors.Join(err, filterErr)
+	}
+
+	var kept []fs.DirEntry
+	for len(kept) < n {
+		entries, err := d.ReadDirFile.ReadDir(n - len(kept))
+		entries, filterErr := d.fsys.filter(d.name, entries)
+		if filterErr != nil {
+			return kept, filterErr
+		}
+		kept = append(kept, entries...)
+		if err != nil {
+			if len(kept) != 0 && errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return kept, err
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,155 @@
+package rgignore
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pgavlin/glob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func file(text string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(text)}
+}
+
+func visible(t *testing.T, fsys fs.FS) []string {
+	g, err := glob.New([]string{"**"}, nil)
+	require.NoError(t, err)
+	paths, err := glob.CollectSorted(g.Match(fsys, ".", false))
+	require.NoError(t, err)
+	return paths
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":          file("ref: refs/heads/main\n"),
+		".git/info/exclude":  file("*.swp\n"),
+		".gitignore":         file("*.log\nbuild/\n/root-only.txt\n!keep.log\n"),
+		".ignore":            file("generated/\n"),
+		".env":               file(""),
+		"a.log":              file(""),
+		"keep.log":           file(""),
+		"root-only.txt":      file(""),
+		"main.go":            file(""),
+		"main.go.swp":        file(""),
+		"build/out":          file(""),
+		"src/build":          file(""),
+		"src/root-only.txt":  file(""),
+		"src/debug.log":      file(""),
+		"src/generated/x.go": file(""),
+		"src/.gitignore":     file("!debug.log\n"),
+		"docs/.rgignore":     file("!*.log\n"),
+		"docs/.ignore":       file("*.md\n"),
+		"docs/notes.log":     file(""),
+		"docs/readme.md":     file(""),
+		"docs/guide.txt":     file(""),
+	}
+
+	assert.Equal(t, []string{
+		"docs/guide.txt",
+		"docs/notes.log",
+		"keep.log",
+		"main.go",
+		"src/build",
+		"src/debug.log",
+		"src/root-only.txt",
+	}, visible(t, New(fsys, Options{})))
+
+	// Hidden files are included on request, but remain subject to ignore files.
+	hidden := visible(t, New(fsys, Options{Hidden: true}))
+	assert.Contains(t, hidden, ".env")
+	assert.Contains(t, hidden, ".git/HEAD")
+	assert.NotContains(t, hidden, "a.log")
+}
+
+func TestWhitelistHidden(t *testing.T) {
+	fsys := fstest.MapFS{
+		".ignore":           file("!.github/\n!.golangci.yml\n"),
+		".github/ci.yml":    file(""),
+		".golangci.yml":     file(""),
+		".env":              file(""),
+		".cache/x":          file(""),
+		"main.go":           file(""),
+		"sub/.ignore":       file("!.config/\n"),
+		"sub/.config/a.yml": file(""),
+		"sub/.hidden":       file(""),
+	}
+
+	// A whitelist rule re-includes a hidden path; other hidden paths remain ignored.
+	assert.Equal(t, []string{
+		".github/ci.yml",
+		".golangci.yml",
+		"main.go",
+		"sub/.config/a.yml",
+	}, visible(t, New(fsys, Options{})))
+}
+
+func TestRequireGit(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore": file("*.log\n"),
+		".ignore":    file("*.tmp\n"),
+		"a.log":      file(""),
+		"b.tmp":      file(""),
+		"c.txt":      file(""),
+	}
+	assert.Equal(t, []string{"a.log", "c.txt"}, visible(t, New(fsys, Options{})))
+	assert.Equal(t, []string{"c.txt"}, visible(t, New(fsys, Options{NoRequireGit: true})))
+
+	// .gitignore files above the root of a repository do not apply to it.
+	fsys["repo/.git/HEAD"] = file("")
+	fsys["repo/x.log"] = file("")
+	fsys["repo/.gitignore"] = file("*.txt\n")
+	fsys["repo/y.txt"] = file("")
+	assert.Equal(t, []string{"a.log", "c.txt", "repo/x.log"}, visible(t, New(fsys, Options{})))
+}
+
+func TestGlobalRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":  file(""),
+		".gitignore": file("!*.bak\n"),
+		"a.bak":      file(""),
+		"b.orig":     file(""),
+		"c.out":      file(""),
+		"d.txt":      file(""),
+	}
+	options := Options{
+		GitGlobal:   []glob.Rule{{Pattern: "*.bak"}, {Pattern: "*.orig"}},
+		IgnoreFiles: []glob.Rule{{Pattern: "*.out"}, {Pattern: "*.txt", Negate: true}},
+	}
+	assert.Equal(t, []string{"a.bak", "d.txt"}, visible(t, New(fsys, options)))
+
+	_, err := New(fsys, Options{IgnoreFiles: []glob.Rule{{Pattern: "[", Line: 3}}}).ReadDir(".")
+	assert.ErrorContains(t, err, "ignore file:3")
+}
+
+func TestFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":      file(""),
+		".gitignore":     file("vendor/\n*.o\n"),
+		"main.c":         file(""),
+		"main.o":         file(""),
+		"lib/lib.c":      file(""),
+		"lib/lib.o":      file(""),
+		"vendor/dep/x.c": file(""),
+	}
+	view := New(fsys, Options{})
+	require.NoError(t, fstest.TestFS(view, "main.c", "lib/lib.c"))
+
+	for _, name := range []string{"main.o", "vendor", "vendor/dep/x.c", ".gitignore"} {
+		_, err := view.Stat(name)
+		assert.ErrorIs(t, err, fs.ErrNotExist, name)
+		_, err = view.Open(name)
+		assert.ErrorIs(t, err, fs.ErrNotExist, name)
+	}
+
+	ignored, err := view.Ignored("vendor/dep", true)
+	require.NoError(t, err)
+	assert.True(t, ignored)
+
+	// An ignore file that cannot be read is reported.
+	fsys["lib/.gitignore/x"] = file("")
+	_, err = New(fsys, Options{}).ReadDir("lib")
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package glob
+
+import "strings"
+
+// A scopeGlob is a Glob that can report which directories it may match beneath a directory, and can therefore bound a
+// traversal when it is used as MatchOptions.Scope.
+type scopeGlob interface {
+	// scope reports whether the glob matches the directory with the given names and whether it may match a directory
+	// beneath it. The starting directory has no names.
+	scope(names []string) (matched, mayContain bool)
+}
+
+// scopeFunc returns the scope function for the given glob, or nil if the glob is nil. If the glob was not created by
+// this package, every directory may contain a directory that it matches.
+func scopeFunc(g Glob) func(names []string) (bool, bool) {
+	if g == nil {
+		return nil
+	}
+	if sg, ok := g.(scopeGlob); ok {
+		return sg.scope
+	}
+	return func(names []string) (bool, bool) {
+		return len(names) != 0 && g.MatchPath(strings.Join(names, "/")), true
+	}
+}
+
+func (g *matchGlob) scope(names []string) (bool, bool) {
+	if len(names) == 0 {
+		return always(g.include) && !excludesAll(exclusion{}, g.exclude), true
+	}
+	return g.MatchPath(strings.Join(names, "/")), mayContain(g.include, names)
+}
+
+func (allGlob) scope(names []string) (bool, bool) {
+	return true, true
+}
+
+func (noneGlob) scope(names []string) (bool, bool) {
+	return false, false
+}
+
+func (g *Layered) scope(names []string) (bool, bool) {
+	_, mayContain := scopeFunc(g.union)(names)
+	return g.decide(names).Matched, mayContain
+}
+
+// mayContain reports whether any of the given patterns may match a path beneath the directory with the given names.
+// Exclude patterns are not considered.
+func mayContain(patterns []pattern, names []string) bool {
+	for _, name := range names {
+		var next []pattern
+		for _, p := range patterns {
+			p.matchDir(name, &next)
+		}
+		if len(next) == 0 {
+			return false
+		}
+		patterns = next
+	}
+	return true
+}
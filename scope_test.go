@@ -0,0 +1,83 @@
+package glob
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	fxs "github.com/pgavlin/fx/v2/slices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobScope(t *testing.T) {
+	paths := []string{
+		"services/a/migrations/001.sql",
+		"services/a/migrations/old/000.sql",
+		"services/a/schema.sql",
+		"services/b/migrations/001.sql",
+		"services/b/migrations/README.md",
+		"tools/gen/x.sql",
+		"top.sql",
+	}
+
+	cases := []struct {
+		includes []string
+		scope    []string
+		dirs     DirMode
+		expected []string
+		reads    []string
+	}{
+		{
+			includes: []string{"**/*.sql"},
+			scope:    []string{"services/*/migrations"},
+			expected: []string{"services/a/migrations/001.sql", "services/a/migrations/old/000.sql", "services/b/migrations/001.sql"},
+			reads:    []string{".", "services", "services/a", "services/a/migrations", "services/a/migrations/old", "services/b", "services/b/migrations"},
+		},
+		{
+			includes: []string{"**"},
+			scope:    []string{"services/*/migrations"},
+			dirs:     DirsPre,
+			expected: []string{"services/a/migrations", "services/a/migrations/001.sql", "services/a/migrations/old", "services/a/migrations/old/000.sql", "services/b/migrations", "services/b/migrations/001.sql", "services/b/migrations/README.md"},
+		},
+		{
+			includes: []string{"services/a/migrations/*.sql", "tools/gen/*.sql"},
+			scope:    []string{"services/*/migrations"},
+			expected: []string{"services/a/migrations/001.sql"},
+			reads:    []string{".", "services/a/migrations"},
+		},
+		{
+			includes: []string{"**/*.sql"},
+			scope:    []string{"**"},
+			expected: []string{"services/a/migrations/001.sql", "services/a/migrations/old/000.sql", "services/a/schema.sql", "services/b/migrations/001.sql", "tools/gen/x.sql", "top.sql"},
+		},
+	}
+	for _, c := range cases {
+		g, err := New(c.includes, nil)
+		require.NoError(t, err)
+		scope, err := New(c.scope, nil)
+		require.NoError(t, err)
+
+		fsys := newReadDirFS(paths...)
+		matches, err := fxs.TryCollect(MatchWith(g, fsys, ".", MatchOptions{Dirs: c.dirs, Scope: scope}))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v %v", c.includes, c.scope)
+		if c.reads != nil {
+			assert.Equal(t, c.reads, slices.Sorted(maps.Keys(fsys.reads)), "%v %v", c.includes, c.scope)
+		}
+
+		// A scope that was not created by this package gives the same results, except that it never matches the starting
+		// directory.
+		if slices.Contains(c.scope, "**") {
+			continue
+		}
+		matches, err = fxs.TryCollect(MatchWith(g, newReadDirFS(paths...), ".", MatchOptions{Dirs: c.dirs, Scope: foreignGlob{scope}}))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matches, "%v %v", c.includes, c.scope)
+	}
+}
+
+// foreignGlob hides the unexported methods of a glob.
+type foreignGlob struct {
+	Glob
+}
@@ -0,0 +1,502 @@
+package glob
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A segmentMatcher is a compiled form of a single pattern element.
+//
+// The element is represented as a list of chunks separated by '*'. Each chunk matches a fixed number of characters, so
+// a name can be matched by anchoring the first and last chunks and finding the leftmost match of each of the chunks in
+// between. No backtracking is required, and matching takes time proportional to the length of the name multiplied by
+// the length of the longest chunk.
+//
+// Common forms of elements--literals, '*', and elements of the form 'prefix*', '*suffix', or '*infix*' where the
+// prefix, suffix, or infix contains no wildcards--are matched using simple string comparisons instead.
+//
+// A case-insensitive matcher holds the case-folded form of its literal text and folds each name before matching it. A
+// byte-oriented matcher treats each byte of a name as a single character. A matcher with a transform applies it to each
+// name before matching it. A custom matcher delegates to the SegmentMatcher compiled by a SegmentSyntax.
+type segmentMatcher struct {
+	kind         segmentKind
+	literal      string
+	chunks       []chunk
+	leadingStar  bool
+	trailingStar bool
+	fold         bool
+	bytes        bool
+	transform    func(string) string
+	custom       SegmentMatcher
+}
+
+type segmentKind int
+
+const (
+	segmentGeneral segmentKind = iota
+	segmentLiteral
+	segmentAny
+	segmentPrefix
+	segmentSuffix
+	segmentContains
+	segmentCustom
+)
+
+// A chunk is a sequence of atoms that contains no '*'.
+type chunk []atom
+
+type atomKind int
+
+const (
+	atomLiteral atomKind = iota
+	atomAny
+	atomClass
+)
+
+// An atom is a run of literal text, a '?', or a character class.
+type atom struct {
+	kind    atomKind
+	literal string
+	class   charClass
+}
+
+// A charClass is a compiled character class.
+type charClass struct {
+	negated bool
+	fold    bool
+	bytes   bool
+	ranges  []runeRange
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+func (c *charClass) contains(r rune) bool {
+	in := c.inRanges(r)
+	switch {
+	case c.fold && c.bytes:
+		// Only ASCII letters are folded in byte-oriented classes.
+		if 'A' <= r && r <= 'Z' {
+			in = in || c.inRanges(r-'A'+'a')
+		}
+	case c.fold:
+		// Any character that folds to r is in the class.
+		for f := unicode.SimpleFold(r); f != r && !in; f = unicode.SimpleFold(f) {
+			in = c.inRanges(f)
+		}
+	}
+	return in != c.negated
+}
+
+func (c *charClass) inRanges(r rune) bool {
+	for _, rr := range c.ranges {
+		if rr.lo <= r && r <= rr.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// compileSegment compiles a single pattern element. The syntax is that of path.Match, except that a character class
+// may also be negated with '!'. If the element is malformed, compileSegment returns a *SyntaxError, which wraps
+// path.ErrBadPattern, whose range is relative to the element.
+func compileSegment(p string) (*segmentMatcher, error) {
+	return compileSegmentWith(p, Options{})
+}
+
+// compileSegmentWith compiles a single pattern element using the given options.
+func compileSegmentWith(p string, options Options) (*segmentMatcher, error) {
+	for _, syntax := range options.Segments {
+		custom, ok, err := syntax.CompileSegment(p)
+		if err != nil {
+			return nil, syntaxError(0, len(p), "%v", err)
+		}
+		if ok {
+			return &segmentMatcher{kind: segmentCustom, custom: custom, transform: options.Transform}, nil
+		}
+	}
+
+	m, err := compileText(p, options)
+	if err != nil {
+		return nil, err
+	}
+	m.transform = options.Transform
+	return m, nil
+}
+
+// compileText compiles the text of a pattern element, which has been transformed if the options have a Transform.
+func compileText(p string, options Options) (*segmentMatcher, error) {
+	if options.Transform != nil {
+		p = options.Transform(p)
+	}
+	fold, bytes, element := options.CaseInsensitive, options.Bytes, len(p)
+
+	// Split the element into the chunks between each run of '*'.
+	parts := []chunk{nil}
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() != 0 {
+			text := literal.String()
+			if fold {
+				text = foldText(text, bytes)
+			}
+			parts[len(parts)-1] = append(parts[len(parts)-1], atom{kind: atomLiteral, literal: text})
+			literal.Reset()
+		}
+	}
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			flushLiteral()
+			parts = append(parts, nil)
+			p = strings.TrimLeft(p, "*")
+		case '?':
+			flushLiteral()
+			parts[len(parts)-1] = append(parts[len(parts)-1], atom{kind: atomAny})
+			p = p[1:]
+		case '[':
+			flushLiteral()
+			class, rest, err := compileClass(p[1:], bytes)
+			if err != nil {
+				return nil, shiftError(err, element-len(p)+1)
+			}
+			class.fold, class.bytes = fold, bytes
+			parts[len(parts)-1] = append(parts[len(parts)-1], atom{kind: atomClass, class: class})
+			p = rest
+		case '\\':
+			if len(p) == 1 {
+				return nil, syntaxError(element-1, element, "'\\' at end of element")
+			}
+			literal.WriteByte(p[1])
+			p = p[2:]
+		default:
+			literal.WriteByte(p[0])
+			p = p[1:]
+		}
+	}
+	flushLiteral()
+
+	if len(parts) == 1 {
+		m := &segmentMatcher{chunks: parts, fold: fold, bytes: bytes}
+		if lit, ok := parts[0].literalText(); ok {
+			m.kind, m.literal = segmentLiteral, lit
+		}
+		return m, nil
+	}
+
+	first, last := parts[0], parts[len(parts)-1]
+	m := &segmentMatcher{leadingStar: len(first) == 0, trailingStar: len(last) == 0, fold: fold, bytes: bytes}
+	for _, c := range parts {
+		if len(c) != 0 {
+			m.chunks = append(m.chunks, c)
+		}
+	}
+
+	switch {
+	case len(m.chunks) == 0:
+		m.kind = segmentAny
+	case len(m.chunks) == 1 && m.leadingStar && m.trailingStar:
+		if lit, ok := m.chunks[0].literalText(); ok {
+			m.kind, m.literal = segmentContains, lit
+		}
+	case len(m.chunks) == 1 && m.trailingStar:
+		if lit, ok := m.chunks[0].literalText(); ok {
+			m.kind, m.literal = segmentPrefix, lit
+		}
+	case len(m.chunks) == 1 && m.leadingStar:
+		if lit, ok := m.chunks[0].literalText(); ok {
+			m.kind, m.literal = segmentSuffix, lit
+		}
+	}
+	return m, nil
+}
+
+// compileClass compiles the character class that begins just after the '[' at the start of p. It returns the class
+// and the input that follows the closing ']'. If bytes is true, each byte of the class is a single character. If the
+// class is malformed, the range of the returned *SyntaxError is relative to p, so an unterminated class begins at -1.
+func compileClass(p string, bytes bool) (charClass, string, error) {
+	input := len(p)
+
+	var class charClass
+	if len(p) > 0 && (p[0] == '^' || p[0] == '!') {
+		class.negated, p = true, p[1:]
+	}
+	for first := true; ; first = false {
+		if len(p) > 0 && p[0] == ']' && !first {
+			return class, p[1:], nil
+		}
+		if len(p) == 0 {
+			return charClass{}, "", syntaxError(-1, input, "unterminated character class")
+		}
+		lo, rest, err := classChar(p, bytes)
+		if err != nil {
+			return charClass{}, "", shiftError(err, input-len(p))
+		}
+		hi := lo
+		if len(rest) > 0 && rest[0] == '-' {
+			if len(rest) == 1 {
+				return charClass{}, "", syntaxError(-1, input, "unterminated character class")
+			}
+			offset := input - len(rest) + 1
+			if hi, rest, err = classChar(rest[1:], bytes); err != nil {
+				return charClass{}, "", shiftError(err, offset)
+			}
+		}
+		class.ranges, p = append(class.ranges, runeRange{lo: lo, hi: hi}), rest
+	}
+}
+
+// classChar reads a possibly-escaped character from a character class. p must not be empty. If the character is
+// malformed, the range of the returned *SyntaxError is relative to p.
+func classChar(p string, bytes bool) (rune, string, error) {
+	if p[0] == '-' || p[0] == ']' {
+		return 0, "", syntaxError(0, 1, "unexpected %q in character class", p[0])
+	}
+	escape := 0
+	if p[0] == '\\' {
+		p, escape = p[1:], 1
+		if len(p) == 0 {
+			return 0, "", syntaxError(0, 1, "'\\' at end of character class")
+		}
+	}
+	if bytes {
+		return rune(p[0]), p[1:], nil
+	}
+	r, n := utf8.DecodeRuneInString(p)
+	if r == utf8.RuneError && n == 1 {
+		return 0, "", syntaxError(escape, escape+1, "invalid UTF-8 in character class")
+	}
+	return r, p[n:], nil
+}
+
+// exact reports whether the element matches names exactly as they are written, without folding or transforming them.
+// The literal text of an exact element is the name of the file it matches.
+func (m *segmentMatcher) exact() bool {
+	return !m.fold && m.transform == nil && m.kind != segmentCustom
+}
+
+// prefix returns the literal text with which every name that matches the element begins. Elements that are not exact
+// have no prefix.
+func (m *segmentMatcher) prefix() string {
+	if !m.exact() || m.leadingStar || len(m.chunks) == 0 || len(m.chunks[0]) == 0 || m.chunks[0][0].kind != atomLiteral {
+		return ""
+	}
+	return m.chunks[0][0].literal
+}
+
+// match reports whether name matches the compiled element.
+func (m *segmentMatcher) match(name string) bool {
+	if m.transform != nil {
+		name = m.transform(name)
+	}
+	if m.fold {
+		name = foldText(name, m.bytes)
+	}
+
+	switch m.kind {
+	case segmentLiteral:
+		return name == m.literal
+	case segmentAny:
+		return true
+	case segmentPrefix:
+		return strings.HasPrefix(name, m.literal)
+	case segmentSuffix:
+		return strings.HasSuffix(name, m.literal)
+	case segmentContains:
+		return strings.Contains(name, m.literal)
+	case segmentCustom:
+		return m.custom.MatchSegment(name)
+	}
+
+	chunks := m.chunks
+	if !m.leadingStar && !m.trailingStar && len(chunks) == 1 {
+		// There is no '*' in the element.
+		n, ok := chunks[0].matchPrefix(name, m.bytes)
+		return ok && n == len(name)
+	}
+
+	if !m.leadingStar {
+		n, ok := chunks[0].matchPrefix(name, m.bytes)
+		if !ok {
+			return false
+		}
+		name, chunks = name[n:], chunks[1:]
+	}
+
+	var last chunk
+	if !m.trailingStar {
+		last, chunks = chunks[len(chunks)-1], chunks[:len(chunks)-1]
+	}
+
+	for _, c := range chunks {
+		i, n, ok := c.find(name, m.bytes)
+		if !ok {
+			return false
+		}
+		name = name[i+n:]
+	}
+
+	if last == nil {
+		return true
+	}
+	start, ok := last.suffixStart(name, m.bytes)
+	if !ok {
+		return false
+	}
+	n, ok := last.matchPrefix(name[start:], m.bytes)
+	return ok && start+n == len(name)
+}
+
+// literalText returns the text matched by the chunk if the chunk contains no wildcards.
+func (c chunk) literalText() (string, bool) {
+	switch {
+	case len(c) == 0:
+		return "", true
+	case len(c) == 1 && c[0].kind == atomLiteral:
+		return c[0].literal, true
+	default:
+		return "", false
+	}
+}
+
+// runes returns the number of characters matched by the chunk. If bytes is true, each byte is a character.
+func (c chunk) runes(bytes bool) int {
+	n := 0
+	for _, a := range c {
+		switch {
+		case a.kind == atomLiteral && bytes:
+			n += len(a.literal)
+		case a.kind == atomLiteral:
+			n += utf8.RuneCountInString(a.literal)
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// matchPrefix matches the chunk against the start of s, returning the number of bytes matched.
+func (c chunk) matchPrefix(s string, bytes bool) (int, bool) {
+	n := 0
+	for _, a := range c {
+		switch a.kind {
+		case atomLiteral:
+			if !strings.HasPrefix(s[n:], a.literal) {
+				return 0, false
+			}
+			n += len(a.literal)
+		default:
+			if n == len(s) {
+				return 0, false
+			}
+			r, size := decodeChar(s[n:], bytes)
+			if a.kind == atomClass && !a.class.contains(r) {
+				return 0, false
+			}
+			n += size
+		}
+	}
+	return n, true
+}
+
+// find returns the offset and length of the leftmost match of the chunk in s.
+func (c chunk) find(s string, bytes bool) (int, int, bool) {
+	for i := 0; i <= len(s); {
+		if n, ok := c.matchPrefix(s[i:], bytes); ok {
+			return i, n, true
+		}
+		if i == len(s) {
+			break
+		}
+		_, size := decodeChar(s[i:], bytes)
+		i += size
+	}
+	return 0, 0, false
+}
+
+// suffixStart returns the offset in s of the suffix that contains exactly as many characters as the chunk matches.
+func (c chunk) suffixStart(s string, bytes bool) (int, bool) {
+	n := c.runes(bytes)
+	if bytes {
+		return len(s) - n, n <= len(s)
+	}
+
+	i := len(s)
+	for ; n > 0; n-- {
+		if i == 0 {
+			return 0, false
+		}
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return i, true
+}
+
+// decodeChar returns the first character in s and its size. If bytes is true, the character is the first byte of s.
+func decodeChar(s string, bytes bool) (rune, int) {
+	if bytes {
+		return rune(s[0]), 1
+	}
+	return utf8.DecodeRuneInString(s)
+}
+
+// foldText returns the case-folded form of s. If bytes is true, only ASCII letters are folded.
+func foldText(s string, bytes bool) string {
+	if !bytes {
+		return foldString(s)
+	}
+	folded := []byte(s)
+	for i, c := range folded {
+		if 'a' <= c && c <= 'z' {
+			folded[i] = c - 'a' + 'A'
+		}
+	}
+	return string(folded)
+}
+
+// foldString returns the case-folded form of s. Two strings are equal under Unicode simple case folding if and only if
+// their folded forms are equal. Each character is replaced by the smallest character that is equivalent to it under
+// simple folding; bytes that are not valid UTF-8 are preserved.
+func foldString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		f := foldRune(r)
+		if f == r {
+			if b.Cap() != 0 {
+				b.WriteString(s[i : i+size])
+			}
+		} else {
+			if b.Cap() == 0 {
+				b.Grow(len(s))
+				b.WriteString(s[:i])
+			}
+			b.WriteRune(f)
+		}
+		i += size
+	}
+	if b.Cap() == 0 {
+		return s
+	}
+	return b.String()
+}
+
+// foldRune returns the smallest character that is equivalent to r under simple case folding.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}
+
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
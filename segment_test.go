@@ -0,0 +1,195 @@
+package glob
+
+import (
+	"math/rand/v2"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentMatcher(t *testing.T) {
+	patterns := []string{
+		"", "*", "**", "?", "a", "abc", "a*", "*a", "a*b", "a*b*c", "*a*", "a?c", "??", "*.go", "test_*", "a*a*a*b",
+		"[abc]", "[^abc]", "[a-c]x", "x[^a-c]*", `\*`, `a\?b`, "[\\]]", "ä*", "*ö", "?ü?", "[α-ω]*",
+	}
+	names := []string{
+		"", "a", "b", "ab", "abc", "abcc", "aab", "ac", "abbc", "x.go", "test_x", "aaab", "aaaa", "*", "a?b", "]",
+		"xd", "xa", "äb", "bö", "aüb", "β", "\xff", "a\xffb",
+	}
+	for _, p := range patterns {
+		m, err := compileSegment(p)
+		if !assert.NoError(t, err, p) {
+			continue
+		}
+		for _, n := range names {
+			expected, _ := path.Match(p, n)
+			assert.Equal(t, expected, m.match(n), "%q against %q", p, n)
+		}
+	}
+}
+
+func TestSegmentMatcherBangClass(t *testing.T) {
+	for _, c := range [][2]string{{"[!abc]", "[^abc]"}, {"x[!a-c]*", "x[^a-c]*"}, {"[!!]", "[^!]"}} {
+		bang, err := compileSegment(c[0])
+		require.NoError(t, err)
+		caret, err := compileSegment(c[1])
+		require.NoError(t, err)
+		for _, n := range []string{"a", "d", "!", "xa", "xd", "xdd", ""} {
+			assert.Equal(t, caret.match(n), bang.match(n), "%q against %q", c[0], n)
+		}
+	}
+
+	m, err := compileSegment("[a!]")
+	require.NoError(t, err)
+	assert.True(t, m.match("!"))
+}
+
+func TestSegmentMatcherRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	alphabet := []string{"a", "b", "*", "?", "[ab]", "[^a]", "ä"}
+	for range 10000 {
+		var p, n strings.Builder
+		for range r.IntN(6) {
+			p.WriteString(alphabet[r.IntN(len(alphabet))])
+		}
+		for range r.IntN(8) {
+			n.WriteString([]string{"a", "b", "ä"}[r.IntN(3)])
+		}
+		m, err := compileSegment(p.String())
+		if !assert.NoError(t, err) {
+			continue
+		}
+		expected, _ := path.Match(p.String(), n.String())
+		assert.Equal(t, expected, m.match(n.String()), "%q against %q", p.String(), n.String())
+	}
+}
+
+func TestSegmentMatcherErrors(t *testing.T) {
+	for _, p := range []string{"[", "[]", "[^]", "[a", "[-a]", "[a-]", `\`, `[\`, "a[b-"} {
+		_, err := compileSegment(p)
+		assert.ErrorIs(t, err, path.ErrBadPattern, p)
+		_, expected := path.Match(p, "")
+		assert.ErrorIs(t, expected, path.ErrBadPattern, p)
+	}
+}
+
+func TestSegmentMatcherAdversarial(t *testing.T) {
+	m, err := compileSegment("a*a*a*a*a*a*a*a*a*b")
+	assert.NoError(t, err)
+	assert.False(t, m.match(strings.Repeat("a", 1<<20)))
+	assert.True(t, m.match(strings.Repeat("a", 1<<20)+"b"))
+}
+
+func TestSegmentMatcherKinds(t *testing.T) {
+	cases := []struct {
+		pattern string
+		kind    segmentKind
+		literal string
+	}{
+		{"", segmentLiteral, ""},
+		{"main.go", segmentLiteral, "main.go"},
+		{`a\*b`, segmentLiteral, "a*b"},
+		{"*", segmentAny, ""},
+		{"***", segmentAny, ""},
+		{"*.go", segmentSuffix, ".go"},
+		{"test_*", segmentPrefix, "test_"},
+		{"*_test.*", segmentContains, "_test."},
+		{"*_test.go*x", segmentGeneral, ""},
+		{"a*b", segmentGeneral, ""},
+		{"*.[ch]", segmentGeneral, ""},
+	}
+	for _, c := range cases {
+		m, err := compileSegment(c.pattern)
+		if assert.NoError(t, err, c.pattern) {
+			assert.Equal(t, c.kind, m.kind, c.pattern)
+			assert.Equal(t, c.literal, m.literal, c.pattern)
+		}
+	}
+}
+
+func TestSegmentMatcherFold(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"*.JPG", "photo.jpg", true},
+		{"*.jpg", "PHOTO.JPG", true},
+		{"Makefile", "makefile", true},
+		{"make*", "MAKEFILE", true},
+		{"*FILE*", "makefile.in", true},
+		{"[A-C]x", "bX", true},
+		{"[^A-C]x", "bx", false},
+		{"[^a-c]x", "dX", true},
+		{"k", "K", true},
+		{"s*", "ſx", true},
+		{"straße", "STRASSE", false},
+		{"\xffa", "\xffA", true},
+		{"a?c", "AbC", true},
+		{"a", "b", false},
+	}
+	for _, c := range cases {
+		m, err := compileSegmentWith(c.pattern, Options{CaseInsensitive: true})
+		if assert.NoError(t, err, c.pattern) {
+			assert.Equal(t, c.matches, m.match(c.name), "%q against %q", c.pattern, c.name)
+		}
+	}
+}
+
+func TestSegmentMatcherBytes(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		fold    bool
+		matches bool
+	}{
+		{"?", "\xe9", false, true},
+		{"?", "é", false, false},
+		{"??", "é", false, true},
+		{"caf?", "caf\xe9", false, true},
+		{"caf?", "café", false, false},
+		{"caf*", "caf\xe9\xff", false, true},
+		{"*\xe9", "caf\xe9", false, true},
+		{"[\xe0-\xff]", "\xe9", false, true},
+		{"[^\xe9]x", "\xe8x", false, true},
+		{"[é]", "\xc3", false, true},
+		{"a*?b", "a\xffb", false, true},
+		{"*?\xff", "\xff", false, false},
+		{"CAF?", "caf\xe9", true, true},
+		{"[A-C]\xe9", "b\xe9", true, true},
+		{"\xc9", "\xe9", true, false},
+	}
+	for _, c := range cases {
+		m, err := compileSegmentWith(c.pattern, Options{CaseInsensitive: c.fold, Bytes: true})
+		if assert.NoError(t, err, c.pattern) {
+			assert.Equal(t, c.matches, m.match(c.name), "%q against %q", c.pattern, c.name)
+		}
+	}
+
+	// A class that holds invalid UTF-8 is malformed unless matching bytes.
+	_, err := compileSegment("[\xff]")
+	assert.Error(t, err)
+}
+
+func BenchmarkSegmentMatch(b *testing.B) {
+	cases := []struct{ pattern, name string }{
+		{"*.go", "segment_test.go"},
+		{"test_*_[a-z]?.go", "test_segment_ab.go"},
+		{"a*a*a*a*a*a*a*a*b", strings.Repeat("a", 32)},
+	}
+	for _, c := range cases {
+		b.Run(c.pattern+"/path.Match", func(b *testing.B) {
+			for b.Loop() {
+				path.Match(c.pattern, c.name)
+			}
+		})
+		b.Run(c.pattern+"/match", func(b *testing.B) {
+			for b.Loop() {
+				match(c.pattern, c.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"iter"
+	"path"
+)
+
+// A Session evaluates many globs against a single filesystem. The session caches the directory listings and lookups
+// performed by its matches, so globs that visit the same directories do not read them again. This suits workloads
+// such as editors that evaluate many globs against the same tree in quick succession.
+//
+// A Session does not observe changes to the filesystem; call Invalidate when a path changes. A Session is safe for
+// concurrent use if its filesystem is.
+type Session struct {
+	fsys *CachingFS
+}
+
+// NewSession creates a session that evaluates globs against fsys.
+func NewSession(fsys fs.FS) *Session {
+	return &Session{fsys: NewCachingFS(fsys)}
+}
+
+// FS returns the session's caching view of its filesystem.
+func (s *Session) FS() fs.FS {
+	return s.fsys
+}
+
+// Match is like g.Match, but matches against the session's filesystem.
+func (s *Session) Match(g Glob, dir string, includeDirs bool) iter.Seq2[string, error] {
+	return g.Match(s.fsys, dir, includeDirs)
+}
+
+// MatchWith is like the MatchWith function, but matches against the session's filesystem.
+func (s *Session) MatchWith(g Glob, dir string, options MatchOptions) iter.Seq2[string, error] {
+	return MatchWith(g, s.fsys, dir, options)
+}
+
+// MatchPath reports whether the path p beneath dir matches g and exists in the session's filesystem. p is relative to
+// dir, as it is for g.MatchPath. The path is looked up only if it matches.
+func (s *Session) MatchPath(g Glob, dir, p string) (bool, error) {
+	if !g.MatchPath(p) {
+		return false, nil
+	}
+	names := splitPath(p)
+	if len(names) == 0 {
+		return false, nil
+	}
+	if _, err := s.fsys.Stat(path.Join(append([]string{dir}, names...)...)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Invalidate discards the session's cached information about the named path, the paths beneath it, and the listing
+// of its parent directory. It should be called when the path is created, removed, or modified.
+func (s *Session) Invalidate(name string) {
+	s.fsys.Invalidate(name)
+}
@@ -0,0 +1,70 @@
+package glob
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"src/a.go":      {},
+		"src/a_test.go": {},
+		"src/lib/b.go":  {},
+		"docs/c.md":     {},
+	}
+	fsys := &countingFS{MapFS: mapFS, reads: map[string]int{}, stats: map[string]int{}}
+	s := NewSession(fsys)
+
+	goFiles, err := New([]string{"**/*.go"}, []string{"**/*_test.go"})
+	require.NoError(t, err)
+	tests, err := New([]string{"src/**/*_test.go"}, nil)
+	require.NoError(t, err)
+
+	matches, err := Collect(s.Match(goFiles, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a.go", "src/lib/b.go"}, matches)
+	matches, err = Collect(s.MatchWith(tests, ".", MatchOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a_test.go"}, matches)
+	assert.Equal(t, map[string]int{".": 1, "src": 1, "src/lib": 1, "docs": 1}, fsys.reads)
+
+	for _, c := range []struct {
+		glob     Glob
+		path     string
+		expected bool
+	}{
+		{goFiles, "src/a.go", true},
+		{goFiles, "./src/lib/b.go", true},
+		{goFiles, "src/missing.go", false},
+		{goFiles, "src/a_test.go", false},
+		{tests, "src/a_test.go", true},
+	} {
+		matched, err := s.MatchPath(c.glob, ".", c.path)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, matched, c.path)
+	}
+
+	// Changes are only observed once they are invalidated.
+	mapFS["src/lib/d.go"] = &fstest.MapFile{}
+	delete(mapFS, "src/a.go")
+	matches, err = Collect(s.Match(goFiles, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/a.go", "src/lib/b.go"}, matches)
+
+	s.Invalidate("src/lib/d.go")
+	s.Invalidate("src/a.go")
+	matches, err = Collect(s.Match(goFiles, ".", false))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/lib/b.go", "src/lib/d.go"}, matches)
+	matched, err := s.MatchPath(goFiles, ".", "src/a.go")
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	entries, err := fs.ReadDir(s.FS(), "src")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
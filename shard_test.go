@@ -0,0 +1,72 @@
+package glob
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobShard(t *testing.T) {
+	var paths []string
+	for i := range 8 {
+		for j := range 4 {
+			paths = append(paths, fmt.Sprintf("d%v/e%v/f.go", i, j), fmt.Sprintf("d%v/e%v.txt", i, j))
+		}
+		paths = append(paths, fmt.Sprintf("f%v.go", i))
+	}
+
+	for _, includes := range [][]string{{"**"}, {"**/*.go"}, {"d1/**", "d2/e3/*.go", "f*.go"}} {
+		g, err := New(includes, nil)
+		require.NoError(t, err)
+
+		expected, err := CollectSorted(MatchWith(g, newReadDirFS(paths...), ".", MatchOptions{Dirs: DirsPre}))
+		require.NoError(t, err)
+
+		for _, depth := range []int{0, 1, 2, 3} {
+			const count = 3
+
+			var union []string
+			for index := range count {
+				shard := Shard{Index: index, Count: count, Depth: depth}
+				fsys := newReadDirFS(paths...)
+				matches, err := Collect(MatchWith(g, fsys, ".", MatchOptions{Dirs: DirsPre, Shard: shard}))
+				require.NoError(t, err)
+				assert.NotEqual(t, len(expected), len(matches), "%v %v", includes, shard)
+				for _, p := range matches {
+					assert.True(t, shard.Contains(p), "%v %v", p, shard)
+				}
+				union = append(union, matches...)
+
+				// Each shard reads only the subtrees assigned to it.
+				for read := range fsys.reads {
+					assert.True(t, Depth(".", read) < max(depth, 1) || shard.Contains(read), "%v %v", read, shard)
+				}
+			}
+			slices.Sort(union)
+			assert.Equal(t, expected, union, "%v %v", includes, depth)
+		}
+	}
+}
+
+func TestShardContains(t *testing.T) {
+	shard := Shard{Index: 1, Count: 4, Depth: 2}
+	assert.Equal(t, shard.Contains("a/b"), shard.Contains("a/b/c/d"))
+	assert.Equal(t, shard.Contains("a/b"), shard.Contains("/a/b/"))
+	assert.True(t, Shard{}.Contains("a"))
+
+	var counts [4]int
+	for i := range 1000 {
+		for index := range counts {
+			if (Shard{Index: index, Count: len(counts)}).Contains(fmt.Sprint(i)) {
+				counts[index]++
+			}
+		}
+	}
+	assert.Equal(t, 1000, counts[0]+counts[1]+counts[2]+counts[3])
+	for _, c := range counts {
+		assert.Greater(t, c, 200)
+	}
+}
@@ -0,0 +1,85 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// A SyntaxError describes a malformed pattern. It wraps path.ErrBadPattern, so errors.Is(err, path.ErrBadPattern)
+// reports true for a *SyntaxError. The errors returned by New and NewWithOptions for malformed patterns are
+// *SyntaxErrors joined with errors.Join.
+type SyntaxError struct {
+	// Pattern is the malformed pattern, including the leading '!' of a negated exclude pattern.
+	Pattern string
+	// Exclude is true if the pattern is an exclude pattern.
+	Exclude bool
+	// Index is the index of the pattern in its list.
+	Index int
+	// Start and End are the byte offsets within Pattern of the malformed text. End is greater than Start unless the
+	// pattern is malformed because it ends too soon, in which case both are the length of the pattern.
+	Start, End int
+	// Message describes the problem.
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%v %q at offset %d: %v", path.ErrBadPattern, e.Pattern, e.Start, e.Message)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return path.ErrBadPattern
+}
+
+// Parse checks the syntax of the given include and exclude patterns, which are interpreted as they are by
+// NewWithOptions, and returns a *SyntaxError for each malformed pattern, include patterns first. Each pattern is
+// parsed independently, so an error in one pattern does not prevent the others from being checked. Parse is suitable
+// for reporting problems with patterns as they are edited; unlike New, it reports every error with its pattern and the
+// range of the pattern's text that is malformed.
+func Parse(includes, excludes []string, options Options) []*SyntaxError {
+	var errs []*SyntaxError
+	for i, p := range includes {
+		if _, err := compilePattern(p, options); err != nil {
+			errs = append(errs, locateError(err, p, false, i))
+		}
+	}
+	for i, p := range excludes {
+		text, _ := strings.CutPrefix(p, "!")
+		if _, err := compilePattern(text, options); err != nil {
+			errs = append(errs, locateError(err, p, true, i))
+		}
+	}
+	return errs
+}
+
+// syntaxError returns a *SyntaxError for the byte range [start, end) of a pattern.
+func syntaxError(start, end int, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Start: start, End: end, Message: fmt.Sprintf(format, args...)}
+}
+
+// shiftError returns err with its range moved n bytes later if it is a *SyntaxError.
+func shiftError(err error, n int) error {
+	var se *SyntaxError
+	if !errors.As(err, &se) {
+		return err
+	}
+	shifted := *se
+	shifted.Start, shifted.End = shifted.Start+n, shifted.End+n
+	return &shifted
+}
+
+// locateError returns the *SyntaxError for an error in compiling the given pattern. If the pattern is a negated exclude
+// pattern, the error's range is moved past the leading '!'.
+func locateError(err error, p string, exclude bool, index int) *SyntaxError {
+	if exclude && strings.HasPrefix(p, "!") {
+		err = shiftError(err, 1)
+	}
+	var se *SyntaxError
+	if !errors.As(err, &se) {
+		se = syntaxError(0, len(p), "%v", err)
+	}
+	located := *se
+	located.Pattern, located.Exclude, located.Index = p, exclude, index
+	return &located
+}
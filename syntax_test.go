@@ -0,0 +1,44 @@
+package glob
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	errs := Parse(
+		[]string{"src/**/*.go", "src/[a-", `a/b\`, "(?x)a", "(?i", "a/**{x}/b", "a/**{2,1}", "[a-]", "**/*.md"},
+		[]string{"**/testdata", "!x/[]"},
+		Options{},
+	)
+	assert.Equal(t, []*SyntaxError{
+		{Pattern: "src/[a-", Index: 1, Start: 4, End: 7, Message: "unterminated character class"},
+		{Pattern: `a/b\`, Index: 2, Start: 3, End: 4, Message: `'\' at end of element`},
+		{Pattern: "(?x)a", Index: 3, Start: 2, End: 3, Message: `unknown flag 'x'`},
+		{Pattern: "(?i", Index: 4, Start: 0, End: 3, Message: "unterminated flag group"},
+		{Pattern: "a/**{x}/b", Index: 5, Start: 5, End: 6, Message: `invalid '**' bound "x"`},
+		{Pattern: "a/**{2,1}", Index: 6, Start: 7, End: 8, Message: `invalid '**' bound "1"`},
+		{Pattern: "[a-]", Index: 7, Start: 3, End: 4, Message: `unexpected ']' in character class`},
+		{Pattern: "!x/[]", Exclude: true, Index: 1, Start: 4, End: 5, Message: `unexpected ']' in character class`},
+	}, errs)
+
+	assert.Empty(t, Parse([]string{"**/*.go", `a/\[x]`}, []string{"!**/keep"}, Options{}))
+}
+
+func TestSyntaxError(t *testing.T) {
+	_, err := New([]string{"src/*.go", "src/[a-"}, []string{"!x/[]"})
+	require.ErrorIs(t, err, path.ErrBadPattern)
+
+	var se *SyntaxError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, &SyntaxError{Pattern: "src/[a-", Index: 1, Start: 4, End: 7, Message: "unterminated character class"}, se)
+	assert.Equal(t, `syntax error in pattern "src/[a-" at offset 4: unterminated character class`, se.Error())
+
+	_, err = New([]string{"src/*.go"}, []string{"**/testdata", "!x/[]"})
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, &SyntaxError{Pattern: "!x/[]", Exclude: true, Index: 1, Start: 4, End: 5, Message: `unexpected ']' in character class`}, se)
+}
@@ -0,0 +1,269 @@
+package glob
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A TranslationError is returned when a glob cannot be expressed in the syntax of another tool.
+type TranslationError struct {
+	// Tool is the tool for which the glob was being translated.
+	Tool string
+	// Pattern is the pattern that cannot be translated, or empty if the glob as a whole cannot be translated.
+	Pattern string
+	// Reason describes why the glob cannot be translated.
+	Reason string
+}
+
+func (e *TranslationError) Error() string {
+	if e.Pattern == "" {
+		return fmt.Sprintf("glob: cannot translate glob for %v: %v", e.Tool, e.Reason)
+	}
+	return fmt.Sprintf("glob: cannot translate pattern %q for %v: %v", e.Pattern, e.Tool, e.Reason)
+}
+
+// A translatedPattern is a pattern whose alternatives have been split into element texts for translation.
+type translatedPattern struct {
+	source       string
+	alternatives [][]string
+	fold         bool
+}
+
+// translatePatterns splits the patterns of g for translation for the named tool. Options that no tool can express are
+// rejected, as are negated excludes; case-insensitive patterns are rejected unless fold is true.
+func translatePatterns(g Glob, tool string, fold bool) (includes, excludes []translatedPattern, err error) {
+	sg, ok := g.(sourcedGlob)
+	if !ok {
+		return nil, nil, &TranslationError{Tool: tool, Reason: "the glob was not created by New or NewWithOptions"}
+	}
+	options := sg.compileOptions()
+	switch {
+	case options.Transform != nil:
+		return nil, nil, &TranslationError{Tool: tool, Reason: "the glob has a Transform"}
+	case len(options.Segments) != 0:
+		return nil, nil, &TranslationError{Tool: tool, Reason: "the glob has custom segment syntaxes"}
+	case options.Bytes:
+		return nil, nil, &TranslationError{Tool: tool, Reason: "the glob matches bytes"}
+	}
+
+	split := func(p string) (translatedPattern, error) {
+		text, options, err := parseFlags(p, options)
+		if err != nil {
+			return translatedPattern{}, err
+		}
+		switch {
+		case options.ExplicitDot:
+			return translatedPattern{}, &TranslationError{Tool: tool, Pattern: p, Reason: "wildcards do not match a leading '.'"}
+		case options.CaseInsensitive && !fold:
+			return translatedPattern{}, &TranslationError{Tool: tool, Pattern: p, Reason: "the pattern is case-insensitive"}
+		}
+
+		alternatives, err := compilePattern(text, options)
+		if err != nil {
+			return translatedPattern{}, err
+		}
+		t := translatedPattern{source: p, fold: options.CaseInsensitive}
+		for _, steps := range alternatives {
+			elements := make([]string, len(steps))
+			for i, s := range steps {
+				elements[i] = s.text
+				if !s.isGlobstar() {
+					elements[i] = translateElement(s.text)
+				}
+			}
+			t.alternatives = append(t.alternatives, elements)
+		}
+		return t, nil
+	}
+
+	sourceIncludes, sourceExcludes := sg.patterns()
+	for _, p := range sourceIncludes {
+		t, err := split(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		includes = append(includes, t)
+	}
+	for _, p := range sourceExcludes {
+		if strings.HasPrefix(p, "!") {
+			return nil, nil, &TranslationError{Tool: tool, Pattern: p, Reason: "negated excludes cannot be expressed"}
+		}
+		t, err := split(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		excludes = append(excludes, t)
+	}
+	return includes, excludes, nil
+}
+
+// translateElement rewrites a pattern element for other tools. Each run of '*' characters outside of a character class
+// is replaced with a single '*', as a '**' within an element matches like '*' but other tools treat it as a globstar,
+// and braces, which are literal within an element but denote alternatives to other tools, are escaped.
+func translateElement(text string) string {
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			b.WriteByte(c)
+			i++
+			c = text[i]
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			if i+1 < len(text) && (text[i+1] == '^' || text[i+1] == '!') {
+				b.WriteByte(c)
+				i++
+				c = text[i]
+			}
+		case c == '*' && i > 0 && text[i-1] == '*':
+			continue
+		case c == '{' || c == '}':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// RipgrepArgs translates g into arguments for ripgrep's --glob flag, such as ["--glob", "/src/**/*.go", "--glob",
+// "!/**/testdata"]. Include patterns are translated into globs that select files, and exclude patterns into negated
+// globs that skip files and directories; every glob is anchored to the directory being searched. Case-insensitive
+// patterns are translated using --iglob.
+//
+// The arguments reproduce g's matches only if ripgrep also searches hidden files and does not honor ignore files, as
+// it does when run with --hidden and --no-ignore. Globs with negated exclude patterns, with options other than
+// CaseInsensitive and Basename, or that were not created by New or NewWithOptions cannot be translated.
+func RipgrepArgs(g Glob) ([]string, error) {
+	includes, excludes, err := translatePatterns(g, "ripgrep", true)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	add := func(t translatedPattern, negate string) {
+		flag := "--glob"
+		if t.fold {
+			flag = "--iglob"
+		}
+		for _, elements := range t.alternatives {
+			glob := strings.Join(elements, "/")
+			if elements[0] != "**" {
+				glob = "/" + glob
+			}
+			args = append(args, flag, negate+glob)
+		}
+	}
+	for _, t := range includes {
+		add(t, "")
+	}
+	if len(includes) == 0 {
+		args = append(args, "--glob", "!**")
+	}
+	for _, t := range excludes {
+		add(t, "!")
+	}
+	return args, nil
+}
+
+// RsyncArgs translates g into filter arguments for rsync, such as ["--exclude", "/build", "--include", "*/",
+// "--include", "/src/*.go", "--exclude", "*"]. Exclude patterns are listed first, followed by a rule that includes every
+// directory so that rsync descends into them, the include patterns, and a final rule that excludes everything else.
+// Every pattern is anchored to the root of the transfer, and each '**' that may match no directories is expanded into
+// a rule with and a rule without it.
+//
+// Because every directory is included, rsync should be run with --prune-empty-dirs to omit directories that contain no
+// matches. Globs with negated exclude patterns, with options other than Basename, or that were not created by New or
+// NewWithOptions cannot be translated.
+func RsyncArgs(g Glob) ([]string, error) {
+	includes, excludes, err := translatePatterns(g, "rsync", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	add := func(flag string, t translatedPattern) error {
+		var expanded [][]string
+		for _, elements := range t.alternatives {
+			expanded = append(expanded, expandGlobstars(elements)...)
+			if len(expanded) > maxAlternatives {
+				return &TranslationError{Tool: "rsync", Pattern: t.source, Reason: "too many alternatives"}
+			}
+		}
+		for _, elements := range expanded {
+			args = append(args, flag, "/"+strings.Join(elements, "/"))
+		}
+		return nil
+	}
+	for _, t := range excludes {
+		if err := add("--exclude", t); err != nil {
+			return nil, err
+		}
+	}
+	args = append(args, "--include", "*/")
+	for _, t := range includes {
+		if err := add("--include", t); err != nil {
+			return nil, err
+		}
+	}
+	return append(args, "--exclude", "*"), nil
+}
+
+// expandGlobstars expands each '**' element that is not the last element into the alternatives with and without it.
+func expandGlobstars(elements []string) [][]string {
+	alternatives := [][]string{nil}
+	for i, e := range elements {
+		var next [][]string
+		for _, alt := range alternatives {
+			next = append(next, append(alt[:len(alt):len(alt)], e))
+			if e == "**" && i != len(elements)-1 {
+				next = append(next, alt)
+			}
+		}
+		alternatives = next
+	}
+	return alternatives
+}
+
+// TarExcludeFile translates g's exclude patterns into the contents of an exclude file for GNU tar, one pattern per
+// line, for use with tar's --exclude-from (-X) flag. g's include patterns are not translated, as tar archives the files
+// named on its command line: pass it the paths yielded by a match of g to archive exactly those files.
+//
+// The file reproduces g's excludes when tar is run from the directory being matched with --no-wildcards-match-slash and
+// the archive is created from '.', so that the names of its members begin with './': for example,
+// 'tar -c --no-wildcards-match-slash -X excludes .'. Patterns that begin with '**' are matched at any depth; other
+// patterns are anchored to the directory with a './' prefix. A '**' is only translated at the start or end of a
+// pattern. Globs with negated exclude patterns, with options other than Basename, or that were not created by New or
+// NewWithOptions cannot be translated.
+func TarExcludeFile(g Glob) (string, error) {
+	_, excludes, err := translatePatterns(g, "tar", false)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, t := range excludes {
+		for _, elements := range t.alternatives {
+			prefix := "./"
+			if elements[0] == "**" {
+				prefix, elements = "", elements[1:]
+			}
+			if len(elements) == 0 {
+				elements = []string{"*"}
+			}
+			if elements[len(elements)-1] == "**" {
+				elements = append(elements[:len(elements)-1:len(elements)-1], "*")
+			}
+			for _, e := range elements {
+				if e == "**" {
+					return "", &TranslationError{Tool: "tar", Pattern: t.source, Reason: "'**' is only supported at the start or end of a pattern"}
+				}
+			}
+			b.WriteString(prefix + strings.Join(elements, "/") + "\n")
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,105 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRipgrepArgs(t *testing.T) {
+	cases := []struct {
+		includes, excludes []string
+		options            Options
+		expected           []string
+	}{
+		{[]string{"src/**/*.go"}, []string{"**/testdata"}, Options{}, []string{"--glob", "/src/**/*.go", "--glob", "!**/testdata"}},
+		{[]string{"**"}, nil, Options{}, []string{"--glob", "**"}},
+		{nil, []string{"vendor"}, Options{}, []string{"--glob", "!**", "--glob", "!/vendor"}},
+		{[]string{"*.go"}, nil, Options{Basename: true}, []string{"--glob", "**/*.go"}},
+		{[]string{"(?i)*.md", "a**b"}, nil, Options{}, []string{"--iglob", "/*.md", "--glob", "/a*b"}},
+		{[]string{`a/**{1,2}/\{x\}`}, nil, Options{}, []string{"--glob", `/a/*/\{x\}`, "--glob", `/a/*/*/\{x\}`}},
+		{[]string{"[*]**[!a]"}, nil, Options{}, []string{"--glob", "/[*]*[!a]"}},
+	}
+	for _, c := range cases {
+		g, err := NewWithOptions(c.includes, c.excludes, c.options)
+		require.NoError(t, err)
+
+		args, err := RipgrepArgs(g)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, args, "%v %v", c.includes, c.excludes)
+	}
+}
+
+func TestRsyncArgs(t *testing.T) {
+	g, err := New([]string{"src/**/*.go", "docs/**"}, []string{"**/testdata"})
+	require.NoError(t, err)
+	args, err := RsyncArgs(g)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--exclude", "/**/testdata",
+		"--exclude", "/testdata",
+		"--include", "*/",
+		"--include", "/src/**/*.go",
+		"--include", "/src/*.go",
+		"--include", "/docs/**",
+		"--exclude", "*",
+	}, args)
+
+	g, err = New([]string{"(?i)*.md"}, nil)
+	require.NoError(t, err)
+	_, err = RsyncArgs(g)
+	var terr *TranslationError
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, TranslationError{Tool: "rsync", Pattern: "(?i)*.md", Reason: "the pattern is case-insensitive"}, *terr)
+}
+
+func TestTarExcludeFile(t *testing.T) {
+	g, err := New([]string{"**"}, []string{"**/node_modules", "build/**", "*.log", "**"})
+	require.NoError(t, err)
+	file, err := TarExcludeFile(g)
+	require.NoError(t, err)
+	assert.Equal(t, "node_modules\n./build/*\n./*.log\n*\n", file)
+
+	g, err = New(nil, []string{"a/**/b"})
+	require.NoError(t, err)
+	_, err = TarExcludeFile(g)
+	var terr *TranslationError
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, "tar", terr.Tool)
+	assert.Equal(t, "a/**/b", terr.Pattern)
+}
+
+func TestTranslateErrors(t *testing.T) {
+	translators := map[string]func(g Glob) error{
+		"ripgrep": func(g Glob) error { _, err := RipgrepArgs(g); return err },
+		"rsync":   func(g Glob) error { _, err := RsyncArgs(g); return err },
+		"tar":     func(g Glob) error { _, err := TarExcludeFile(g); return err },
+	}
+
+	newGlob := func(includes, excludes []string, options Options) Glob {
+		g, err := NewWithOptions(includes, excludes, options)
+		require.NoError(t, err)
+		return g
+	}
+	layered, err := NewLayered(Layer{Includes: []string{"*"}})
+	require.NoError(t, err)
+
+	globs := map[string]Glob{
+		"layered":        layered,
+		"negated":        newGlob(nil, []string{"**/*.go", "!main.go"}, Options{}),
+		"explicit dot":   newGlob(nil, []string{"(?-d)*"}, Options{}),
+		"bytes":          newGlob(nil, []string{"*"}, Options{Bytes: true}),
+		"transform":      newGlob(nil, []string{"*"}, Options{Transform: func(name string) string { return name }}),
+		"segment syntax": newGlob(nil, []string{"*"}, Options{Segments: []SegmentSyntax{regexpSyntax{}}}),
+	}
+	for tool, translate := range translators {
+		for name, g := range globs {
+			err := translate(g)
+			var terr *TranslationError
+			if assert.ErrorAs(t, err, &terr, "%v %v", tool, name) {
+				assert.Equal(t, tool, terr.Tool)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package glob
+
+import (
+	"errors"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+)
+
+// A Tree is a node in a directory tree assembled from the paths in a sequence of matches.
+type Tree struct {
+	// Name is the last element of the node's path. The name of the root is the directory that was matched.
+	Name string
+	// Path is the node's path, as it would be yielded by the match.
+	Path string
+	// Matched is true if the match yielded the node's path. Nodes that are only the ancestors of matching paths are not
+	// matched.
+	Matched bool
+	// Children lists the node's children, sorted by name.
+	Children []*Tree
+}
+
+// CollectTree assembles the paths in the given sequence of matches of dir into a tree rooted at dir. The paths may be
+// yielded in any order, and each directory that contains a matching path is added to the tree whether or not it was
+// itself yielded. Any errors in the sequence are joined and returned alongside the tree.
+func CollectTree(dir string, matches iter.Seq2[string, error]) (*Tree, error) {
+	dir = path.Clean(dir)
+	root := &Tree{Name: dir, Path: dir}
+	var errs []error
+	for p, err := range matches {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		n := root
+		for name := range strings.SplitSeq(relativePath(dir, path.Clean(p)), "/") {
+			if name != "" {
+				n = n.child(name)
+			}
+		}
+		n.Matched = true
+	}
+	return root, errors.Join(errs...)
+}
+
+// child returns the child of t with the given name, adding it if necessary.
+func (t *Tree) child(name string) *Tree {
+	i, ok := slices.BinarySearchFunc(t.Children, name, func(c *Tree, name string) int {
+		return strings.Compare(c.Name, name)
+	})
+	if !ok {
+		t.Children = slices.Insert(t.Children, i, &Tree{Name: name, Path: path.Join(t.Path, name)})
+	}
+	return t.Children[i]
+}
+
+// All returns a sequence of the nodes in the tree rooted at t, including t itself. Each node is yielded before its
+// children, and children are yielded in order by name.
+func (t *Tree) All() iter.Seq[*Tree] {
+	return func(yield func(*Tree) bool) {
+		stack := []*Tree{t}
+		for len(stack) != 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(n) {
+				return
+			}
+			for _, c := range slices.Backward(n.Children) {
+				stack = append(stack, c)
+			}
+		}
+	}
+}
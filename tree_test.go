@@ -0,0 +1,54 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectTree(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":     {},
+		"src/lib/lib.go":  {},
+		"src/lib/lib.txt": {},
+		"src/z.go":        {},
+		"docs/readme.md":  {},
+	}
+	g, err := New([]string{"**/*.go", "src/lib"}, nil)
+	require.NoError(t, err)
+
+	tree, err := CollectTree(".", MatchWith(g, fsys, ".", MatchOptions{Dirs: DirsPost}))
+	require.NoError(t, err)
+
+	var nodes []string
+	for n := range tree.All() {
+		nodes = append(nodes, fmt.Sprintf("%v %v %v", n.Path, n.Name, n.Matched))
+	}
+	assert.Equal(t, []string{
+		". . false",
+		"src src false",
+		"src/lib lib true",
+		"src/lib/lib.go lib.go true",
+		"src/main.go main.go true",
+		"src/z.go z.go true",
+	}, nodes)
+
+	tree, err = CollectTree("src/", g.Match(fsys, "src", false))
+	require.NoError(t, err)
+	assert.Equal(t, &Tree{Name: "src", Path: "src", Children: []*Tree{
+		{Name: "lib", Path: "src/lib", Children: []*Tree{{Name: "lib.go", Path: "src/lib/lib.go", Matched: true}}},
+		{Name: "main.go", Path: "src/main.go", Matched: true},
+		{Name: "z.go", Path: "src/z.go", Matched: true},
+	}}, tree)
+
+	errBroken := errors.New("broken")
+	tree, err = CollectTree(".", func(yield func(string, error) bool) {
+		_ = yield("a/b", nil) && yield("a", errBroken)
+	})
+	assert.ErrorIs(t, err, errBroken)
+	assert.Equal(t, []*Tree{{Name: "b", Path: "a/b", Matched: true}}, tree.Children[0].Children)
+}
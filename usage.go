@@ -0,0 +1,64 @@
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// A Usage is the number and total size of a set of files.
+type Usage struct {
+	// Files is the number of files.
+	Files int
+	// Bytes is the sum of the sizes of the files.
+	Bytes int64
+}
+
+// add adds a file of the given size to the usage.
+func (u *Usage) add(size int64) {
+	u.Files++
+	u.Bytes += size
+}
+
+// DiskUsage sums the sizes of the files under dir that match g, as du does. If byTopLevel is true, DiskUsage also
+// returns the usage beneath each entry of dir, keyed by the entry's name; a matching file in dir itself is its own
+// entry. Otherwise, the returned map is nil.
+//
+// Each file's size is read from the directory entry found by the match, so each file's information is fetched at most
+// once, and no additional filesystem operations are performed for filesystems whose directory entries carry it.
+// As with du, symbolic links are not followed: the match is performed with MatchOptions.Lstat set, so a symbolic link is
+// counted by its own size rather than that of the file it refers to, even if it is named by literal pattern elements,
+// and a symbolic link to a directory is not descended. Files whose information cannot be fetched are omitted, and any
+// errors encountered while reading directories or fetching information are joined and returned alongside the usage.
+func DiskUsage(g Glob, fsys fs.FS, dir string, byTopLevel bool) (Usage, map[string]Usage, error) {
+	dir = path.Clean(dir)
+
+	var total Usage
+	var groups map[string]Usage
+	if byTopLevel {
+		groups = map[string]Usage{}
+	}
+
+	var errs []error
+	for r := range MatchResults(g, fsys, dir, MatchOptions{Lstat: true}) {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+			continue
+		}
+		info, err := r.DirEntry.Info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		total.add(info.Size())
+		if byTopLevel {
+			name, _, _ := strings.Cut(relativePath(dir, r.Path), "/")
+			u := groups[name]
+			u.add(info.Size())
+			groups[name] = u
+		}
+	}
+	return total, groups, errors.Join(errs...)
+}
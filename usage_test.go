@@ -0,0 +1,58 @@
+package glob
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	fsys := &countingFS{
+		MapFS: fstest.MapFS{
+			"data/a/x.bin":   {Data: make([]byte, 100)},
+			"data/a/y.bin":   {Data: make([]byte, 50)},
+			"data/b/c/z.bin": {Data: make([]byte, 25)},
+			"data/b/c/z.txt": {Data: make([]byte, 1000)},
+			"data/top.bin":   {Data: make([]byte, 5)},
+			"data/link.bin":  {Data: []byte("a/x.bin"), Mode: fs.ModeSymlink},
+		},
+		reads: map[string]int{},
+		stats: map[string]int{},
+	}
+
+	g, err := New([]string{"**/*.bin"}, nil)
+	require.NoError(t, err)
+
+	total, groups, err := DiskUsage(g, fsys, "data/", true)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Files: 5, Bytes: 187}, total)
+	assert.Equal(t, map[string]Usage{
+		"a":        {Files: 2, Bytes: 150},
+		"b":        {Files: 1, Bytes: 25},
+		"link.bin": {Files: 1, Bytes: 7},
+		"top.bin":  {Files: 1, Bytes: 5},
+	}, groups)
+
+	// The sizes are read from the directory entries found by the match.
+	assert.Empty(t, fsys.stats)
+
+	total, groups, err = DiskUsage(g, fsys, "data/b", false)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Files: 1, Bytes: 25}, total)
+	assert.Nil(t, groups)
+
+	// A symbolic link named by literal pattern elements is counted by its own size.
+	literal, err := New([]string{"data/link.bin"}, nil)
+	require.NoError(t, err)
+	total, _, err = DiskUsage(literal, fsys.MapFS, ".", false)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Files: 1, Bytes: 7}, total)
+
+	failing := readDirFailFS{MapFS: fsys.MapFS, dir: "data/a"}
+	total, _, err = DiskUsage(g, failing, "data", false)
+	assert.Error(t, err)
+	assert.Equal(t, Usage{Files: 3, Bytes: 37}, total)
+}
@@ -0,0 +1,1020 @@
+package glob
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// allPatterns is the include list for a subtree whose entries all match.
+var allPatterns = []pattern{{globstar}}
+
+// A frame is a directory whose entries are being matched.
+type frame struct {
+	dir     string
+	entry   fs.DirEntry
+	entries []fs.DirEntry
+	next    int
+
+	// all is true if every entry in the directory matches.
+	all bool
+
+	// yieldAfter is true if the directory should be yielded after its contents.
+	yieldAfter bool
+
+	// pending is true if the directory should be yielded before its contents but is being held back until a file
+	// beneath it is yielded. found is true once a file beneath the directory has been yielded. Both are only used if
+	// the walker omits empty directories.
+	pending bool
+	found   bool
+
+	// shadowed is true once a directory beneath the directory has been yielded. It is only used if the walker yields
+	// leaf directories only.
+	shadowed bool
+
+	// inScope is true if the directory or one of its ancestors is matched by the walker's scope.
+	inScope bool
+
+	// resuming is true if the directory is an ancestor of the checkpoint from which the walker is resuming, so some
+	// of its entries have already been processed.
+	resuming bool
+
+	// exclusion records the last exclude pattern that matched the directory or one of its ancestors.
+	exclusion exclusion
+
+	include      []pattern
+	exclude      []pattern
+	includeIndex patternIndex
+	excludeIndex patternIndex
+
+	// owned holds the pooled pattern lists that must be released when the frame is popped.
+	owned []*[]pattern
+}
+
+// GoString formats the frame for debugging.
+func (f *frame) GoString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "frame{dir: %q", f.dir)
+	if f.all {
+		b.WriteString(", all: true")
+	}
+	if f.exclusion != (exclusion{}) {
+		fmt.Fprintf(&b, ", exclusion: {rule: %d, negate: %v}", f.exclusion.rule, f.exclusion.negate)
+	}
+	for _, list := range []struct {
+		name     string
+		patterns []pattern
+	}{{"include", f.include}, {"exclude", f.exclude}} {
+		if len(list.patterns) != 0 {
+			fmt.Fprintf(&b, ", %v: [", list.name)
+			for i, p := range list.patterns {
+				if i != 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(p.GoString())
+			}
+			b.WriteString("]")
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// A walker matches patterns against the contents of a directory tree.
+//
+// The traversal is iterative: rather than recursing into each subdirectory, the walker maintains an explicit stack of
+// the directories whose entries are being matched. This keeps the traversal's memory use independent of the goroutine
+// stack, so arbitrarily deep trees can be matched.
+type walker struct {
+	fsys        fs.FS
+	preDirs     bool
+	postDirs    bool
+	includeDirs bool
+	includeRoot bool
+	yield       func(string, error) bool
+
+	// entry is the directory entry for the path being yielded, if any, and parent is true if the path is being yielded
+	// only because it is the parent of a match.
+	entry  fs.DirEntry
+	parent bool
+
+	maxOps   int
+	ops      int
+	exceeded bool
+
+	descend func(string, fs.DirEntry) bool
+
+	lstat       bool
+	skipInvalid bool
+	skipSpecial bool
+	omitEmpty   bool
+	leafDirs    bool
+
+	// parents is true if the walker yields the parents of each match. chain holds the names, relative to the starting
+	// directory, of the deepest directory whose parents and itself have been yielded.
+	parents bool
+	chain   []string
+
+	// markers lists the names of files that prevent the walker from matching the contents of the directories that
+	// contain them.
+	markers []string
+
+	filter func(string) bool
+
+	// checkpoint is called when a directory is finished. If resuming is true, resumeFrom is the checkpoint from which
+	// the walker resumes and resume holds its names relative to the starting directory.
+	checkpoint func(string)
+	resuming   bool
+	resumeFrom string
+	resume     []string
+
+	shard Shard
+
+	// root is the starting directory, and scope is the scope function for the walker's scope glob, if any.
+	root  string
+	scope func([]string) (bool, bool)
+
+	trace func(string, string)
+
+	// prefetches holds the lookups that have been started concurrently, by path. sem limits the number of concurrent
+	// lookups, and running tracks those that have not finished.
+	prefetches map[string]*prefetch
+	sem        chan struct{}
+	running    sync.WaitGroup
+
+	// statProbe is positive if fs.Stat is supported by fsys, negative if it is not, and zero if fsys has not been
+	// probed.
+	statProbe int
+
+	sniff     func(string, []byte) bool
+	sniffSize int
+	sniffBuf  []byte
+
+	stack []frame
+}
+
+// newWalker creates a walker that yields the results of a match with the given options.
+func newWalker(fsys fs.FS, options MatchOptions, yield func(string, error) bool) *walker {
+	if options.InvalidUTF8 != InvalidUTF8Allow {
+		yield = checkUTF8(yield, options.InvalidUTF8, options.Warn)
+	}
+	if options.Dedup {
+		yield = dedup(yield)
+	}
+	return &walker{
+		fsys:        fsys,
+		preDirs:     options.Dirs&DirsPre != 0,
+		postDirs:    options.Dirs&DirsPost != 0,
+		includeDirs: options.Dirs != DirsNone,
+		includeRoot: options.IncludeRoot,
+		yield:       yield,
+		maxOps:      options.MaxOps,
+		descend:     options.descend,
+		lstat:       options.Lstat,
+		trace:       options.Trace,
+		omitEmpty:   options.OmitEmptyDirs,
+		leafDirs:    options.LeafDirs,
+		parents:     options.Parents,
+		skipSpecial: options.SkipSpecialFiles,
+		markers:     options.SkipMarkers,
+		filter:      options.filter,
+		scope:       scopeFunc(options.Scope),
+		skipInvalid: options.InvalidUTF8 == InvalidUTF8Skip,
+		sniff:       options.Sniff,
+		sniffSize:   cmp.Or(options.SniffSize, defaultSniffSize),
+		sem:         newSemaphore(options),
+		shard:       options.Shard,
+		checkpoint:  options.Checkpoint,
+		resuming:    options.Resume != "",
+		resumeFrom:  options.Resume,
+	}
+}
+
+// newSemaphore returns the semaphore that limits concurrent lookups for a match with the given options, or nil if
+// lookups must not be performed concurrently.
+func newSemaphore(options MatchOptions) chan struct{} {
+	if options.StatConcurrency <= 1 || options.MaxOps != 0 || options.Lstat {
+		return nil
+	}
+	return make(chan struct{}, options.StatConcurrency)
+}
+
+// dedup wraps yield so that each distinct path is yielded at most once. Errors are always yielded.
+func dedup(yield func(string, error) bool) func(string, error) bool {
+	seen := map[string]struct{}{}
+	return func(p string, err error) bool {
+		if err == nil {
+			p = path.Clean(p)
+			if _, ok := seen[p]; ok {
+				return true
+			}
+			seen[p] = struct{}{}
+		}
+		return yield(p, err)
+	}
+}
+
+// checkUTF8 wraps yield so that paths that are not valid UTF-8 are handled according to the given mode. Warnings are
+// passed to warn, if it is non-nil.
+func checkUTF8(yield func(string, error) bool, mode InvalidUTF8Mode, warn func(error)) func(string, error) bool {
+	return func(p string, err error) bool {
+		if err != nil || utf8.ValidString(p) {
+			return yield(p, err)
+		}
+		switch mode {
+		case InvalidUTF8Skip:
+			return true
+		case InvalidUTF8Warn:
+			if warn != nil {
+				warn(&InvalidUTF8Error{Path: p})
+			}
+			return yield(p, nil)
+		default:
+			yield(p, &InvalidUTF8Error{Path: p})
+			return false
+		}
+	}
+}
+
+// canDescend reports whether the walker may match the contents of the given directory.
+func (w *walker) canDescend(p string, entry fs.DirEntry) bool {
+	return w.mayDescend(p) && (w.descend == nil || w.descend(p, entry))
+}
+
+// mayDescend reports whether the directory p may contain paths that the walker yields: that is, whether it is in the
+// walker's scope or may contain a directory that is, and whether it is above the walker's shard depth or assigned to
+// its shard.
+func (w *walker) mayDescend(p string) bool {
+	if w.shard.Count != 0 && len(w.names(p)) >= max(w.shard.Depth, 1) && !w.inShard(p) {
+		return false
+	}
+	if w.scope == nil || w.inScope(p) {
+		return true
+	}
+	_, mayContain := w.scope(w.names(p))
+	return mayContain
+}
+
+// inScope reports whether the directory p or one of its ancestors is matched by the walker's scope. p must be the
+// directory of the top frame or one of its descendants.
+func (w *walker) inScope(p string) bool {
+	if w.scope == nil {
+		return true
+	}
+
+	names, start := w.names(p), 0
+	if len(w.stack) != 0 {
+		top := &w.stack[len(w.stack)-1]
+		if top.inScope {
+			return true
+		}
+		start = len(w.names(top.dir)) + 1
+	}
+	for i := start; i <= len(names); i++ {
+		if matched, _ := w.scope(names[:i]); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// done reports whether the path p was processed before the checkpoint from which the walker is resuming: that is,
+// whether p precedes the checkpoint in the order of the traversal, is the checkpoint, or is beneath it.
+func (w *walker) done(p string) bool {
+	names := w.names(p)
+	for i, name := range names {
+		if i == len(w.resume) {
+			return true
+		}
+		if c := strings.Compare(name, w.resume[i]); c != 0 {
+			return c < 0
+		}
+	}
+	return len(names) == len(w.resume)
+}
+
+// ancestor reports whether the directory p is an ancestor of the checkpoint from which the walker is resuming.
+func (w *walker) ancestor(p string) bool {
+	names := w.names(p)
+	return len(names) < len(w.resume) && slices.Equal(names, w.resume[:len(names)])
+}
+
+// inShard reports whether the path p is assigned to the walker's shard.
+func (w *walker) inShard(p string) bool {
+	return w.shard.Count == 0 || w.shard.Contains(relativePath(w.root, path.Clean(p)))
+}
+
+// names returns the names of the path p relative to the starting directory.
+func (w *walker) names(p string) []string {
+	return splitPath(relativePath(w.root, path.Clean(p)))
+}
+
+// spend accounts for a single filesystem operation. If the walker's budget has been exhausted, spend returns an
+// error.
+func (w *walker) spend() error {
+	if w.maxOps != 0 && w.ops >= w.maxOps {
+		w.exceeded = true
+		return &BudgetExceededError{Limit: w.maxOps}
+	}
+	w.ops++
+	return nil
+}
+
+// A ReadDirPrefixFS is a filesystem that can list only the entries of a directory whose names begin with a given
+// prefix, such as an object store that answers prefix listings on the server. When every include pattern that applies
+// to a directory begins with literal text, as 'report-*.csv' does, a match lists the directory's entries with the
+// longest prefix that the patterns share rather than reading every entry.
+type ReadDirPrefixFS interface {
+	fs.FS
+
+	// ReadDirPrefix reads the named directory and returns the entries whose names begin with prefix, sorted by
+	// filename.
+	ReadDirPrefix(name, prefix string) ([]fs.DirEntry, error)
+}
+
+// readDir reads the entries of the named directory. If prefix is non-empty, only the entries whose names begin with
+// prefix are needed.
+func (w *walker) readDir(dir, prefix string) ([]fs.DirEntry, error) {
+	if err := w.spend(); err != nil {
+		return nil, err
+	}
+	if fsys, ok := w.fsys.(ReadDirPrefixFS); ok && prefix != "" {
+		return fsys.ReadDirPrefix(dir, prefix)
+	}
+	return fs.ReadDir(w.fsys, dir)
+}
+
+// namePrefix returns the longest literal prefix shared by every name that the first step of any of the given patterns
+// may match.
+func namePrefix(patterns []pattern) string {
+	var prefix string
+	for i, p := range patterns {
+		m := p[0].matcher
+		if m == nil {
+			return ""
+		}
+		if i == 0 {
+			prefix = m.prefix()
+		} else {
+			prefix = prefix[:commonPrefixLen(prefix, m.prefix())]
+		}
+		if prefix == "" {
+			return ""
+		}
+	}
+	return prefix
+}
+
+// commonPrefixLen returns the length of the longest common prefix of a and b.
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// An lstatFS is a filesystem that can describe a symbolic link without following it.
+type lstatFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// A StatBatchFS is a filesystem that can look up many files in a single call, such as an object store or a filesystem
+// backed by a database. When a match is about to read a directory whose include patterns name several paths entirely
+// by literal elements, it looks up those paths with a single call to StatBatch rather than one call to Stat per path.
+// A batch counts as a single operation toward MatchOptions.MaxOps. StatBatch is not used if MatchOptions.Lstat is set.
+type StatBatchFS interface {
+	fs.FS
+
+	// StatBatch returns information about each of the named files, following symbolic links. The returned slices
+	// must have the same length as names. For each name, either the information or the error that fs.Stat would
+	// return must be set.
+	StatBatch(names []string) ([]fs.FileInfo, []error)
+}
+
+// stat returns information about the named file. If the walker does not follow symbolic links, stat describes the
+// link itself.
+func (w *walker) stat(name string) (fs.FileInfo, error) {
+	if pf, ok := w.prefetches[name]; ok {
+		// The lookup was accounted for when it was started.
+		delete(w.prefetches, name)
+		if pf.done != nil {
+			<-pf.done
+		}
+		return pf.info, pf.err
+	}
+
+	if err := w.spend(); err != nil {
+		return nil, err
+	}
+	if w.lstat {
+		if fsys, ok := w.fsys.(lstatFS); ok {
+			return fsys.Lstat(name)
+		}
+		return w.lookup("lstat", name)
+	}
+	if !w.canStat() {
+		return w.lookup("stat", name)
+	}
+	return fs.Stat(w.fsys, name)
+}
+
+// An entryInfo is file information that supplies its own directory entry, such as the information a filesystem
+// returns for a file that it presents differently when it reads the file's parent directory.
+type entryInfo interface {
+	fs.FileInfo
+	dirEntry() fs.DirEntry
+}
+
+// infoToDirEntry returns the directory entry for the given file information.
+func infoToDirEntry(info fs.FileInfo) fs.DirEntry {
+	if ei, ok := info.(entryInfo); ok {
+		return ei.dirEntry()
+	}
+	return fs.FileInfoToDirEntry(info)
+}
+
+// A prefetch is a lookup that was started before the traversal needed its result. If the lookup runs concurrently with
+// the traversal, done is closed when it finishes.
+type prefetch struct {
+	done chan struct{}
+	info fs.FileInfo
+	err  error
+}
+
+// prefetch looks up the paths beneath dir that are named entirely by literal elements of the given patterns, either in
+// a single batch or concurrently. It is called before dir is read, as the traversal will look up each such path after
+// reading dir, unless the path's parent directories do not exist. Lookups are only started if there are at least two
+// of them.
+func (w *walker) prefetch(dir string, include []pattern) {
+	batchFS, batch := w.fsys.(StatBatchFS)
+	if !batch && w.sem == nil || w.lstat || !w.canStat() {
+		return
+	}
+
+	var names []string
+	for _, p := range include {
+		if len(p) > 1 && !slices.ContainsFunc(p, func(s step) bool { return hasMeta(s.text) || !s.matcher.exact() }) {
+			names = append(names, path.Join(dir, p.String()))
+		}
+	}
+	if len(names) < 2 {
+		return
+	}
+
+	if w.prefetches == nil {
+		w.prefetches = map[string]*prefetch{}
+	}
+
+	if batch {
+		// The batch is skipped rather than failed if it exceeds the budget; the first lookup will fail instead.
+		if w.maxOps != 0 && w.ops >= w.maxOps {
+			return
+		}
+		w.ops++
+
+		infos, errs := batchFS.StatBatch(names)
+		for i, name := range names {
+			w.prefetches[name] = &prefetch{info: infos[i], err: errs[i]}
+		}
+		return
+	}
+
+	for _, name := range names {
+		if _, ok := w.prefetches[name]; ok {
+			continue
+		}
+		w.ops++
+		pf := &prefetch{done: make(chan struct{})}
+		w.prefetches[name] = pf
+		w.running.Add(1)
+		go func() {
+			defer w.running.Done()
+			w.sem <- struct{}{}
+			defer func() { <-w.sem }()
+			pf.info, pf.err = fs.Stat(w.fsys, name)
+			close(pf.done)
+		}()
+	}
+}
+
+// canStat reports whether fs.Stat is supported by the walker's filesystem. fs.Stat is supported if the filesystem
+// implements fs.StatFS or can open and stat its root directory. The filesystem is probed at most once.
+func (w *walker) canStat() bool {
+	if w.statProbe == 0 {
+		w.statProbe = -1
+		if _, ok := w.fsys.(fs.StatFS); ok {
+			w.statProbe = 1
+		} else if f, err := w.fsys.Open("."); err == nil {
+			if _, err := f.Stat(); err == nil {
+				w.statProbe = 1
+			}
+			f.Close()
+		}
+	}
+	return w.statProbe > 0
+}
+
+// lookup returns information about the named file by finding its entry in its parent directory. Directory entries
+// describe symbolic links rather than their targets.
+func (w *walker) lookup(op, name string) (fs.FileInfo, error) {
+	dir, base := path.Split(name)
+	entries, err := fs.ReadDir(w.fsys, path.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+	i, ok := slices.BinarySearchFunc(entries, base, func(e fs.DirEntry, name string) int {
+		return strings.Compare(e.Name(), name)
+	})
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return entries[i].Info()
+}
+
+// fail yields an error for the given path. It returns false if the traversal should stop.
+func (w *walker) fail(p string, err error) bool {
+	w.entry, w.parent = nil, false
+	return w.yield(p, err) && !w.exceeded
+}
+
+// emit yields a matching path and its directory entry. The entry is nil for the starting directory. If the walker
+// yields the parents of each match, the parents that have not been yielded are yielded first.
+func (w *walker) emit(p string, entry fs.DirEntry) bool {
+	if w.parents && !w.emitParents(p, entry != nil && entry.IsDir()) {
+		return false
+	}
+	w.entry, w.parent = entry, false
+	return w.yield(p, nil)
+}
+
+// emitParents yields the directories between the starting directory and the path p that have not already been
+// yielded, outermost first. Because the walker visits each directory's contents together, a directory is not yielded
+// again once the walker has left it. If p is a directory, it is recorded as yielded. It returns false if the traversal
+// should stop.
+func (w *walker) emitParents(p string, isDir bool) bool {
+	names := w.names(p)
+	if len(names) == 0 {
+		return true
+	}
+	dirs := names[:len(names)-1]
+
+	n := 0
+	for n < len(w.chain) && n < len(dirs) && w.chain[n] == dirs[n] {
+		n++
+	}
+	w.chain = w.chain[:n]
+	for _, name := range dirs[n:] {
+		w.chain = append(w.chain, name)
+		parent := path.Join(w.root, path.Join(w.chain...))
+		w.entry, w.parent = w.frameEntry(parent), true
+		if !w.yield(parent, nil) {
+			return false
+		}
+	}
+	if isDir {
+		w.chain = append(w.chain, names[len(names)-1])
+	}
+	return true
+}
+
+// frameEntry returns the directory entry of the directory dir if it is on the stack, or nil if it is not.
+func (w *walker) frameEntry(dir string) fs.DirEntry {
+	for i := range w.stack {
+		if w.stack[i].dir == dir {
+			return w.stack[i].entry
+		}
+	}
+	return nil
+}
+
+// walk matches the given patterns against the contents of dir.
+func (w *walker) walk(dir string, include, exclude []pattern) {
+	// Wait for any concurrent lookups so that the filesystem is not accessed after the match returns.
+	defer w.running.Wait()
+
+	w.root = path.Clean(dir)
+	if w.resuming {
+		w.resume = w.names(w.resumeFrom)
+		if w.done(dir) {
+			return
+		}
+	}
+
+	// The starting directory is matched by a pattern of '**', which matches the empty sequence of names.
+	yieldRoot := w.includeRoot && always(include) && !excludesAll(exclusion{}, exclude)
+	if !w.enter(dir, nil, yieldRoot, include, exclude, exclusion{}, nil) {
+		w.unwind()
+		return
+	}
+
+	for len(w.stack) != 0 {
+		f := &w.stack[len(w.stack)-1]
+		if f.next == len(f.entries) {
+			dir, entry, yieldAfter := w.pop()
+			if yieldAfter && !w.emitDir(dir, entry) {
+				w.unwind()
+				return
+			}
+			if w.checkpoint != nil {
+				w.checkpoint(dir)
+			}
+			continue
+		}
+
+		entry := f.entries[f.next]
+		f.next++
+		if !w.step(f, entry) {
+			w.unwind()
+			return
+		}
+	}
+}
+
+// step matches a single directory entry. If the entry is a directory that may contain matches, step pushes a frame
+// for the directory. step returns false if the traversal should stop.
+func (w *walker) step(f *frame, entry fs.DirEntry) bool {
+	name := entry.Name()
+	if w.skipInvalid && !utf8.ValidString(name) {
+		return true
+	}
+	p := path.Join(f.dir, name)
+	if f.resuming && w.done(p) {
+		return true
+	}
+
+	if f.all {
+		if !entry.IsDir() {
+			return w.yieldFile(p, entry)
+		}
+		if w.canDescend(p, entry) {
+			return w.enter(p, entry, true, allPatterns, nil, exclusion{}, nil)
+		}
+		return !w.includeDirs || w.yieldDir(p, entry)
+	}
+
+	var included bool
+	if !entry.IsDir() {
+		e := f.exclusion
+		for pat := range f.excludeIndex.candidates(name) {
+			if pat.matchFile(name) {
+				e = e.update(pat)
+			}
+		}
+		if e.excluded() {
+			return true
+		}
+		for pat := range f.includeIndex.candidates(name) {
+			if pat.matchFile(name) {
+				included = true
+				break
+			}
+		}
+	} else {
+		nextInclude, nextExclude := getPatternList(), getPatternList()
+		owned := []*[]pattern{nextInclude, nextExclude}
+
+		e := f.exclusion
+		for pat := range f.excludeIndex.candidates(name) {
+			if pat.matchDir(name, nextExclude) {
+				e = e.update(pat)
+			}
+		}
+		if e.final(*nextExclude) {
+			release(owned)
+			return true
+		}
+		for pat := range f.includeIndex.candidates(name) {
+			if pat.matchDir(name, nextInclude) {
+				included = w.includeDirs && !e.excluded()
+			}
+		}
+
+		if len(*nextInclude) != 0 && !excludesAll(e, *nextExclude) && w.canDescend(p, entry) {
+			// If there is more to do, the directory's frame will yield the matched directory.
+			return w.enter(p, entry, included, *nextInclude, *nextExclude, e, owned)
+		}
+		release(owned)
+		return !included || w.yieldDir(p, entry)
+	}
+	return !included || w.yieldFile(p, entry)
+}
+
+// enter begins matching the contents of dir, whose directory entry is entry. If yieldDir is true, dir is yielded before
+// its contents. e records the exclude pattern that last matched dir or one of its ancestors. Ownership of the given
+// pooled lists passes to enter. enter returns false if the traversal should stop.
+//
+// If the include patterns begin with literal directory names, enter skips directly to the directory they name: the
+// intermediate directories are not read or stat'd, as the first operation beneath them implicitly verifies that they
+// exist. If that operation fails for any reason other than a missing file, the intermediate directories are retraced
+// one at a time so that the failure is reported as it would be otherwise. Directories are always retraced if the walker
+// must inspect them before descending or must not follow symbolic links.
+func (w *walker) enter(dir string, entry fs.DirEntry, yieldDir bool, include, exclude []pattern, e exclusion, owned []*[]pattern) bool {
+	startDir, startEntry, startInclude, startExclude, startExclusion := dir, entry, include, exclude, e
+	verify, skipped := w.descend != nil || w.lstat || len(w.markers) != 0, false
+
+retrace:
+	for {
+		all := false
+		for !yieldDir {
+			if always(include) {
+				if len(exclude) == 0 {
+					all = true
+					break
+				}
+				include = allPatterns
+				break
+			}
+
+			name, nextInclude, ok := literal(include)
+			if !ok {
+				break
+			}
+			if w.marked(dir) {
+				release(owned)
+				return true
+			}
+
+			// The next element is a literal, so stat it instead of reading the directory.
+			fileExclusion := e
+			for _, p := range exclude {
+				if p.matchFile(name) {
+					fileExclusion = fileExclusion.update(p)
+				}
+			}
+			if fileExclusion.excluded() && len(nextInclude) == 0 {
+				release(owned)
+				return true
+			}
+
+			p := path.Join(dir, name)
+			if w.resuming && w.done(p) {
+				release(owned)
+				return true
+			}
+			if len(nextInclude) != 0 && !verify {
+				if !w.mayDescend(p) {
+					release(owned)
+					return true
+				}
+
+				// Assume that the element names a directory.
+				nextExclude := getPatternList()
+				owned = append(owned, nextExclude)
+				for _, p := range exclude {
+					if p.matchDir(name, nextExclude) {
+						e = e.update(p)
+					}
+				}
+				if e.final(*nextExclude) || excludesAll(e, *nextExclude) {
+					release(owned)
+					return true
+				}
+				dir, entry, include, exclude, skipped = p, nil, nextInclude, *nextExclude, true
+				continue
+			}
+
+			info, err := w.stat(p)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					release(owned)
+					return true
+				}
+				if skipped && !w.exceeded {
+					dir, entry, include, exclude, e = startDir, startEntry, startInclude, startExclude, startExclusion
+					verify, skipped = true, false
+					continue retrace
+				}
+				release(owned)
+				return w.fail(dir, err)
+			}
+			infoEntry := infoToDirEntry(info)
+			if info.IsDir() {
+				nextExclude := getPatternList()
+				owned = append(owned, nextExclude)
+				for _, p := range exclude {
+					if p.matchDir(name, nextExclude) {
+						e = e.update(p)
+					}
+				}
+				if e.final(*nextExclude) {
+					release(owned)
+					return true
+				}
+				if len(nextInclude) != 0 && !excludesAll(e, *nextExclude) {
+					if !w.canDescend(p, infoEntry) {
+						release(owned)
+						return true
+					}
+					// The literal directory is not itself a match, so it is never yielded.
+					dir, entry, include, exclude = p, infoEntry, nextInclude, *nextExclude
+					continue
+				}
+				release(owned)
+				return e.excluded() || len(nextInclude) != 0 || !w.includeDirs || w.yieldDir(p, infoEntry)
+			}
+			release(owned)
+			return fileExclusion.excluded() || len(nextInclude) != 0 || w.yieldFile(p, infoEntry)
+		}
+
+		w.prefetch(dir, include)
+		var prefix string
+		if !all && len(w.markers) == 0 {
+			prefix = namePrefix(include)
+		}
+		entries, err := w.readDir(dir, prefix)
+		if err != nil {
+			if skipped && !w.exceeded {
+				if errors.Is(err, fs.ErrNotExist) {
+					release(owned)
+					return true
+				}
+				dir, entry, include, exclude, e = startDir, startEntry, startInclude, startExclude, startExclusion
+				verify, skipped = true, false
+				continue retrace
+			}
+			release(owned)
+			return w.fail(dir, err)
+		}
+		if w.hasMarker(entries) {
+			entries = nil
+		}
+		inScope := w.inScope(dir)
+		yieldDir = yieldDir && inScope && w.inShard(dir)
+
+		// If the directory is an ancestor of the checkpoint, it has already been yielded before its contents.
+		resuming := w.resuming && w.ancestor(dir)
+		yieldBefore := yieldDir && w.preDirs && !resuming && !w.leafDirs
+		yieldAfter := yieldDir && (w.postDirs || w.leafDirs)
+		pending := yieldBefore && w.omitEmpty
+		if yieldBefore && !pending && !w.emitDir(dir, entry) {
+			release(owned)
+			return false
+		}
+
+		f := frame{dir: dir, entry: entry, entries: entries, all: all, yieldAfter: yieldAfter, pending: pending, inScope: inScope, resuming: resuming, exclusion: e, owned: owned}
+		if !all {
+			f.include, f.exclude = include, exclude
+			f.includeIndex, f.excludeIndex = newPatternIndex(include), newPatternIndex(exclude)
+		}
+		if w.trace != nil {
+			w.trace(dir, f.GoString())
+		}
+		w.stack = append(w.stack, f)
+		return true
+	}
+}
+
+// marked reports whether the directory dir contains one of the walker's marker files. It is used for directories that
+// are not read; the entries of directories that are read are checked with hasMarker.
+func (w *walker) marked(dir string) bool {
+	for _, m := range w.markers {
+		if _, err := w.stat(path.Join(dir, m)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMarker reports whether the given directory entries include one of the walker's marker files.
+func (w *walker) hasMarker(entries []fs.DirEntry) bool {
+	if len(w.markers) == 0 {
+		return false
+	}
+	return slices.ContainsFunc(entries, func(e fs.DirEntry) bool { return slices.Contains(w.markers, e.Name()) })
+}
+
+// specialModes are the type bits of files that are not regular files, directories, or symbolic links.
+const specialModes = fs.ModeNamedPipe | fs.ModeSocket | fs.ModeDevice | fs.ModeCharDevice | fs.ModeIrregular
+
+// yieldFile yields a matching file. If the walker has a sniff predicate, the file is yielded only if the predicate
+// accepts the file's contents.
+func (w *walker) yieldFile(p string, entry fs.DirEntry) bool {
+	if w.skipSpecial && entry != nil && entry.Type()&specialModes != 0 {
+		return true
+	}
+	if w.filter != nil && !w.filter(p) || !w.inScope(path.Dir(p)) || !w.inShard(p) {
+		return true
+	}
+	if w.sniff != nil {
+		head, err := w.readHead(p)
+		if err != nil {
+			return w.fail(p, err)
+		}
+		if !w.sniff(p, head) {
+			return true
+		}
+	}
+	if w.omitEmpty && !w.flush() {
+		return false
+	}
+	return w.emit(p, entry)
+}
+
+// flush records that a file is about to be yielded beneath each directory on the stack and yields any directories
+// that are being held back, outermost first. It returns false if the traversal should stop.
+func (w *walker) flush() bool {
+	i := len(w.stack)
+	for i > 0 && !w.stack[i-1].found {
+		i--
+	}
+	for ; i < len(w.stack); i++ {
+		f := &w.stack[i]
+		f.found = true
+		if f.pending {
+			f.pending = false
+			if !w.emitDir(f.dir, f.entry) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// emitDir yields a matching directory and its directory entry unless the walker's filter rejects it. If the walker
+// yields leaf directories only, the directories on the stack, which are the directory's ancestors, are marked as
+// shadowed.
+func (w *walker) emitDir(p string, entry fs.DirEntry) bool {
+	if w.filter != nil && !w.filter(p) {
+		return true
+	}
+	if w.leafDirs {
+		for i := range w.stack {
+			w.stack[i].shadowed = true
+		}
+	}
+	return w.emit(p, entry)
+}
+
+// readHead reads up to sniffSize bytes from the start of the named file. The returned slice is only valid until the
+// next call to readHead.
+func (w *walker) readHead(name string) ([]byte, error) {
+	if err := w.spend(); err != nil {
+		return nil, err
+	}
+	f, err := w.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if w.sniffBuf == nil {
+		w.sniffBuf = make([]byte, w.sniffSize)
+	}
+	n, err := io.ReadFull(f, w.sniffBuf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return w.sniffBuf[:n], nil
+}
+
+// yieldDir yields a matching directory whose contents are not being matched. The directory is yielded once for each of
+// the pre- and post-order positions selected by the walker's options, or once if the walker yields leaf directories
+// only. If the walker omits empty directories, the directory is not yielded.
+func (w *walker) yieldDir(p string, entry fs.DirEntry) bool {
+	if w.omitEmpty || !w.inScope(p) || !w.inShard(p) {
+		return true
+	}
+	if w.leafDirs {
+		return w.emitDir(p, entry)
+	}
+	if w.preDirs && !w.emitDir(p, entry) {
+		return false
+	}
+	return !w.postDirs || w.emitDir(p, entry)
+}
+
+// pop removes the top frame from the stack. It returns the frame's directory, its entry, and whether the directory should
+// be yielded after its contents.
+func (w *walker) pop() (string, fs.DirEntry, bool) {
+	f := &w.stack[len(w.stack)-1]
+	dir, entry, yieldAfter := f.dir, f.entry, f.yieldAfter && (f.found || !w.omitEmpty) && !f.shadowed
+	release(f.owned)
+	*f = frame{}
+	w.stack = w.stack[:len(w.stack)-1]
+	return dir, entry, yieldAfter
+}
+
+// unwind removes all frames from the stack.
+func (w *walker) unwind() {
+	for len(w.stack) != 0 {
+		w.pop()
+	}
+}
+
+// release returns the given pattern lists to the pool.
+func release(lists []*[]pattern) {
+	for _, l := range lists {
+		putPatternList(l)
+	}
+}